@@ -3,6 +3,7 @@ package cache
 import (
 	"database/sql"
 	"dns-resolver-go/dns"
+	"net/netip"
 	"os"
 	"time"
 
@@ -54,10 +55,52 @@ func createTable(db *sql.DB) error {
 			address TEXT NOT NULL,
 			ttl INTEGER NOT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			expired_at DATETIME
+			expired_at DATETIME,
+			scope TEXT NOT NULL DEFAULT '',
+			rdata BLOB
 		);
 	`)
-	return err
+	if err != nil {
+		return err
+	}
+	return migrateTable(db)
+}
+
+// addedColumns lists the columns added to the dns_records table by later versions, with their definition.
+var addedColumns = []struct{ name, definition string }{
+	// Records cached before the scope was stored were all resolved without client subnet
+	{"scope", `scope TEXT NOT NULL DEFAULT ''`},
+	// Records cached before the resource data was stored only have their presentation format
+	{"rdata", `rdata BLOB`},
+}
+
+// migrateTable adds the columns missing from tables created by older versions.
+func migrateTable(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('dns_records')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, column := range addedColumns {
+		if columns[column.name] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE dns_records ADD COLUMN ` + column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ClearExpiredRecords deletes all the expired records from the cache.
@@ -68,9 +111,34 @@ func (client *CacheClient) ClearExpiredRecords() error {
 }
 
 // Get gets the records with the given domain from the cache and returns them as a slice of ResourceRecord.
+// Records scoped to a client subnet are not returned, see GetBySubnet.
 func (client *CacheClient) Get(domain string) ([]dns.ResourceRecord, error) {
+	return client.getRecords(netip.Prefix{}, `SELECT `+recordColumns+` FROM dns_records WHERE domain = ?`, domain)
+}
+
+// GetByType gets the records with the given domain and record type from the cache and returns them as a slice of ResourceRecord.
+// Records scoped to a client subnet are not returned, see GetBySubnet.
+func (client *CacheClient) GetByType(domain string, recordType uint16) ([]dns.ResourceRecord, error) {
+	return client.GetBySubnet(domain, recordType, netip.Prefix{})
+}
+
+// GetBySubnet gets the records with the given domain and record type that can be served to the given client subnet.
+// These are the records valid for any client, and the records whose scope covers the subnet.
+// If the subnet is invalid (e.g. the zero value), only the records valid for any client are returned.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7871#section-7.3.2 for more information
+func (client *CacheClient) GetBySubnet(domain string, recordType uint16, subnet netip.Prefix) ([]dns.ResourceRecord, error) {
+	return client.getRecords(subnet, `SELECT `+recordColumns+` FROM dns_records WHERE domain = ? AND type = ?`, domain, recordType)
+}
+
+// recordColumns lists the columns of the dns_records table scanned by getRecords.
+const recordColumns = `id, domain, type, address, ttl, created_at, expired_at, scope, rdata`
+
+// getRecords runs the given query on the dns_records table and returns the selected rows as a slice of ResourceRecord.
+// The rows scoped to a client subnet which does not cover the given subnet are skipped.
+func (client *CacheClient) getRecords(subnet netip.Prefix, query string, args ...any) ([]dns.ResourceRecord, error) {
 	var messages []dns.ResourceRecord
-	rows, err := client.db.Query(`SELECT * FROM dns_records WHERE domain = ?`, domain)
+	rows, err := client.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -80,28 +148,84 @@ func (client *CacheClient) Get(domain string) ([]dns.ResourceRecord, error) {
 		var id int
 		var createdAt time.Time
 		var expiredAt time.Time
+		var scope string
+		var rData []byte
 
-		err := rows.Scan(&id, &message.Name, &message.Type, &message.RDataParsed, &message.TTL, &createdAt, &expiredAt)
+		err := rows.Scan(&id, &message.Name, &message.Type, &message.RDataParsed, &message.TTL, &createdAt, &expiredAt, &scope, &rData)
 		if err != nil {
 			return nil, err
 		}
+		if scope != "" && !scopeCovers(scope, subnet) {
+			continue
+		}
+		// Records cached by older versions have no resource data
+		if rData != nil {
+			message.RDLength = uint16(len(rData))
+			message.RData = rData
+			message.Data, _ = dns.UnpackRData(message.Type, rData)
+		}
 		messages = append(messages, message)
 	}
 	return messages, nil
 }
 
+// scopeCovers returns whether a record cached for the given scope can be served to the client subnet.
+// The subnet must be inside the scope, and not less specific than it.
+func scopeCovers(scope string, subnet netip.Prefix) bool {
+	scopePrefix, err := netip.ParsePrefix(scope)
+	if err != nil || !subnet.IsValid() {
+		return false
+	}
+	return scopePrefix.Bits() <= subnet.Bits() && scopePrefix.Contains(subnet.Addr())
+}
+
 // Insert inserts a new record into the cache while deleting the existing record with the same domain, address & type.
+// The record is valid for any client.
 func (client *CacheClient) Insert(domain string, recordType uint16, address string, ttl int) error {
+	return client.InsertScoped(domain, recordType, address, ttl, netip.Prefix{})
+}
+
+// InsertScoped inserts a new record into the cache which is only valid for the clients in the given scope,
+// while deleting the existing record with the same domain, address, type & scope.
+// The scope is the subnet returned by a server answering with EDNS Client Subnet.
+// If the scope is invalid (e.g. the zero value) or has a zero prefix length, the record is valid for any client.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7871#section-7.3.1 for more information
+func (client *CacheClient) InsertScoped(domain string, recordType uint16, address string, ttl int, scope netip.Prefix) error {
+	return client.insert(domain, recordType, address, nil, ttl, scope)
+}
+
+// InsertRecord inserts the record into the cache for the domain, which is only valid for the clients in the given scope,
+// see InsertScoped.
+// The resource data of the record is stored byte for byte along with its presentation format,
+// so that the records of types which are not supported are served unchanged.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3597#section-4 for more information
+func (client *CacheClient) InsertRecord(domain string, record dns.ResourceRecord, scope netip.Prefix) error {
+	rData := record.RData
+	if rData == nil {
+		rData = []byte{}
+	}
+	return client.insert(domain, record.Type, record.RDataParsed, rData, int(record.TTL), scope)
+}
+
+// insert inserts a new record into the cache while deleting the existing record with the same domain, address, type & scope.
+// The resource data is nil if it is not known.
+func (client *CacheClient) insert(domain string, recordType uint16, address string, rData []byte, ttl int, scope netip.Prefix) error {
+	scopeText := ""
+	if scope.IsValid() && scope.Bits() > 0 {
+		scopeText = scope.Masked().String()
+	}
 	expiryAt := time.Now().Add(time.Duration(ttl) * time.Second)
 	// Create Transaction
 	tx, err := client.db.Begin()
 	if err != nil {
 		return err
 	}
-	// Delete the existing record with the same domain, address & type.
-	tx.Exec(`DELETE FROM dns_records WHERE domain = ? AND address = ? AND type = ?`, domain, address, recordType)
+	// Delete the existing record with the same domain, address, type & scope.
+	tx.Exec(`DELETE FROM dns_records WHERE domain = ? AND address = ? AND type = ? AND scope = ?`, domain, address, recordType, scopeText)
 	// Then insert the new record
-	tx.Exec(`INSERT INTO dns_records (domain, type, address, ttl, created_at, expired_at) VALUES (?, ?, ?, ?, ?, ?)`, domain, recordType, address, ttl, time.Now(), expiryAt)
+	tx.Exec(`INSERT INTO dns_records (domain, type, address, ttl, created_at, expired_at, scope, rdata) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, domain, recordType, address, ttl, time.Now(), expiryAt, scopeText, rData)
 	err = tx.Commit()
 	return err
 }
@@ -1,7 +1,10 @@
 package cache
 
 import (
+	"database/sql"
 	"dns-resolver-go/dns"
+	"net/netip"
+	"os"
 	"testing"
 	"time"
 
@@ -58,6 +61,136 @@ func TestCacheClient(t *testing.T) {
 		assert.Equal(t, "127.0.0.1", records[0].RDataParsed)
 	})
 
+	t.Run("Should Get Record By Type", func(t *testing.T) {
+		client, err := NewClient(TEST_CACHE_PATH)
+		assert.Nil(t, err)
+		assert.NotNil(t, client)
+		defer client.Close()
+		defer client.Delete("txt.example.com")
+
+		err = client.Insert("txt.example.com", dns.TypeA, "127.0.0.2", 300)
+		assert.Nil(t, err)
+		err = client.Insert("txt.example.com", dns.TypeTXT, `"v=spf1 -all"`, 300)
+		assert.Nil(t, err)
+
+		records, err := client.GetByType("txt.example.com", dns.TypeTXT)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, `"v=spf1 -all"`, records[0].RDataParsed)
+
+		records, err = client.GetByType("txt.example.com", dns.TypeA)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "127.0.0.2", records[0].RDataParsed)
+	})
+
+	t.Run("Should only serve scoped records to the client subnets they cover", func(t *testing.T) {
+		client, err := NewClient(TEST_CACHE_PATH)
+		assert.Nil(t, err)
+		assert.NotNil(t, client)
+		defer client.Close()
+		defer client.Delete("cdn.example.com")
+
+		err = client.InsertScoped("cdn.example.com", dns.TypeA, "192.0.2.10", 300, netip.MustParsePrefix("198.51.100.0/24"))
+		assert.Nil(t, err)
+		err = client.InsertScoped("cdn.example.com", dns.TypeA, "192.0.2.20", 300, netip.MustParsePrefix("203.0.113.0/24"))
+		assert.Nil(t, err)
+
+		records, err := client.GetBySubnet("cdn.example.com", dns.TypeA, netip.MustParsePrefix("198.51.100.0/24"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "192.0.2.10", records[0].RDataParsed)
+
+		records, err = client.GetBySubnet("cdn.example.com", dns.TypeA, netip.MustParsePrefix("198.51.0.0/16"))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(records))
+
+		records, err = client.GetBySubnet("cdn.example.com", dns.TypeA, netip.MustParsePrefix("2001:db8::/56"))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(records))
+
+		records, err = client.GetByType("cdn.example.com", dns.TypeA)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(records))
+
+		err = client.InsertScoped("cdn.example.com", dns.TypeA, "192.0.2.30", 300, netip.MustParsePrefix("0.0.0.0/0"))
+		assert.Nil(t, err)
+		records, err = client.GetBySubnet("cdn.example.com", dns.TypeA, netip.MustParsePrefix("203.0.113.0/24"))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(records))
+		records, err = client.GetByType("cdn.example.com", dns.TypeA)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "192.0.2.30", records[0].RDataParsed)
+	})
+
+	t.Run("Should keep the resource data of the records byte for byte", func(t *testing.T) {
+		client, err := NewClient(TEST_CACHE_PATH)
+		assert.Nil(t, err)
+		defer client.Close()
+
+		unknown := dns.NewResourceRecord("unknown.example.com", 65280, dns.ClassIN, 300, 3, []byte{0, 0xff, 7})
+		err = client.InsertRecord("unknown.example.com", *unknown, netip.Prefix{})
+		assert.Nil(t, err)
+		empty := dns.NewResourceRecord("unknown.example.com", 65281, dns.ClassIN, 300, 0, nil)
+		err = client.InsertRecord("unknown.example.com", *empty, netip.Prefix{})
+		assert.Nil(t, err)
+
+		records, err := client.GetByType("unknown.example.com", 65280)
+		assert.Nil(t, err)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, []byte{0, 0xff, 7}, records[0].RData)
+			assert.Equal(t, uint16(3), records[0].RDLength)
+			assert.Equal(t, `\# 3 00ff07`, records[0].RDataParsed)
+			assert.True(t, unknown.Data.Equal(records[0].Data))
+		}
+		records, err = client.GetByType("unknown.example.com", 65281)
+		assert.Nil(t, err)
+		if assert.Equal(t, 1, len(records)) {
+			assert.NotNil(t, records[0].RData)
+			assert.Equal(t, `\# 0`, records[0].RDataParsed)
+		}
+
+		err = client.Insert("unknown.example.com", dns.TypeA, "192.0.2.1", 300)
+		assert.Nil(t, err)
+		records, err = client.GetByType("unknown.example.com", dns.TypeA)
+		assert.Nil(t, err)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Nil(t, records[0].RData)
+			assert.Nil(t, records[0].Data)
+		}
+		client.Delete("unknown.example.com")
+	})
+
+	t.Run("Should add the missing columns to an existing table", func(t *testing.T) {
+		const OLD_CACHE_PATH = "./old.db"
+		defer os.Remove(OLD_CACHE_PATH)
+		db, err := sql.Open("sqlite3", OLD_CACHE_PATH)
+		assert.Nil(t, err)
+		_, err = db.Exec(`CREATE TABLE dns_records (
+			id INTEGER PRIMARY KEY,
+			domain TEXT NOT NULL,
+			type INTEGER NOT NULL,
+			address TEXT NOT NULL,
+			ttl INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expired_at DATETIME
+		)`)
+		assert.Nil(t, err)
+		_, err = db.Exec(`INSERT INTO dns_records (domain, type, address, ttl, created_at, expired_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			"example.com", dns.TypeA, "127.0.0.1", 300, time.Now(), time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		db.Close()
+
+		client, err := NewClient(OLD_CACHE_PATH)
+		assert.Nil(t, err)
+		defer client.Close()
+		records, err := client.GetByType("example.com", dns.TypeA)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, "127.0.0.1", records[0].RDataParsed)
+	})
+
 	t.Run("Should Delete Record", func(t *testing.T) {
 		client, err := NewClient(TEST_CACHE_PATH)
 		assert.Nil(t, err)
@@ -0,0 +1,139 @@
+package dns
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// NewQuery creates a query for the records of the given type for the name, in the IN class, with a random ID.
+// The name is converted to its A-labels as by NewQuestion. The flags and the EDNS pseudo-record are set
+// with the methods returning the message, which can be chained:
+//
+//	query := dns.NewQuery("example.com", dns.TypeA).WithRecursion().WithEDNS(1232).WithDO()
+func NewQuery(name string, qType uint16) *DNSMessage {
+	header := NewHeader(RandomID(), 0, 1, 0, 0, 0)
+	return NewDNSMessage(*header, []Question{*NewQuestion(name, qType, ClassIN)})
+}
+
+// RandomID returns a random message ID, which makes the responses to a query harder to spoof.
+//
+// See https://datatracker.ietf.org/doc/html/rfc5452#section-4.3 for more information
+func RandomID() uint16 {
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic("dns: failed to read random bytes: " + err.Error())
+	}
+	return binary.BigEndian.Uint16(id[:])
+}
+
+// WithRecursion sets the RD bit, asking the server to resolve the query recursively.
+func (m *DNSMessage) WithRecursion() *DNSMessage {
+	return m.updateFlags(func(flags *HeaderFlag) { flags.RD = true })
+}
+
+// WithCheckingDisabled sets the CD bit, asking the server to skip the DNSSEC validation of the data.
+func (m *DNSMessage) WithCheckingDisabled() *DNSMessage {
+	return m.updateFlags(func(flags *HeaderFlag) { flags.CD = true })
+}
+
+// WithEDNS adds an OPT pseudo-record advertising the given UDP payload size, or updates the size
+// advertised by the existing one.
+func (m *DNSMessage) WithEDNS(udpSize uint16) *DNSMessage {
+	e := m.edns()
+	e.UDPSize = udpSize
+	m.SetEDNS(e)
+	return m
+}
+
+// WithDO sets the DO bit of the OPT pseudo-record, asking for the DNSSEC records.
+// An OPT pseudo-record advertising DefaultEDNSUDPSize is added if the message has none.
+func (m *DNSMessage) WithDO() *DNSMessage {
+	e := m.edns()
+	e.DO = true
+	m.SetEDNS(e)
+	return m
+}
+
+// WithEDNSOption sets the option in the OPT pseudo-record, replacing any option with the same code.
+// An OPT pseudo-record advertising DefaultEDNSUDPSize is added if the message has none.
+func (m *DNSMessage) WithEDNSOption(option EDNSOption) *DNSMessage {
+	e := m.edns()
+	e.SetOption(option)
+	m.SetEDNS(e)
+	return m
+}
+
+// Reply creates the response to the query: it has the ID, the opcode, the questions and the RD and CD bits
+// of the query, its QR bit is set and its response code is NOERROR. If the query has an OPT pseudo-record,
+// the response has one with the same UDP payload size and DO bit, without options.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6891#section-7 for more information
+func (m *DNSMessage) Reply() *DNSMessage {
+	query := HeaderFlagFromUint16(m.Header.Flags)
+	flags := NewHeaderFlag(true, query.Opcode, false, false, query.RD, false, 0, RCodeNoError)
+	flags.CD = query.CD
+	questions := append([]Question(nil), m.Questions...)
+	header := NewHeader(m.Header.ID, flags.GenerateFlag(), uint16(len(questions)), 0, 0, 0)
+	response := NewDNSMessage(*header, questions)
+	if e := m.EDNS(); e != nil {
+		edns := NewEDNS(e.UDPSize)
+		edns.DO = e.DO
+		response.SetEDNS(edns)
+	}
+	return response
+}
+
+// SetRCode sets the response code of the message. The 4 lower bits are stored in the header, and the upper
+// bits of the extended response codes, such as BADVERS, in the OPT pseudo-record, which is added if the
+// message has none.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6891#section-6.1.3 for more information
+func (m *DNSMessage) SetRCode(rcode uint16) *DNSMessage {
+	m.updateFlags(func(flags *HeaderFlag) { flags.RCode = uint8(rcode & 0xF) })
+	if e := m.EDNS(); e != nil || rcode > 0xF {
+		if e == nil {
+			e = NewEDNS(DefaultEDNSUDPSize)
+		}
+		e.ExtendedRCode = uint8(rcode >> 4)
+		m.SetEDNS(e)
+	}
+	return m
+}
+
+// AddAnswer appends the records to the answer section, and keeps the answer count of the header up to date.
+func (m *DNSMessage) AddAnswer(records ...ResourceRecord) *DNSMessage {
+	m.Answers = append(m.Answers, records...)
+	m.Header.ANCount = uint16(len(m.Answers))
+	return m
+}
+
+// AddAuthority appends the records to the authority section, and keeps the authority count of the header up to date.
+func (m *DNSMessage) AddAuthority(records ...ResourceRecord) *DNSMessage {
+	m.AuthorityRRs = append(m.AuthorityRRs, records...)
+	m.Header.NSCount = uint16(len(m.AuthorityRRs))
+	return m
+}
+
+// AddAdditional appends the records to the additional section, and keeps the additional count of the header up to date.
+// The OPT pseudo-record is set with SetEDNS instead.
+func (m *DNSMessage) AddAdditional(records ...ResourceRecord) *DNSMessage {
+	m.AdditionalRRs = append(m.AdditionalRRs, records...)
+	m.Header.ARCount = uint16(len(m.AdditionalRRs))
+	return m
+}
+
+// updateFlags applies the update to the flags of the header.
+func (m *DNSMessage) updateFlags(update func(flags *HeaderFlag)) *DNSMessage {
+	flags := HeaderFlagFromUint16(m.Header.Flags)
+	update(flags)
+	m.Header.Flags = flags.GenerateFlag()
+	return m
+}
+
+// edns returns the EDNS fields of the message, or new ones advertising DefaultEDNSUDPSize if it has none.
+func (m *DNSMessage) edns() *EDNS {
+	if e := m.EDNS(); e != nil {
+		return e
+	}
+	return NewEDNS(DefaultEDNSUDPSize)
+}
@@ -0,0 +1,87 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Run("Should build a query with its flags and its EDNS pseudo-record", func(t *testing.T) {
+		query := NewQuery("dns.google.com", TypeA).WithRecursion().WithEDNS(1232).WithDO()
+
+		flags := HeaderFlagFromUint16(query.Header.Flags)
+		assert.False(t, flags.QR)
+		assert.True(t, flags.RD)
+		assert.Equal(t, uint16(1), query.Header.QDCount)
+		assert.Equal(t, uint16(1), query.Header.ARCount)
+		assert.Equal(t, *NewQuestion("dns.google.com", TypeA, ClassIN), query.Questions[0])
+		edns := query.EDNS()
+		assert.Equal(t, uint16(1232), edns.UDPSize)
+		assert.True(t, edns.DO)
+
+		parsed, err := ParseMessage(query.ToBytes())
+		assert.NoError(t, err)
+		assert.Equal(t, query.Header, parsed.Header)
+	})
+
+	t.Run("Should keep a single EDNS pseudo-record", func(t *testing.T) {
+		query := NewQuery("bücher.example", TypeAAAA).WithDO().WithEDNS(4096).WithCheckingDisabled().
+			WithEDNSOption(&NSIDOption{})
+		assert.Equal(t, "xn--bcher-kva.example", query.Questions[0].Name)
+		assert.Len(t, query.AdditionalRRs, 1)
+		assert.Equal(t, uint16(1), query.Header.ARCount)
+		edns := query.EDNS()
+		assert.Equal(t, uint16(4096), edns.UDPSize)
+		assert.True(t, edns.DO)
+		assert.NotNil(t, edns.Option(EDNSOptionNSID))
+		assert.True(t, HeaderFlagFromUint16(query.Header.Flags).CD)
+	})
+
+	t.Run("Should generate random IDs", func(t *testing.T) {
+		ids := make(map[uint16]bool)
+		for i := 0; i < 16; i++ {
+			ids[NewQuery("example.com", TypeA).Header.ID] = true
+		}
+		assert.Greater(t, len(ids), 1)
+	})
+
+	t.Run("Should reply to a query with consistent counts", func(t *testing.T) {
+		query := NewQuery("dns.google.com", TypeA).WithRecursion().WithCheckingDisabled().WithEDNS(1232).WithDO().
+			WithEDNSOption(&NSIDOption{})
+		response := query.Reply().
+			AddAnswer(*NewResourceRecord("dns.google.com", TypeA, ClassIN, 900, 4, []byte{8, 8, 8, 8})).
+			AddAnswer(*NewResourceRecord("dns.google.com", TypeA, ClassIN, 900, 4, []byte{8, 8, 4, 4})).
+			AddAuthority(*NewResourceRecord("google.com", TypeNS, ClassIN, 900, 0, nil)).
+			AddAdditional(*NewResourceRecord("ns1.google.com", TypeA, ClassIN, 900, 4, []byte{216, 239, 32, 10}))
+
+		assert.Equal(t, query.Header.ID, response.Header.ID)
+		assert.Equal(t, query.Questions, response.Questions)
+		flags := HeaderFlagFromUint16(response.Header.Flags)
+		assert.True(t, flags.QR)
+		assert.True(t, flags.RD)
+		assert.True(t, flags.CD)
+		assert.False(t, flags.AA)
+		assert.Equal(t, RCodeNoError, flags.RCode)
+		assert.Equal(t, *NewHeader(query.Header.ID, response.Header.Flags, 1, 2, 1, 2), response.Header)
+		edns := response.EDNS()
+		assert.Equal(t, uint16(1232), edns.UDPSize)
+		assert.True(t, edns.DO)
+		assert.Empty(t, edns.Options)
+
+		assert.Nil(t, NewQuery("example.com", TypeA).Reply().EDNS(), "the reply has no OPT pseudo-record if the query has none")
+	})
+
+	t.Run("Should set the response codes", func(t *testing.T) {
+		response := NewQuery("example.com", TypeA).Reply().SetRCode(uint16(RCodeNameError))
+		assert.Equal(t, uint16(RCodeNameError), response.RCode())
+		assert.Nil(t, response.EDNS())
+
+		response.SetRCode(RCodeBadVersion)
+		assert.Equal(t, RCodeBadVersion, response.RCode())
+		assert.Equal(t, uint16(1), response.Header.ARCount, "the extended response code needs an OPT pseudo-record")
+
+		response.SetRCode(uint16(RCodeRefused))
+		assert.Equal(t, uint16(RCodeRefused), response.RCode())
+	})
+}
@@ -0,0 +1,162 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CAAFlagIssuerCritical is the flag of a CAA property that a CA must understand to issue a certificate.
+const CAAFlagIssuerCritical uint8 = 128
+
+// CAA property tags defined by RFC 8659.
+const (
+	CAATagIssue     = "issue"     // CA authorized to issue certificates for the domain and its subdomains
+	CAATagIssueWild = "issuewild" // CA authorized to issue wildcard certificates for the domain
+	CAATagIODEF     = "iodef"     // URL to report invalid certificate requests to
+)
+
+// maxCAATagLength is the maximum length of the tag of a CAA property.
+const maxCAATagLength = 15
+
+// CAA is the resource data of a CAA record, a property of the certificate issuance policy of the domain.
+// The value is kept as is: the values of the issue and issuewild properties can be decoded with ParseCAAIssueValue.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8659#section-4.1 for more information
+type CAA struct {
+	Flags uint8  // Flags holds CAAFlagIssuerCritical if the property must be understood by the CA.
+	Tag   string // Tag is the name of the property, such as issue. Tags are compared case-insensitively.
+	Value string // Value is the value of the property, whose format depends on the tag.
+}
+
+// Type returns TypeCAA.
+func (rd *CAA) Type() uint16 { return TypeCAA }
+
+// String returns the flags and the tag followed by the quoted value.
+func (rd *CAA) String() string {
+	return fmt.Sprintf("%d %s %s", rd.Flags, rd.Tag, quoteCharacterString(rd.Value))
+}
+
+// Equal reports whether other is a CAA record with the same flags, tag and value.
+func (rd *CAA) Equal(other RData) bool {
+	o, ok := other.(*CAA)
+	return ok && rd.Flags == o.Flags && strings.EqualFold(rd.Tag, o.Tag) && rd.Value == o.Value
+}
+
+// IsCritical reports whether the issuer critical flag of the property is set.
+func (rd *CAA) IsCritical() bool { return rd.Flags&CAAFlagIssuerCritical != 0 }
+
+func (rd *CAA) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	if err := checkCAATag(rd.Tag); err != nil {
+		return nil, err
+	}
+	msg = append(msg, rd.Flags, byte(len(rd.Tag)))
+	msg = append(msg, rd.Tag...)
+	return append(msg, rd.Value...), nil
+}
+
+func (rd *CAA) unpack(msg []byte, off, end int) error {
+	if end-off < 2 || off+2+int(msg[off+1]) > end {
+		return rdataLengthError(TypeCAA, end-off)
+	}
+	tagEnd := off + 2 + int(msg[off+1])
+	rd.Flags = msg[off]
+	rd.Tag = string(msg[off+2 : tagEnd])
+	rd.Value = string(msg[tagEnd:end])
+	return checkCAATag(rd.Tag)
+}
+
+// parse accepts a quoted or unquoted value, which is not limited to 255 bytes.
+func (rd *CAA) parse(tokens []zoneToken, _ string) error {
+	if err := expectTokens(tokens, 3); err != nil {
+		return err
+	}
+	flags, err := parseUint(tokens[0], 8)
+	if err != nil {
+		return err
+	}
+	if err := checkCAATag(tokens[1].text); err != nil {
+		return err
+	}
+	value, err := unescapeCharacterString(tokens[2].text)
+	if err != nil {
+		return err
+	}
+	rd.Flags, rd.Tag, rd.Value = uint8(flags), tokens[1].text, value
+	return nil
+}
+
+// checkCAATag checks that the tag of a CAA property is made of 1 to 15 letters and digits.
+func checkCAATag(tag string) error {
+	if len(tag) == 0 || len(tag) > maxCAATagLength {
+		return fmt.Errorf("invalid CAA tag length: %d", len(tag))
+	}
+	for i := 0; i < len(tag); i++ {
+		if !isAlphaNumeric(tag[i]) {
+			return fmt.Errorf("invalid CAA tag: %q", tag)
+		}
+	}
+	return nil
+}
+
+// CAAIssueValue is the decoded value of an issue or issuewild property.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8659#section-4.2 for more information
+type CAAIssueValue struct {
+	Issuer     string            // Issuer is the domain name of the authorized CA. It is empty if no CA is authorized.
+	Parameters map[string]string // Parameters are the parameters for the CA, such as accounturi, by tag.
+}
+
+// ParseCAAIssueValue decodes the value of an issue or issuewild property: the domain name of the CA,
+// optionally followed by parameters separated by semicolons, such as "ca.example.net; accounturi=https://...".
+func ParseCAAIssueValue(value string) (*CAAIssueValue, error) {
+	issuer, parameters, hasParameters := strings.Cut(value, ";")
+	issuer = strings.Trim(issuer, " \t")
+	if issuer != "" {
+		for _, label := range strings.Split(issuer, ".") {
+			if !isCAALabel(label) {
+				return nil, fmt.Errorf("invalid CAA issuer domain name: %q", issuer)
+			}
+		}
+	}
+
+	issueValue := &CAAIssueValue{Issuer: issuer, Parameters: make(map[string]string)}
+	if !hasParameters || strings.Trim(parameters, " \t") == "" {
+		return issueValue, nil
+	}
+	for _, parameter := range strings.Split(parameters, ";") {
+		tag, paramValue, ok := strings.Cut(parameter, "=")
+		tag, paramValue = strings.Trim(tag, " \t"), strings.Trim(paramValue, " \t")
+		if !ok || !isCAALabel(tag) {
+			return nil, fmt.Errorf("invalid CAA parameter: %q", parameter)
+		}
+		for i := 0; i < len(paramValue); i++ {
+			if paramValue[i] < 0x21 || paramValue[i] > 0x7E {
+				return nil, fmt.Errorf("invalid CAA parameter value: %q", paramValue)
+			}
+		}
+		if _, exists := issueValue.Parameters[tag]; exists {
+			return nil, fmt.Errorf("duplicate CAA parameter: %s", tag)
+		}
+		issueValue.Parameters[tag] = paramValue
+	}
+	return issueValue, nil
+}
+
+// isCAALabel reports whether the text is a label of an issuer domain name or a parameter tag:
+// letters and digits, with hyphens between them.
+func isCAALabel(text string) bool {
+	if text == "" || !isAlphaNumeric(text[0]) || !isAlphaNumeric(text[len(text)-1]) {
+		return false
+	}
+	for i := 0; i < len(text); i++ {
+		if !isAlphaNumeric(text[i]) && text[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlphaNumeric reports whether the byte is an ASCII letter or digit.
+func isAlphaNumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,69 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCAA(t *testing.T) {
+	t.Run("Should pack, unpack and print a CAA record", func(t *testing.T) {
+		record := &CAA{Flags: 0, Tag: "issue", Value: "ca.example.net; account=230123"}
+		rData, err := PackRData(record)
+		assert.NoError(t, err)
+		assert.Equal(t, append([]byte{0, 5}, "issueca.example.net; account=230123"...), rData)
+
+		unpacked, err := UnpackRData(TypeCAA, rData)
+		assert.NoError(t, err)
+		assert.True(t, record.Equal(unpacked))
+		assert.True(t, record.Equal(&CAA{Tag: "ISSUE", Value: record.Value}))
+		assert.Equal(t, `0 issue "ca.example.net; account=230123"`, unpacked.String())
+		assert.False(t, record.IsCritical())
+		assert.True(t, (&CAA{Flags: CAAFlagIssuerCritical, Tag: "tbs", Value: "Unknown"}).IsCritical())
+	})
+
+	t.Run("Should reject CAA records with an invalid tag", func(t *testing.T) {
+		for _, rData := range [][]byte{{0}, {0, 0}, {0, 6, 'i', 's', 's', 'u', 'e'}, {0, 2, 'a', '-'}} {
+			_, err := UnpackRData(TypeCAA, rData)
+			assert.Error(t, err, "%v", rData)
+		}
+		_, err := PackRData(&CAA{Tag: "averyveryverylongtag"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should read and write CAA records in zone files", func(t *testing.T) {
+		zone := "$TTL 300\n@ CAA 0 issue \"ca.example.net\"\n@ CAA 128 tbs Unknown\n@ CAA 0 iodef \"mailto:security@example.com\"\n"
+		records, err := ParseZone(strings.NewReader(zone), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 3, len(records)) {
+			assert.Equal(t, &CAA{Flags: 0, Tag: CAATagIssue, Value: "ca.example.net"}, records[0].Data)
+			assert.Equal(t, "example.com.\t300\tIN\tCAA\t128 tbs \"Unknown\"", records[1].String())
+		}
+
+		for _, invalid := range []string{"@ 300 CAA 0 issue", "@ 300 CAA 256 issue \"ca\"", "@ 300 CAA 0 is-sue \"ca\""} {
+			_, err := ParseZone(strings.NewReader(invalid), "example.com.", "")
+			assert.Error(t, err, invalid)
+		}
+	})
+
+	t.Run("Should decode the value of the issue properties", func(t *testing.T) {
+		value, err := ParseCAAIssueValue("ca.example.net; account=230123")
+		assert.NoError(t, err)
+		assert.Equal(t, &CAAIssueValue{Issuer: "ca.example.net", Parameters: map[string]string{"account": "230123"}}, value)
+
+		value, err = ParseCAAIssueValue(" ca.example.net ; accounturi = https://ca.example.net/acct/1 ; validationmethods=dns-01 ")
+		assert.NoError(t, err)
+		assert.Equal(t, "ca.example.net", value.Issuer)
+		assert.Equal(t, map[string]string{"accounturi": "https://ca.example.net/acct/1", "validationmethods": "dns-01"}, value.Parameters)
+
+		value, err = ParseCAAIssueValue(";")
+		assert.NoError(t, err)
+		assert.Equal(t, "", value.Issuer)
+
+		for _, invalid := range []string{"%%%%%", "ca..example.net", "-ca.example.net", "ca.example.net; account", "ca.example.net; a=1; a=2", "ca.example.net; a=b c"} {
+			_, err := ParseCAAIssueValue(invalid)
+			assert.Error(t, err, invalid)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// maxPointerOffset is the largest message offset a compression pointer can address (14 bits).
+const maxPointerOffset = 0x3FFF
+
+// compressionMap maps the domain names already written to a message to the offset at which they start.
+// It is shared by every name written to the same message so that later names can point to earlier ones.
+// The names are keyed by their presentation format, without the trailing dot.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1035#section-4.1.4 for more information
+type compressionMap map[string]int
+
+// compressionMaps holds the compression maps of the messages packed so far, cleared, so that packing
+// a message does not allocate a new map.
+var compressionMaps = sync.Pool{New: func() any { return make(compressionMap) }}
+
+// appendName appends the RFC 1035 encoding of the domain name, written in its presentation format, to msg.
+// msg must hold the message written so far, starting at the first byte of the header,
+// because the offsets recorded in comp are relative to the start of the message.
+//
+// If comp is nil the name is written uncompressed. Otherwise the longest suffix of the name
+// that was already written is replaced by a pointer and every new suffix is recorded in comp.
+// It fails if the name is not valid, see NewName. The root can be written as "." or an empty name.
+//
+// The escapes are decoded while the labels are written, and the suffixes are recorded as substrings
+// of the name, so that appendName does not allocate.
+func appendName(msg []byte, name string, comp compressionMap) ([]byte, error) {
+	name = trimFQDN(name)
+	wireLength := 1
+	for start := 0; start < len(name); {
+		if comp != nil {
+			if offset, ok := comp[name[start:]]; ok {
+				return binary.BigEndian.AppendUint16(msg, 0xC000|uint16(offset)), nil
+			}
+			if len(msg) <= maxPointerOffset {
+				comp[name[start:]] = len(msg)
+			}
+		}
+
+		lengthOffset := len(msg)
+		msg = append(msg, 0)
+		end := start
+		for ; end < len(name) && name[end] != '.'; end++ {
+			c := name[end]
+			if c == '\\' {
+				value, next, err := unescapeAt(name, end)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDomainName, name, err)
+				}
+				c, end = value, next-1
+			}
+			msg = append(msg, c)
+		}
+		length := len(msg) - lengthOffset - 1
+		if length == 0 || length > maxLabelLength || end == len(name)-1 {
+			return nil, fmt.Errorf("%w: %s: label length must be between 1 and %d octets", ErrInvalidDomainName, name, maxLabelLength)
+		}
+		msg[lengthOffset] = byte(length)
+		if wireLength += length + 1; wireLength > maxNameLength {
+			return nil, fmt.Errorf("%w: %s", ErrNameTooLong, name)
+		}
+		start = end + 1
+	}
+	return append(msg, 0), nil
+}
+
+// maxPointerHops is the maximum number of compression pointers followed while decoding a single name.
+// A name can not be longer than 255 octets, so a valid name never needs more than 127 pointers.
+const maxPointerHops = 127
+
+// maxNameLength is the maximum length of a domain name in its wire format.
+const maxNameLength = 255
+
+// maxLabelLength is the maximum length of a label of a domain name.
+const maxLabelLength = 63
+
+// Errors returned while decoding a domain name.
+// They are wrapped in a *NameError that records where the decoding failed.
+var (
+	ErrNameTruncated     = errors.New("domain name exceeds the message")
+	ErrNameTooLong       = errors.New("domain name is longer than 255 octets")
+	ErrInvalidLabelType  = errors.New("reserved label type")
+	ErrPointerOutOfRange = errors.New("compression pointer points outside of the message")
+	ErrForwardPointer    = errors.New("compression pointer does not point to a prior occurrence")
+	ErrPointerLoop       = errors.New("too many compression pointers")
+)
+
+// NameError describes a domain name that could not be decoded.
+type NameError struct {
+	Offset int   // Offset is the offset in the message at which the decoding failed.
+	Err    error // Err is one of the Err* values describing the failure.
+}
+
+// Error returns the description of the NameError.
+func (e *NameError) Error() string {
+	return fmt.Sprintf("invalid domain name at offset %d: %v", e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error so that errors.Is can be used on a NameError.
+func (e *NameError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeNameAt decodes the domain name starting at the given offset of the full message msg.
+// Compression pointers are followed wherever they appear in the name, using their full 14-bit offset.
+// Pointers must point to a prior occurrence in the message, which together with a limit on the number
+// of pointers followed guarantees that decoding terminates on hostile input.
+//
+// It returns the decoded name in its presentation format, with the special characters and the bytes
+// that are not printable escaped, see Name, and the offset of the first byte following the name in the message.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1035#section-4.1.4 for more information
+func DecodeNameAt(msg []byte, offset int) (string, int, error) {
+	name, next, err := appendDecodedName(nil, msg, offset)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(name), next, nil
+}
+
+// appendDecodedName appends the presentation format of the domain name starting at the given offset
+// of the full message msg to dst, as decoded by DecodeNameAt. The root is appended as an empty name.
+// It returns the extended buffer and the offset of the first byte following the name in the message,
+// and does not allocate if dst is large enough.
+func appendDecodedName(dst []byte, msg []byte, offset int) ([]byte, int, error) {
+	start := len(dst)
+	next := -1 // offset following the name, known once the first pointer is followed
+	wireLength := 0
+	hops := 0
+	off := offset
+
+	for {
+		if off < 0 || off >= len(msg) {
+			return nil, 0, &NameError{Offset: off, Err: ErrNameTruncated}
+		}
+		length := int(msg[off])
+
+		switch length & 0xC0 {
+		case 0x00:
+			wireLength += length + 1
+			if wireLength > maxNameLength {
+				return nil, 0, &NameError{Offset: off, Err: ErrNameTooLong}
+			}
+			if length == 0 {
+				if next < 0 {
+					next = off + 1
+				}
+				return dst, next, nil
+			}
+			if off+1+length > len(msg) {
+				return nil, 0, &NameError{Offset: off, Err: ErrNameTruncated}
+			}
+			if len(dst) > start {
+				dst = append(dst, '.')
+			}
+			dst = appendEscapedLabel(dst, msg[off+1:off+1+length])
+			off += 1 + length
+		case 0xC0:
+			if off+1 >= len(msg) {
+				return nil, 0, &NameError{Offset: off, Err: ErrNameTruncated}
+			}
+			pointer := int(binary.BigEndian.Uint16(msg[off:]) & maxPointerOffset)
+			if pointer >= len(msg) {
+				return nil, 0, &NameError{Offset: off, Err: ErrPointerOutOfRange}
+			}
+			if pointer >= off {
+				return nil, 0, &NameError{Offset: off, Err: ErrForwardPointer}
+			}
+			hops++
+			if hops > maxPointerHops {
+				return nil, 0, &NameError{Offset: off, Err: ErrPointerLoop}
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = pointer
+		default:
+			// 0x40 and 0x80 are reserved (RFC 6891 deprecated extended label types).
+			return nil, 0, &NameError{Offset: off, Err: ErrInvalidLabelType}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package dns
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompression(t *testing.T) {
+	t.Run("Should append an uncompressed name", func(t *testing.T) {
+		expected := []byte{3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0}
+		for name, expected := range map[string][]byte{"dns.google.com": expected, "dns.google.com.": expected, ".": {0}} {
+			msg, err := appendName(nil, name, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, msg)
+		}
+	})
+
+	t.Run("Should replace a known suffix by a pointer", func(t *testing.T) {
+		comp := make(compressionMap)
+		msg := make([]byte, 12)
+		for _, name := range []string{"dns.google.com", "mail.google.com", "dns.google.com"} {
+			var err error
+			msg, err = appendName(msg, name, comp)
+			assert.NoError(t, err)
+		}
+		expected := []byte{
+			3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0,
+			4, 109, 97, 105, 108, 192, 16,
+			192, 12,
+		}
+		assert.Equal(t, expected, msg[12:])
+	})
+
+	t.Run("Should compress the names inside the resource data of RFC 1035 types", func(t *testing.T) {
+		comp := make(compressionMap)
+		msg, err := appendName(make([]byte, 12), "google.com", comp)
+		assert.NoError(t, err)
+
+		msg, err = (&MX{Preference: 10, Exchange: "smtp.google.com"}).pack(msg, comp)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 10, 4, 115, 109, 116, 112, 192, 12}, msg[24:])
+	})
+
+	t.Run("Should not compress the SRV target", func(t *testing.T) {
+		comp := make(compressionMap)
+		msg, err := appendName(make([]byte, 12), "google.com", comp)
+		assert.NoError(t, err)
+
+		msg, err = (&SRV{Priority: 1, Weight: 2, Port: 3, Target: "google.com"}).pack(msg, comp)
+		assert.NoError(t, err)
+		assert.Equal(t, append([]byte{0, 1, 0, 2, 0, 3}, encodeName("google.com")...), msg[24:])
+	})
+
+	t.Run("Should follow a 14-bit compression pointer", func(t *testing.T) {
+		msg := make([]byte, 300)
+		copy(msg[280:], encodeName("google.com"))
+		msg = append(msg, 3, 100, 110, 115, 0xC1, 0x18) // dns.<pointer to 280>
+		name, next, err := DecodeNameAt(msg, 300)
+		assert.NoError(t, err)
+		assert.Equal(t, "dns.google.com", name)
+		assert.Equal(t, 306, next)
+	})
+
+	t.Run("Should follow chained compression pointers", func(t *testing.T) {
+		msg := []byte{3, 99, 111, 109, 0, 6, 103, 111, 111, 103, 108, 101, 192, 0, 3, 100, 110, 115, 192, 5, 192, 14}
+		name, next, err := DecodeNameAt(msg, 20)
+		assert.NoError(t, err)
+		assert.Equal(t, "dns.google.com", name)
+		assert.Equal(t, 22, next)
+	})
+
+	t.Run("Should reject invalid compression pointers", func(t *testing.T) {
+		_, _, err := DecodeNameAt([]byte{192, 0}, 0)
+		assert.True(t, errors.Is(err, ErrForwardPointer))
+
+		_, _, err = DecodeNameAt([]byte{3, 99, 111, 109, 192, 4}, 0)
+		assert.True(t, errors.Is(err, ErrForwardPointer))
+
+		_, _, err = DecodeNameAt([]byte{0, 192, 200}, 1)
+		assert.True(t, errors.Is(err, ErrPointerOutOfRange))
+
+		_, _, err = DecodeNameAt([]byte{3, 99, 111}, 0)
+		assert.True(t, errors.Is(err, ErrNameTruncated))
+
+		_, _, err = DecodeNameAt([]byte{0x40, 0}, 0)
+		assert.True(t, errors.Is(err, ErrInvalidLabelType))
+
+		var nameErr *NameError
+		assert.True(t, errors.As(err, &nameErr))
+		assert.Equal(t, 0, nameErr.Offset)
+	})
+
+	t.Run("Should reject names longer than 255 octets", func(t *testing.T) {
+		msg := make([]byte, 0)
+		for i := 0; i < 5; i++ {
+			msg = append(msg, 63)
+			msg = append(msg, make([]byte, 63)...)
+		}
+		_, _, err := DecodeNameAt(append(msg, 0), 0)
+		assert.True(t, errors.Is(err, ErrNameTooLong))
+	})
+}
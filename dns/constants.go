@@ -8,37 +8,69 @@ const (
 
 // DNS record types
 const (
-	TypeA     uint16 = 1   // IPv4 address record
-	TypeNS    uint16 = 2   // authoritative name server record
-	TypeCNAME uint16 = 5   // canonical name record
-	TypeSOA   uint16 = 6   // start of authority record
-	TypePTR   uint16 = 12  // pointer record
-	TypeMX    uint16 = 15  // mail exchange record
-	TypeTXT   uint16 = 16  // text record
-	TypeAAAA  uint16 = 28  // IPv6 address record
-	TypeSRV   uint16 = 33  // service locator record
-	TypeOPT   uint16 = 41  // option record
-	TypeAXFR  uint16 = 252 // transfer of an entire zone record
-	TypeMAILB uint16 = 253 // mailbox-related records (MB, MG, MR)
-	TypeMAILA uint16 = 254 // mail agent RRs (Obsolete - see MX)
-	TypeAll   uint16 = 255 // all records
+	TypeA          uint16 = 1   // IPv4 address record
+	TypeNS         uint16 = 2   // authoritative name server record
+	TypeCNAME      uint16 = 5   // canonical name record
+	TypeSOA        uint16 = 6   // start of authority record
+	TypePTR        uint16 = 12  // pointer record
+	TypeHINFO      uint16 = 13  // host information record
+	TypeMX         uint16 = 15  // mail exchange record
+	TypeTXT        uint16 = 16  // text record
+	TypeAAAA       uint16 = 28  // IPv6 address record
+	TypeLOC        uint16 = 29  // location record
+	TypeSRV        uint16 = 33  // service locator record
+	TypeNAPTR      uint16 = 35  // naming authority pointer record
+	TypeDNAME      uint16 = 39  // delegation name record
+	TypeOPT        uint16 = 41  // option record
+	TypeDS         uint16 = 43  // delegation signer record
+	TypeSSHFP      uint16 = 44  // SSH public key fingerprint record
+	TypeRRSIG      uint16 = 46  // DNSSEC signature record
+	TypeNSEC       uint16 = 47  // next secure record
+	TypeDNSKEY     uint16 = 48  // DNSSEC public key record
+	TypeNSEC3      uint16 = 50  // hashed next secure record
+	TypeNSEC3PARAM uint16 = 51  // NSEC3 parameters record
+	TypeTLSA       uint16 = 52  // TLS certificate association record
+	TypeSVCB       uint16 = 64  // service binding record
+	TypeHTTPS      uint16 = 65  // HTTPS service binding record
+	TypeTSIG       uint16 = 250 // transaction signature record
+	TypeAXFR       uint16 = 252 // transfer of an entire zone record
+	TypeMAILB      uint16 = 253 // mailbox-related records (MB, MG, MR)
+	TypeMAILA      uint16 = 254 // mail agent RRs (Obsolete - see MX)
+	TypeAll        uint16 = 255 // all records
+	TypeURI        uint16 = 256 // uniform resource identifier record
+	TypeCAA        uint16 = 257 // certification authority authorization record
 )
 
 // DNS record classes
 const (
-	ClassIN  uint16 = 1   // Internet class
-	ClassCS  uint16 = 2   // CSNET class (Obsolete)
-	ClassCH  uint16 = 3   // CHAOS class
-	ClassHS  uint16 = 4   // Hesiod [Dyer 87]
-	ClassAll uint16 = 255 // all classes
+	ClassIN   uint16 = 1   // Internet class
+	ClassCS   uint16 = 2   // CSNET class (Obsolete)
+	ClassCH   uint16 = 3   // CHAOS class
+	ClassHS   uint16 = 4   // Hesiod [Dyer 87]
+	ClassNone uint16 = 254 // none class, used by the prerequisites and updates of UPDATE messages (RFC 2136)
+	ClassAll  uint16 = 255 // all classes
+)
+
+// DNS opcodes
+const (
+	OpcodeQuery  uint8 = 0 // standard query
+	OpcodeIQuery uint8 = 1 // inverse query (Obsolete)
+	OpcodeStatus uint8 = 2 // server status request
+	OpcodeNotify uint8 = 4 // zone change notification (RFC 1996)
+	OpcodeUpdate uint8 = 5 // dynamic update (RFC 2136)
 )
 
 // DNS response codes
 const (
-	RCodeNoError        uint8 = 0 // No error condition
-	RCodeFormatError    uint8 = 1 // Format error - The name server was unable to interpret the query
-	RCodeServerFailure  uint8 = 2 // Server failure - The name server was unable to process this query due to a problem with the name server
-	RCodeNameError      uint8 = 3 // Name error - Meaningful only for responses from an authoritative name server, this code signifies that the domain name referenced in the query does not exist
-	RCodeNotImplemented uint8 = 4 // Not implemented - The name server does not support the requested kind of query
-	RCodeRefused        uint8 = 5 // Refused - The name server refuses to perform the specified operation for policy reasons
+	RCodeNoError        uint8 = 0  // No error condition
+	RCodeFormatError    uint8 = 1  // Format error - The name server was unable to interpret the query
+	RCodeServerFailure  uint8 = 2  // Server failure - The name server was unable to process this query due to a problem with the name server
+	RCodeNameError      uint8 = 3  // Name error - Meaningful only for responses from an authoritative name server, this code signifies that the domain name referenced in the query does not exist
+	RCodeNotImplemented uint8 = 4  // Not implemented - The name server does not support the requested kind of query
+	RCodeRefused        uint8 = 5  // Refused - The name server refuses to perform the specified operation for policy reasons
+	RCodeYXDomain       uint8 = 6  // Name exists - A name that ought not to exist does exist (RFC 2136)
+	RCodeYXRRSet        uint8 = 7  // RRset exists - An RRset that ought not to exist does exist (RFC 2136)
+	RCodeNXRRSet        uint8 = 8  // RRset does not exist - An RRset that ought to exist does not exist (RFC 2136)
+	RCodeNotAuth        uint8 = 9  // Not authoritative - The server is not authoritative for the zone of the update (RFC 2136)
+	RCodeNotZone        uint8 = 10 // Not in zone - A name of the prerequisite or update sections is not within the zone (RFC 2136)
 )
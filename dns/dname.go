@@ -0,0 +1,88 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DNAME is the resource data of a DNAME record, which redirects the names below its owner to the same names
+// below the target, as a CNAME would redirect a single name. The owner name itself is not redirected.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6672#section-2 for more information
+type DNAME struct {
+	Target string
+}
+
+// Type returns TypeDNAME.
+func (rd *DNAME) Type() uint16 { return TypeDNAME }
+
+// String returns the fully qualified target.
+func (rd *DNAME) String() string { return fqdn(rd.Target) }
+
+// Equal reports whether other is a DNAME record with the same target.
+func (rd *DNAME) Equal(other RData) bool {
+	o, ok := other.(*DNAME)
+	return ok && equalNames(rd.Target, o.Target)
+}
+
+// pack never compresses the target, as required by RFC 6672.
+func (rd *DNAME) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	return appendName(msg, rd.Target, nil)
+}
+
+// unpack accepts a compressed target, as some servers compress it anyway.
+func (rd *DNAME) unpack(msg []byte, off, end int) error {
+	if end-off == 0 {
+		return rdataLengthError(TypeDNAME, end-off)
+	}
+	target, _, err := unpackRDataName(msg, off, end)
+	rd.Target = target
+	return err
+}
+
+func (rd *DNAME) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 1); err != nil {
+		return err
+	}
+	target, err := parseZoneName(tokens[0].text, origin)
+	rd.Target = target
+	return err
+}
+
+// Substitute returns the name the DNAME record owned by owner redirects the name to: the labels of the name
+// below the owner followed by the target. It fails if the name is not strictly below the owner, or if the
+// resulting name is too long, in which case the server answers YXDOMAIN.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6672#section-2.2 for more information
+func (rd *DNAME) Substitute(name, owner string) (string, error) {
+	name, owner = trimFQDN(name), trimFQDN(owner)
+	if equalNames(name, owner) || !IsSubdomain(name, owner) {
+		return "", fmt.Errorf("the DNAME record of %s does not redirect %s", fqdn(owner), fqdn(name))
+	}
+	labels := SplitLabels(name)
+	target := strings.Join(labels[:len(labels)-CountLabels(owner)], ".")
+	if t := trimFQDN(rd.Target); t != "" {
+		target += "." + t
+	}
+	if _, err := decodeLabels(target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// SynthesizeCNAME returns the CNAME record that redirects the name to its substitute by the DNAME record,
+// with the TTL of the DNAME record. Servers send it along with the DNAME record for the resolvers that do
+// not know DNAME, and the resolvers that do synthesize it when it is missing or does not match.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6672#section-3.1 for more information
+func SynthesizeCNAME(dname ResourceRecord, name string) (*ResourceRecord, error) {
+	data, ok := dname.Data.(*DNAME)
+	if !ok {
+		return nil, fmt.Errorf("expected a DNAME record, got %s", RTypeToString(dname.Type))
+	}
+	target, err := data.Substitute(name, dname.Name)
+	if err != nil {
+		return nil, err
+	}
+	return NewResourceRecordWithData(trimFQDN(name), dname.Class, dname.TTL, &CNAME{Target: target})
+}
@@ -0,0 +1,67 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNAME(t *testing.T) {
+	t.Run("Should pack the target of a DNAME record without compression", func(t *testing.T) {
+		record := &DNAME{Target: "frobozz-division.acme.example"}
+		comp := compressionMap{"acme.example": 0}
+		rData, err := record.pack(nil, comp)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(encodeName("frobozz-division.acme.example")), rData)
+
+		unpacked, err := UnpackRData(TypeDNAME, rData)
+		assert.NoError(t, err)
+		assert.True(t, record.Equal(unpacked))
+		assert.Equal(t, "frobozz-division.acme.example.", unpacked.String())
+	})
+
+	t.Run("Should substitute the owner of a DNAME record with its target", func(t *testing.T) {
+		record := &DNAME{Target: "frobozz-division.acme.example."}
+		target, err := record.Substitute("foo.bar.Frobozz.example.", "frobozz.example")
+		assert.NoError(t, err)
+		assert.Equal(t, "foo.bar.frobozz-division.acme.example", target)
+
+		target, err = (&DNAME{Target: ""}).Substitute("www.example.com", "example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "www", target, "the target may be the root")
+		target, err = record.Substitute("www.example", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "www.example.frobozz-division.acme.example", target, "the owner may be the root")
+
+		_, err = record.Substitute("frobozz.example", "frobozz.example")
+		assert.Error(t, err, "the owner itself is not redirected")
+		_, err = record.Substitute("www.example.com", "frobozz.example")
+		assert.Error(t, err)
+		_, err = (&DNAME{Target: strings.Repeat("a", 63) + "." + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63)}).
+			Substitute(strings.Repeat("d", 63)+".example", "example")
+		assert.ErrorIs(t, err, ErrNameTooLong)
+	})
+
+	t.Run("Should synthesize the CNAME record of a name below a DNAME record", func(t *testing.T) {
+		dname, err := NewResourceRecordWithData("example.com", ClassIN, 600, &DNAME{Target: "example.net"})
+		assert.NoError(t, err)
+		cname, err := SynthesizeCNAME(*dname, "www.example.com.")
+		assert.NoError(t, err)
+		assert.Equal(t, "www.example.com.\t600\tIN\tCNAME\twww.example.net.", cname.String())
+
+		_, err = SynthesizeCNAME(*dname, "example.com")
+		assert.Error(t, err)
+		_, err = SynthesizeCNAME(*cname, "www.example.com")
+		assert.Error(t, err, "the record is not a DNAME record")
+	})
+
+	t.Run("Should read DNAME records in zone files", func(t *testing.T) {
+		records, err := ParseZone(strings.NewReader("$TTL 300\n@ DNAME frobozz-division.acme.example.\nold DNAME new\n"), "frobozz.example.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, &DNAME{Target: "frobozz-division.acme.example"}, records[0].Data)
+			assert.Equal(t, &DNAME{Target: "new.frobozz.example"}, records[1].Data)
+		}
+	})
+}
@@ -2,6 +2,9 @@ package dns
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"strings"
 )
 
 // DNSMessage represents a DNS message.
@@ -42,99 +45,284 @@ func NewDNSMessage(header Header, questions []Question, records ...[]ResourceRec
 	}
 }
 
+// String returns the message as printed by dig: the header, the EDNS pseudo-section if the message has
+// an OPT pseudo-record, and the question, answer, authority and additional sections that are not empty.
+// The records are in the presentation format of zone files.
+func (m *DNSMessage) String() string {
+	return m.format(false)
+}
+
+// UnicodeString returns the message as String does, with the U-labels of the internationalized domain names
+// of each question and record in a comment following it, such as ; bücher.example. for xn--bcher-kva.example.
+func (m *DNSMessage) UnicodeString() string {
+	return m.format(true)
+}
+
+// format returns the message as printed by dig, with the U-labels of its names in comments if unicode is set.
+func (m *DNSMessage) format(unicode bool) string {
+	var b strings.Builder
+	b.WriteString(m.Header.format(m.RCode()))
+	b.WriteString("\n")
+
+	if edns := m.EDNS(); edns != nil {
+		b.WriteString("\n;; OPT PSEUDOSECTION:\n")
+		b.WriteString(edns.String())
+		b.WriteString("\n")
+	}
+	titles := [...]string{"QUESTION", "ANSWER", "AUTHORITY", "ADDITIONAL"}
+	if HeaderFlagFromUint16(m.Header.Flags).Opcode == OpcodeUpdate {
+		titles = [...]string{"ZONE", "PREREQUISITE", "UPDATE", "ADDITIONAL"}
+	}
+	if len(m.Questions) > 0 {
+		fmt.Fprintf(&b, "\n;; %s SECTION:\n", titles[0])
+		for _, q := range m.Questions {
+			b.WriteString(q.String())
+			if unicode {
+				b.WriteString(unicodeComment(q.Name))
+			}
+			b.WriteString("\n")
+		}
+	}
+	additionalRRs := make([]ResourceRecord, 0, len(m.AdditionalRRs))
+	for _, rr := range m.AdditionalRRs {
+		if rr.Type != TypeOPT {
+			additionalRRs = append(additionalRRs, rr)
+		}
+	}
+	for _, section := range []struct {
+		name    string
+		records []ResourceRecord
+	}{
+		{titles[1], m.Answers},
+		{titles[2], m.AuthorityRRs},
+		{titles[3], additionalRRs},
+	} {
+		if len(section.records) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n;; %s SECTION:\n", section.name)
+		for _, rr := range section.records {
+			b.WriteString(rr.String())
+			if unicode {
+				b.WriteString(unicodeComment(append([]string{rr.Name}, rdataNames(rr.Data)...)...))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
 // ToBytes converts the DNSMessage to a byte slice.
-// It returns the byte slice representation of the DNSMessage.
+// The domain names are compressed as described in RFC 1035 section 4.1.4,
+// sharing a single compression table across the questions, the owner names and the resource data.
+// It returns the byte slice representation of the DNSMessage, or nil if a domain name is not valid.
+// Use Pack to get the error.
 func (m *DNSMessage) ToBytes() []byte {
-	// Create a buffer to store the bytes
-	buf := new(bytes.Buffer)
+	msg, _ := m.Pack()
+	return msg
+}
 
-	// Write the header to the buffer
-	buf.Write(m.Header.ToBytes())
+// Pack converts the DNSMessage to a byte slice, compressing the domain names as ToBytes does.
+// It fails if the name of a question or the owner name of a record is not valid, see NewName.
+func (m *DNSMessage) Pack() ([]byte, error) {
+	return m.AppendPack(nil)
+}
 
-	// Write the questions to the buffer
-	for _, q := range m.Questions {
-		buf.Write(q.ToBytes())
+// AppendPack appends the DNSMessage to b, compressing the domain names as Pack does, and returns the extended
+// buffer. b may hold a prefix, such as the length of the message sent over TCP: the compression pointers are
+// relative to the start of the message. It does not allocate when b has the capacity to hold the message,
+// which lets a server reuse the same buffer for every response.
+func (m *DNSMessage) AppendPack(b []byte) ([]byte, error) {
+	comp := compressionMaps.Get().(compressionMap)
+	// The message is written at the end of b, in its spare capacity, so that it starts at offset 0
+	msg, err := m.pack(b[len(b):], comp)
+	clear(comp)
+	compressionMaps.Put(comp)
+	if err != nil {
+		return nil, err
 	}
+	return append(b, msg...), nil
+}
 
-	// Write the answers to the buffer
-	for _, a := range m.Answers {
-		buf.Write(a.ToBytes())
-	}
+// ToBytesUncompressed converts the DNSMessage to a byte slice without compressing any domain name.
+// It is useful for callers that need the uncompressed wire form, e.g. the DNSSEC canonical form.
+// It returns nil if a domain name is not valid.
+func (m *DNSMessage) ToBytesUncompressed() []byte {
+	msg, _ := m.pack(nil, nil)
+	return msg
+}
 
-	// Write the authority RRs to the buffer
-	for _, rr := range m.AuthorityRRs {
-		buf.Write(rr.ToBytes())
+// pack appends the DNSMessage to msg, which must be empty, compressing the domain names against comp if it is not nil.
+func (m *DNSMessage) pack(msg []byte, comp compressionMap) ([]byte, error) {
+	// Write the header
+	msg = m.Header.pack(msg)
+
+	// Write the questions
+	var err error
+	for i := range m.Questions {
+		if msg, err = m.Questions[i].pack(msg, comp); err != nil {
+			return nil, err
+		}
 	}
 
-	// Write the additional RRs to the buffer
-	for _, rr := range m.AdditionalRRs {
-		buf.Write(rr.ToBytes())
+	// Write the answers, the authority RRs and the additional RRs
+	for _, section := range [...][]ResourceRecord{m.Answers, m.AuthorityRRs, m.AdditionalRRs} {
+		for i := range section {
+			if msg, err = section[i].pack(msg, comp); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// Return the bytes from the buffer
-	return buf.Bytes()
+	return msg, nil
 }
 
 // appendFromBufferUntilNull reads bytes from the buffer until a null byte is encountered.
-// It returns the read bytes as a byte slice.
+// It returns the read bytes as a byte slice, which lacks the null byte if the buffer ran out before it.
 func appendFromBufferUntilNull(buf *bytes.Buffer) []byte {
 	// Create a bytes slice by reading the bytes until we reach a null byte for any string field
 	data := make([]byte, 0)
-	for {
-		b := buf.Next(1)
-		data = append(data, b[0])
-		if b[0] == 0 {
+	for buf.Len() > 0 {
+		b, _ := buf.ReadByte()
+		data = append(data, b)
+		if b == 0 {
 			break
 		}
 	}
 	return data
 }
 
-// DNSMessageFromBytes creates a DNSMessage from the given byte slice.
-// It returns a pointer to the created DNSMessage.
-func DNSMessageFromBytes(data []byte) *DNSMessage {
-	// Create a new buffer from the data
-	buf := bytes.NewBuffer(data)
-	bufCopy := bytes.NewBuffer(data)
+// Minimum wire lengths of the message parts, used to reject counts that can not fit in a message.
+const (
+	headerLength            = 12
+	minQuestionLength       = 5  // root name, type and class
+	minResourceRecordLength = 11 // root name, type, class, TTL and RDLength
+)
 
-	// Read the header from the buffer
-	header := HeaderFromBytes(buf.Next(12))
+// Errors returned by ParseMessage, wrapped in a *ParseError.
+var (
+	ErrShortHeader   = errors.New("message is shorter than the header")
+	ErrCountTooLarge = errors.New("section counts exceed the message length")
+	ErrTruncated     = errors.New("message is truncated")
+)
 
-	// Read the questions from the buffer
-	questions := make([]Question, header.QDCount)
-	for i := 0; i < int(header.QDCount); i++ {
-		questionBytes := appendFromBufferUntilNull(buf)
-		questionBytes = append(questionBytes, buf.Next(4)...)
-		questions[i] = *QuestionFromBytes(questionBytes)
+// ParseError describes where and why a DNS message could not be parsed.
+type ParseError struct {
+	Section string // Section is the part of the message being parsed: header, question, answer, authority or additional.
+	Index   int    // Index is the index of the entry within the section.
+	Offset  int    // Offset is the offset in the message at which the entry starts.
+	Err     error  // Err is the underlying error.
+}
+
+// Error returns the description of the ParseError.
+func (e *ParseError) Error() string {
+	if e.Section == "header" {
+		return fmt.Sprintf("failed to parse the header: %v", e.Err)
 	}
+	return fmt.Sprintf("failed to parse %s #%d at offset %d: %v", e.Section, e.Index, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error so that errors.Is and errors.As can be used on a ParseError.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
 
-	// Read the answers from the buffer
-	answers := make([]ResourceRecord, header.ANCount)
-	for i := 0; i < int(header.ANCount); i++ {
-		rrBytes := TrimResourceRecordBytes(buf)
-		answers[i] = *ResourceRecordFromBytes(rrBytes, bufCopy)
+// ParseMessage parses the wire format of a DNS message.
+// Every length, count and offset is validated against the data, so that ParseMessage never panics.
+// A *ParseError describing the failing section, entry and offset is returned if the data is not a valid message.
+//
+// Every entry is decoded, and the records are copied out of the data. Use a Parser to read the entries lazily.
+func ParseMessage(data []byte) (*DNSMessage, error) {
+	var p Parser
+	header, err := p.Start(data)
+	if err != nil {
+		return nil, err
 	}
 
-	// Read the authority RRs from the buffer
-	authorityRRs := make([]ResourceRecord, header.NSCount)
-	for i := 0; i < int(header.NSCount); i++ {
-		rrBytes := TrimResourceRecordBytes(buf)
-		authorityRRs[i] = *ResourceRecordFromBytes(rrBytes, bufCopy)
+	questions := make([]Question, header.QDCount)
+	for i := range questions {
+		raw, err := p.Question()
+		if err != nil {
+			return nil, err
+		}
+		q, err := raw.Question()
+		if err != nil {
+			return nil, &ParseError{Section: sectionNames[SectionQuestion], Index: i, Offset: raw.Name.Offset, Err: err}
+		}
+		questions[i] = *q
 	}
 
-	// Read the additional RRs from the buffer
-	additionalRRs := make([]ResourceRecord, header.ARCount)
-	for i := 0; i < int(header.ARCount); i++ {
-		rrBytes := TrimResourceRecordBytes(buf)
-		additionalRRs[i] = *ResourceRecordFromBytes(rrBytes, bufCopy)
+	// Read the answers, the authority RRs and the additional RRs
+	var records [3][]ResourceRecord
+	for s := range records {
+		section := SectionAnswer + s
+		records[s] = make([]ResourceRecord, p.count(section))
+		for i := range records[s] {
+			raw, err := p.Record(section)
+			if err != nil {
+				return nil, err
+			}
+			rr, err := raw.Record()
+			if err != nil {
+				return nil, &ParseError{Section: sectionNames[section], Index: i, Offset: raw.Name.Offset, Err: err}
+			}
+			records[s][i] = *rr
+		}
 	}
 
 	// Create a new DNS message with the parsed data
 	return &DNSMessage{
-		Header:        *header,
+		Header:        header,
 		Questions:     questions,
-		Answers:       answers,
-		AuthorityRRs:  authorityRRs,
-		AdditionalRRs: additionalRRs,
+		Answers:       records[0],
+		AuthorityRRs:  records[1],
+		AdditionalRRs: records[2],
+	}, nil
+}
+
+// DNSMessageFromBytes creates a DNSMessage from the given byte slice.
+// It returns a pointer to the created DNSMessage, which is empty if the data is not a valid message.
+// Use ParseMessage to find out why the data could not be parsed.
+func DNSMessageFromBytes(data []byte) *DNSMessage {
+	message, err := ParseMessage(data)
+	if err != nil {
+		return NewDNSMessage(Header{}, []Question{})
+	}
+	return message
+}
+
+// unicodeComment returns the comment holding the U-labels of the names that hold A-labels,
+// or an empty string if there is none.
+func unicodeComment(names ...string) string {
+	var unicodeNames []string
+	for _, name := range names {
+		if unicode := ToUnicode(fqdn(name)); unicode != fqdn(name) {
+			unicodeNames = append(unicodeNames, unicode)
+		}
+	}
+	if len(unicodeNames) == 0 {
+		return ""
+	}
+	return "\t; " + strings.Join(unicodeNames, " ")
+}
+
+// rdataNames returns the domain names held by the resource data of the types that point to other names.
+func rdataNames(data RData) []string {
+	switch rd := data.(type) {
+	case *NS:
+		return []string{rd.Host}
+	case *CNAME:
+		return []string{rd.Target}
+	case *DNAME:
+		return []string{rd.Target}
+	case *PTR:
+		return []string{rd.Target}
+	case *MX:
+		return []string{rd.Exchange}
+	case *SRV:
+		return []string{rd.Target}
+	case *SOA:
+		return []string{rd.MName}
 	}
+	return nil
 }
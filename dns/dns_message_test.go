@@ -2,6 +2,7 @@ package dns
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,6 +40,71 @@ func TestDNSMessage(t *testing.T) {
 		assert.Equal(t, DNSMessage, *DNSMessageFromBytes(DNSMessageBytes))
 	})
 
+	t.Run("Should compress the names of a dns message response", func(t *testing.T) {
+		flag := NewHeaderFlag(true, 0, false, false, true, true, 0, 0).GenerateFlag()
+		header := NewHeader(22, flag, 1, 2, 0, 0)
+		question := NewQuestion("dns.google.com", TypeA, ClassIN)
+		answers := []ResourceRecord{
+			*NewResourceRecord("dns.google.com", TypeA, ClassIN, 900, 4, []byte{8, 8, 4, 4}),
+			*NewResourceRecord("dns.google.com", TypeA, ClassIN, 900, 4, []byte{8, 8, 8, 8}),
+		}
+		message := NewDNSMessage(*header, []Question{*question}, answers)
+		expected := []byte{0, 22, 129, 128, 0, 1, 0, 2, 0, 0, 0, 0, 3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 1, 0, 1, 192, 12, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8, 4, 4, 192, 12, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8, 8, 8}
+		assert.Equal(t, expected, message.ToBytes())
+	})
+
+	t.Run("Should write a dns message response without compression", func(t *testing.T) {
+		flag := NewHeaderFlag(true, 0, false, false, true, true, 0, 0).GenerateFlag()
+		header := NewHeader(22, flag, 1, 1, 0, 0)
+		question := NewQuestion("dns.google.com", TypeCNAME, ClassIN)
+		answer := NewResourceRecord("dns.google.com", TypeCNAME, ClassIN, 900, 12, []byte(encodeName("google.com")))
+		message := NewDNSMessage(*header, []Question{*question}, []ResourceRecord{*answer})
+		expected := append(header.ToBytes(), question.ToBytes()...)
+		expected = append(expected, answer.ToBytes()...)
+		assert.Equal(t, expected, message.ToBytesUncompressed())
+		assert.Less(t, len(message.ToBytes()), len(expected))
+	})
+
+	t.Run("Should parse a dns message response", func(t *testing.T) {
+		dnsMessageBytes := []byte{0, 22, 129, 128, 0, 1, 0, 2, 0, 0, 0, 0, 3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 1, 0, 1, 192, 12, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8, 4, 4, 192, 12, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8, 8, 8}
+		message, err := ParseMessage(dnsMessageBytes)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(message.Answers))
+		assert.Equal(t, "dns.google.com", message.Answers[1].Name)
+		assert.Equal(t, "8.8.8.8", message.Answers[1].RDataParsed)
+	})
+
+	t.Run("Should report where a dns message could not be parsed", func(t *testing.T) {
+		_, err := ParseMessage([]byte{0, 22, 129})
+		assert.True(t, errors.Is(err, ErrShortHeader))
+
+		_, err = ParseMessage([]byte{0, 22, 129, 128, 255, 255, 0, 0, 0, 0, 0, 0})
+		assert.True(t, errors.Is(err, ErrCountTooLarge))
+
+		// The second answer claims 4 bytes of resource data but only 2 are left
+		dnsMessageBytes := []byte{0, 22, 129, 128, 0, 1, 0, 2, 0, 0, 0, 0, 3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 1, 0, 1, 192, 12, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8, 4, 4, 192, 12, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8}
+		_, err = ParseMessage(dnsMessageBytes)
+		var parseErr *ParseError
+		assert.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, "answer", parseErr.Section)
+		assert.Equal(t, 1, parseErr.Index)
+		assert.Equal(t, 48, parseErr.Offset)
+		assert.True(t, errors.Is(err, ErrTruncated))
+
+		// The answer points forward to its own resource data
+		dnsMessageBytes = []byte{0, 22, 129, 128, 0, 0, 0, 1, 0, 0, 0, 0, 192, 14, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8, 8, 8}
+		_, err = ParseMessage(dnsMessageBytes)
+		assert.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, "answer", parseErr.Section)
+		assert.True(t, errors.Is(err, ErrForwardPointer))
+	})
+
+	t.Run("Should return an empty dns message for invalid bytes", func(t *testing.T) {
+		message := DNSMessageFromBytes([]byte{0, 22})
+		assert.Equal(t, 0, len(message.Questions))
+		assert.Equal(t, 0, len(message.Answers))
+	})
+
 	t.Run("Should append the bytes of a buffer until a null byte is encountered", func(t *testing.T) {
 		message := []byte{3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 1, 0, 1}
 		buf := bytes.NewBuffer(message)
@@ -50,4 +116,81 @@ func TestDNSMessage(t *testing.T) {
 		expected = []byte{1, 2, 3, 4, 0}
 		assert.Equal(t, expected, appendFromBufferUntilNull(buf))
 	})
+
+	t.Run("Should print a dns message as dig does", func(t *testing.T) {
+		flag := NewHeaderFlag(true, 0, false, false, true, true, 0, 0).GenerateFlag()
+		question := NewQuestion("dns.google.com", TypeA, ClassIN)
+		answers := []ResourceRecord{
+			*NewResourceRecord("dns.google.com", TypeA, ClassIN, 900, 4, []byte{8, 8, 8, 8}),
+			*NewResourceRecord("dns.google.com", TypeA, ClassIN, 900, 4, []byte{8, 8, 4, 4}),
+		}
+		message := NewDNSMessage(*NewHeader(12345, flag, 1, 2, 0, 0), []Question{*question}, answers)
+		message.SetEDNS(NewEDNS(1232))
+
+		expected := `;; ->>HEADER<<- opcode: QUERY, status: NOERROR, id: 12345
+;; flags: qr rd ra; QUERY: 1, ANSWER: 2, AUTHORITY: 0, ADDITIONAL: 1
+
+;; OPT PSEUDOSECTION:
+; EDNS: version: 0, flags:; udp: 1232
+
+;; QUESTION SECTION:
+;dns.google.com.		IN	A
+
+;; ANSWER SECTION:
+dns.google.com.	900	IN	A	8.8.8.8
+dns.google.com.	900	IN	A	8.8.4.4
+`
+		assert.Equal(t, expected, message.String())
+
+		edns := message.EDNS()
+		edns.ExtendedRCode = 1
+		message.SetEDNS(edns)
+		assert.Contains(t, message.String(), "status: BADVERS")
+	})
+	t.Run("Should append a dns message after a prefix with the compression relative to the message", func(t *testing.T) {
+		msg := parserTestMessage()
+		message, err := ParseMessage(msg)
+		assert.NoError(t, err)
+
+		packed, err := message.AppendPack([]byte{0, byte(len(msg))})
+		assert.NoError(t, err)
+		assert.Equal(t, append([]byte{0, byte(len(msg))}, msg...), packed)
+		assert.Equal(t, msg, message.ToBytes())
+	})
+
+	t.Run("Should pack a dns message into a reused buffer without allocating", func(t *testing.T) {
+		message, err := ParseMessage(parserTestMessage())
+		assert.NoError(t, err)
+		buf := make([]byte, 0, 512)
+		_, _ = message.AppendPack(buf)
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = message.AppendPack(buf)
+		})
+		assert.Equal(t, 0.0, allocs)
+	})
+}
+
+func BenchmarkAppendPack(b *testing.B) {
+	message, err := ParseMessage(parserTestMessage())
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := make([]byte, 0, 512)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := message.AppendPack(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkToBytes(b *testing.B) {
+	message, err := ParseMessage(parserTestMessage())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		message.ToBytes()
+	}
 }
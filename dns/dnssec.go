@@ -0,0 +1,763 @@
+package dns
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DNSSEC algorithm numbers, as registered by IANA.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8624#section-3.1 for more information
+const (
+	AlgorithmRSAMD5           uint8 = 1  // RSA/MD5 (deprecated)
+	AlgorithmRSASHA1          uint8 = 5  // RSA/SHA-1
+	AlgorithmRSASHA1NSEC3SHA1 uint8 = 7  // RSA/SHA-1, for zones signed with NSEC3
+	AlgorithmRSASHA256        uint8 = 8  // RSA/SHA-256
+	AlgorithmRSASHA512        uint8 = 10 // RSA/SHA-512
+	AlgorithmECDSAP256SHA256  uint8 = 13 // ECDSA P-256 with SHA-256
+	AlgorithmECDSAP384SHA384  uint8 = 14 // ECDSA P-384 with SHA-384
+	AlgorithmED25519          uint8 = 15 // Ed25519
+	AlgorithmED448            uint8 = 16 // Ed448
+)
+
+// DS digest types, as registered by IANA.
+const (
+	DigestSHA1   uint8 = 1 // SHA-1
+	DigestSHA256 uint8 = 2 // SHA-256
+	DigestSHA384 uint8 = 4 // SHA-384
+)
+
+// DNSKEY flags and protocol.
+const (
+	DNSKEYFlagZone   uint16 = 256 // the key signs the records of the zone
+	DNSKEYFlagRevoke uint16 = 128 // the key is revoked (RFC 5011)
+	DNSKEYFlagSEP    uint16 = 1   // the key is a secure entry point, usually a key signing key
+	DNSKEYProtocol   uint8  = 3   // the only valid protocol of a DNSKEY
+)
+
+// NSEC3 hash algorithms and flags.
+const (
+	NSEC3HashSHA1    uint8 = 1 // SHA-1, the only hash algorithm of NSEC3
+	NSEC3FlagOptOut  uint8 = 1 // the NSEC3 record may cover unsigned delegations
+	maxTypeBitMapLen       = 32
+)
+
+// signatureTimeLayout is the layout of the times of RRSIG records in the presentation format: YYYYMMDDHHmmSS in UTC.
+const signatureTimeLayout = "20060102150405"
+
+// base32Hex is the encoding of the hashed owner names of NSEC3 records: base32 with the extended hex alphabet,
+// without padding, written in lowercase.
+var base32Hex = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// DNSKEY is the resource data of a DNSKEY record, a public key used to verify the signatures of a zone.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-2 for more information
+type DNSKEY struct {
+	Flags     uint16 // Flags holds DNSKEYFlagZone for zone keys, and DNSKEYFlagSEP for key signing keys.
+	Protocol  uint8  // Protocol must be DNSKEYProtocol.
+	Algorithm uint8  // Algorithm is the DNSSEC algorithm of the key, such as AlgorithmECDSAP256SHA256.
+	PublicKey []byte // PublicKey is the public key, in the format of the algorithm.
+}
+
+// Type returns TypeDNSKEY.
+func (rd *DNSKEY) Type() uint16 { return TypeDNSKEY }
+
+// String returns the flags, protocol and algorithm followed by the public key in base64.
+func (rd *DNSKEY) String() string {
+	return fmt.Sprintf("%d %d %d %s", rd.Flags, rd.Protocol, rd.Algorithm, base64.StdEncoding.EncodeToString(rd.PublicKey))
+}
+
+// Equal reports whether other is a DNSKEY record with the same fields.
+func (rd *DNSKEY) Equal(other RData) bool {
+	o, ok := other.(*DNSKEY)
+	return ok && rd.Flags == o.Flags && rd.Protocol == o.Protocol && rd.Algorithm == o.Algorithm && bytes.Equal(rd.PublicKey, o.PublicKey)
+}
+
+// KeyTag returns the key tag of the key, which identifies it in the RRSIG and DS records.
+// It is a checksum of the resource data, except for AlgorithmRSAMD5.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#appendix-B for more information
+func (rd *DNSKEY) KeyTag() uint16 {
+	if rd.Algorithm == AlgorithmRSAMD5 {
+		// The 16 most significant bits of the 24 least significant bits of the modulus, which ends the key
+		if len(rd.PublicKey) < 3 {
+			return 0
+		}
+		return binary.BigEndian.Uint16(rd.PublicKey[len(rd.PublicKey)-3:])
+	}
+	rData, _ := rd.pack(nil, nil)
+	var ac uint32
+	for i, b := range rData {
+		if i&1 == 0 {
+			ac += uint32(b) << 8
+		} else {
+			ac += uint32(b)
+		}
+	}
+	ac += ac >> 16 & 0xFFFF
+	return uint16(ac & 0xFFFF)
+}
+
+// ToDS returns the DS record of the key owned by the given name, whose digest is computed with the given digest type.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-5.1.4 for more information
+func (rd *DNSKEY) ToDS(owner string, digestType uint8) (*DS, error) {
+	var h hash.Hash
+	switch digestType {
+	case DigestSHA1:
+		h = sha1.New()
+	case DigestSHA256:
+		h = sha256.New()
+	case DigestSHA384:
+		h = sha512.New384()
+	default:
+		return nil, fmt.Errorf("unsupported DS digest type: %d", digestType)
+	}
+	ownerWire, err := appendName(nil, canonicalName(owner), nil)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(ownerWire)
+	rData, _ := rd.pack(nil, nil)
+	h.Write(rData)
+	return &DS{KeyTag: rd.KeyTag(), Algorithm: rd.Algorithm, DigestType: digestType, Digest: h.Sum(nil)}, nil
+}
+
+func (rd *DNSKEY) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg = binary.BigEndian.AppendUint16(msg, rd.Flags)
+	msg = append(msg, rd.Protocol, rd.Algorithm)
+	return append(msg, rd.PublicKey...), nil
+}
+
+func (rd *DNSKEY) unpack(msg []byte, off, end int) error {
+	if end-off < 4 {
+		return rdataLengthError(TypeDNSKEY, end-off)
+	}
+	rd.Flags = binary.BigEndian.Uint16(msg[off : off+2])
+	rd.Protocol = msg[off+2]
+	rd.Algorithm = msg[off+3]
+	rd.PublicKey = bytes.Clone(msg[off+4 : end])
+	return nil
+}
+
+// parse accepts a public key split over several fields, as it is usually written in parentheses.
+func (rd *DNSKEY) parse(tokens []zoneToken, _ string) error {
+	if len(tokens) < 4 {
+		return fmt.Errorf("expected at least 4 fields, got %d", len(tokens))
+	}
+	flags, err := parseUint(tokens[0], 16)
+	if err != nil {
+		return err
+	}
+	protocol, err := parseUint(tokens[1], 8)
+	if err != nil {
+		return err
+	}
+	algorithm, err := parseUint(tokens[2], 8)
+	if err != nil {
+		return err
+	}
+	publicKey, err := parseBase64(tokens[3:])
+	if err != nil {
+		return err
+	}
+	rd.Flags, rd.Protocol, rd.Algorithm, rd.PublicKey = uint16(flags), uint8(protocol), uint8(algorithm), publicKey
+	return nil
+}
+
+// DS is the resource data of a DS record, the digest of a DNSKEY of the child zone held by its parent zone.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-5 for more information
+type DS struct {
+	KeyTag     uint16 // KeyTag is the key tag of the DNSKEY, see DNSKEY.KeyTag.
+	Algorithm  uint8  // Algorithm is the DNSSEC algorithm of the DNSKEY.
+	DigestType uint8  // DigestType is the algorithm of the digest, such as DigestSHA256.
+	Digest     []byte // Digest is the digest of the owner name and resource data of the DNSKEY.
+}
+
+// Type returns TypeDS.
+func (rd *DS) Type() uint16 { return TypeDS }
+
+// String returns the key tag, algorithm and digest type followed by the digest in uppercase hex.
+func (rd *DS) String() string {
+	return fmt.Sprintf("%d %d %d %s", rd.KeyTag, rd.Algorithm, rd.DigestType, strings.ToUpper(hex.EncodeToString(rd.Digest)))
+}
+
+// Equal reports whether other is a DS record with the same fields.
+func (rd *DS) Equal(other RData) bool {
+	o, ok := other.(*DS)
+	return ok && rd.KeyTag == o.KeyTag && rd.Algorithm == o.Algorithm && rd.DigestType == o.DigestType && bytes.Equal(rd.Digest, o.Digest)
+}
+
+func (rd *DS) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg = binary.BigEndian.AppendUint16(msg, rd.KeyTag)
+	msg = append(msg, rd.Algorithm, rd.DigestType)
+	return append(msg, rd.Digest...), nil
+}
+
+func (rd *DS) unpack(msg []byte, off, end int) error {
+	if end-off < 4 {
+		return rdataLengthError(TypeDS, end-off)
+	}
+	rd.KeyTag = binary.BigEndian.Uint16(msg[off : off+2])
+	rd.Algorithm = msg[off+2]
+	rd.DigestType = msg[off+3]
+	rd.Digest = bytes.Clone(msg[off+4 : end])
+	return nil
+}
+
+// parse accepts a digest split over several fields.
+func (rd *DS) parse(tokens []zoneToken, _ string) error {
+	if len(tokens) < 4 {
+		return fmt.Errorf("expected at least 4 fields, got %d", len(tokens))
+	}
+	keyTag, err := parseUint(tokens[0], 16)
+	if err != nil {
+		return err
+	}
+	algorithm, err := parseUint(tokens[1], 8)
+	if err != nil {
+		return err
+	}
+	digestType, err := parseUint(tokens[2], 8)
+	if err != nil {
+		return err
+	}
+	digest, err := parseHex(tokens[3:])
+	if err != nil {
+		return err
+	}
+	rd.KeyTag, rd.Algorithm, rd.DigestType, rd.Digest = uint16(keyTag), uint8(algorithm), uint8(digestType), digest
+	return nil
+}
+
+// RRSIG is the resource data of an RRSIG record, the signature of an RRset by a DNSKEY of the zone.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-3 for more information
+type RRSIG struct {
+	TypeCovered uint16 // TypeCovered is the type of the signed RRset.
+	Algorithm   uint8  // Algorithm is the DNSSEC algorithm of the signature.
+	Labels      uint8  // Labels is the number of labels of the owner name, without the root and a leading wildcard.
+	OriginalTTL uint32 // OriginalTTL is the TTL of the RRset in the zone.
+	Expiration  uint32 // Expiration is the time after which the signature is not valid, in seconds since the epoch.
+	Inception   uint32 // Inception is the time before which the signature is not valid, in seconds since the epoch.
+	KeyTag      uint16 // KeyTag is the key tag of the DNSKEY that verifies the signature.
+	SignerName  string // SignerName is the name of the zone holding the DNSKEY.
+	Signature   []byte // Signature is the signature, in the format of the algorithm.
+}
+
+// Type returns TypeRRSIG.
+func (rd *RRSIG) Type() uint16 { return TypeRRSIG }
+
+// String returns the fields of the signature, with the times as YYYYMMDDHHmmSS and the signature in base64.
+func (rd *RRSIG) String() string {
+	return fmt.Sprintf("%s %d %d %d %s %s %d %s %s", RTypeToString(rd.TypeCovered), rd.Algorithm, rd.Labels, rd.OriginalTTL,
+		formatSignatureTime(rd.Expiration), formatSignatureTime(rd.Inception), rd.KeyTag, fqdn(rd.SignerName),
+		base64.StdEncoding.EncodeToString(rd.Signature))
+}
+
+// Equal reports whether other is an RRSIG record with the same fields.
+func (rd *RRSIG) Equal(other RData) bool {
+	o, ok := other.(*RRSIG)
+	return ok && rd.TypeCovered == o.TypeCovered && rd.Algorithm == o.Algorithm && rd.Labels == o.Labels &&
+		rd.OriginalTTL == o.OriginalTTL && rd.Expiration == o.Expiration && rd.Inception == o.Inception &&
+		rd.KeyTag == o.KeyTag && equalNames(rd.SignerName, o.SignerName) && bytes.Equal(rd.Signature, o.Signature)
+}
+
+// pack never compresses the signer name, as required by RFC 4034.
+func (rd *RRSIG) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg = binary.BigEndian.AppendUint16(msg, rd.TypeCovered)
+	msg = append(msg, rd.Algorithm, rd.Labels)
+	msg = binary.BigEndian.AppendUint32(msg, rd.OriginalTTL)
+	msg = binary.BigEndian.AppendUint32(msg, rd.Expiration)
+	msg = binary.BigEndian.AppendUint32(msg, rd.Inception)
+	msg = binary.BigEndian.AppendUint16(msg, rd.KeyTag)
+	msg, err := appendName(msg, rd.SignerName, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(msg, rd.Signature...), nil
+}
+
+func (rd *RRSIG) unpack(msg []byte, off, end int) error {
+	if end-off < 19 {
+		return rdataLengthError(TypeRRSIG, end-off)
+	}
+	rd.TypeCovered = binary.BigEndian.Uint16(msg[off : off+2])
+	rd.Algorithm = msg[off+2]
+	rd.Labels = msg[off+3]
+	rd.OriginalTTL = binary.BigEndian.Uint32(msg[off+4 : off+8])
+	rd.Expiration = binary.BigEndian.Uint32(msg[off+8 : off+12])
+	rd.Inception = binary.BigEndian.Uint32(msg[off+12 : off+16])
+	rd.KeyTag = binary.BigEndian.Uint16(msg[off+16 : off+18])
+	signerName, next, err := unpackRDataName(msg, off+18, end)
+	if err != nil {
+		return err
+	}
+	rd.SignerName = signerName
+	rd.Signature = bytes.Clone(msg[next:end])
+	return nil
+}
+
+// parse accepts the times as YYYYMMDDHHmmSS or as seconds since the epoch, and a signature split over several fields.
+func (rd *RRSIG) parse(tokens []zoneToken, origin string) error {
+	if len(tokens) < 9 {
+		return fmt.Errorf("expected at least 9 fields, got %d", len(tokens))
+	}
+	typeCovered, ok := typeFromString(tokens[0].text)
+	if !ok {
+		return fmt.Errorf("unknown record type: %s", tokens[0].text)
+	}
+	var values [7]uint64
+	for i, bitSize := range []int{8, 8, 32, 0, 0, 16} {
+		var err error
+		if bitSize == 0 {
+			values[i], err = parseSignatureTime(tokens[i+1].text)
+		} else {
+			values[i], err = parseUint(tokens[i+1], bitSize)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	signerName, err := parseZoneName(tokens[7].text, origin)
+	if err != nil {
+		return err
+	}
+	signature, err := parseBase64(tokens[8:])
+	if err != nil {
+		return err
+	}
+	rd.TypeCovered = typeCovered
+	rd.Algorithm, rd.Labels, rd.OriginalTTL = uint8(values[0]), uint8(values[1]), uint32(values[2])
+	rd.Expiration, rd.Inception, rd.KeyTag = uint32(values[3]), uint32(values[4]), uint16(values[5])
+	rd.SignerName, rd.Signature = signerName, signature
+	return nil
+}
+
+// formatSignatureTime returns the presentation format of a time of an RRSIG record.
+func formatSignatureTime(t uint32) string {
+	return time.Unix(int64(t), 0).UTC().Format(signatureTimeLayout)
+}
+
+// parseSignatureTime parses a time of an RRSIG record, given as YYYYMMDDHHmmSS or as seconds since the epoch.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-3.2 for more information
+func parseSignatureTime(text string) (uint64, error) {
+	if len(text) == len(signatureTimeLayout) {
+		t, err := time.Parse(signatureTimeLayout, text)
+		if err != nil || t.Unix() < 0 || t.Unix() > 0xFFFFFFFF {
+			return 0, fmt.Errorf("invalid signature time: %s", text)
+		}
+		return uint64(t.Unix()), nil
+	}
+	value, err := strconv.ParseUint(text, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid signature time: %s", text)
+	}
+	return value, nil
+}
+
+// NSEC is the resource data of an NSEC record, which proves that the names between its owner and the next name
+// of the zone in canonical order do not exist, and that its owner only has the listed types.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-4 for more information
+type NSEC struct {
+	NextDomain string   // NextDomain is the next owner name of the zone in canonical order.
+	TypeBitMap []uint16 // TypeBitMap lists the types of the records of the owner name.
+}
+
+// Type returns TypeNSEC.
+func (rd *NSEC) Type() uint16 { return TypeNSEC }
+
+// String returns the fully qualified next domain name followed by the mnemonics of the types.
+func (rd *NSEC) String() string {
+	return fqdn(rd.NextDomain) + formatTypeBitMap(rd.TypeBitMap)
+}
+
+// Equal reports whether other is an NSEC record with the same next domain name and types.
+func (rd *NSEC) Equal(other RData) bool {
+	o, ok := other.(*NSEC)
+	return ok && equalNames(rd.NextDomain, o.NextDomain) && equalTypes(rd.TypeBitMap, o.TypeBitMap)
+}
+
+// pack never compresses the next domain name, as required by RFC 4034.
+func (rd *NSEC) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg, err := appendName(msg, rd.NextDomain, nil)
+	if err != nil {
+		return nil, err
+	}
+	return appendTypeBitMap(msg, rd.TypeBitMap), nil
+}
+
+func (rd *NSEC) unpack(msg []byte, off, end int) error {
+	if end-off == 0 {
+		return rdataLengthError(TypeNSEC, end-off)
+	}
+	nextDomain, next, err := unpackRDataName(msg, off, end)
+	if err != nil {
+		return err
+	}
+	rd.NextDomain = nextDomain
+	rd.TypeBitMap, err = unpackTypeBitMap(msg[next:end])
+	return err
+}
+
+func (rd *NSEC) parse(tokens []zoneToken, origin string) error {
+	if len(tokens) < 1 {
+		return fmt.Errorf("expected at least 1 field, got %d", len(tokens))
+	}
+	nextDomain, err := parseZoneName(tokens[0].text, origin)
+	if err != nil {
+		return err
+	}
+	types, err := parseTypeBitMap(tokens[1:])
+	if err != nil {
+		return err
+	}
+	rd.NextDomain, rd.TypeBitMap = nextDomain, types
+	return nil
+}
+
+// NSEC3 is the resource data of an NSEC3 record, which proves that the names whose hash is between the hash of its
+// owner and the next hashed owner name of the zone do not exist, and that its owner only has the listed types.
+// The owner name of an NSEC3 record is the base32hex hash of an owner name of the zone, prepended to the zone.
+//
+// See https://datatracker.ietf.org/doc/html/rfc5155#section-3 for more information
+type NSEC3 struct {
+	HashAlgorithm   uint8    // HashAlgorithm is the algorithm used to hash the owner names, NSEC3HashSHA1.
+	Flags           uint8    // Flags holds NSEC3FlagOptOut if the record may cover unsigned delegations.
+	Iterations      uint16   // Iterations is the number of additional times the hash is applied.
+	Salt            []byte   // Salt is appended to the name before each hash.
+	NextHashedOwner []byte   // NextHashedOwner is the next hashed owner name of the zone, in binary.
+	TypeBitMap      []uint16 // TypeBitMap lists the types of the records of the original owner name.
+}
+
+// Type returns TypeNSEC3.
+func (rd *NSEC3) Type() uint16 { return TypeNSEC3 }
+
+// String returns the hash parameters, the salt in hex or - if empty, the next hashed owner name in base32hex
+// and the mnemonics of the types.
+func (rd *NSEC3) String() string {
+	return fmt.Sprintf("%d %d %d %s %s%s", rd.HashAlgorithm, rd.Flags, rd.Iterations, formatSalt(rd.Salt),
+		strings.ToLower(base32Hex.EncodeToString(rd.NextHashedOwner)), formatTypeBitMap(rd.TypeBitMap))
+}
+
+// Equal reports whether other is an NSEC3 record with the same fields.
+func (rd *NSEC3) Equal(other RData) bool {
+	o, ok := other.(*NSEC3)
+	return ok && rd.HashAlgorithm == o.HashAlgorithm && rd.Flags == o.Flags && rd.Iterations == o.Iterations &&
+		bytes.Equal(rd.Salt, o.Salt) && bytes.Equal(rd.NextHashedOwner, o.NextHashedOwner) && equalTypes(rd.TypeBitMap, o.TypeBitMap)
+}
+
+// IsOptOut reports whether the opt-out flag of the record is set.
+func (rd *NSEC3) IsOptOut() bool { return rd.Flags&NSEC3FlagOptOut != 0 }
+
+func (rd *NSEC3) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	if len(rd.Salt) > 255 || len(rd.NextHashedOwner) == 0 || len(rd.NextHashedOwner) > 255 {
+		return nil, fmt.Errorf("invalid NSEC3 salt or hash length: %d, %d", len(rd.Salt), len(rd.NextHashedOwner))
+	}
+	msg = append(msg, rd.HashAlgorithm, rd.Flags)
+	msg = binary.BigEndian.AppendUint16(msg, rd.Iterations)
+	msg = append(msg, byte(len(rd.Salt)))
+	msg = append(msg, rd.Salt...)
+	msg = append(msg, byte(len(rd.NextHashedOwner)))
+	msg = append(msg, rd.NextHashedOwner...)
+	return appendTypeBitMap(msg, rd.TypeBitMap), nil
+}
+
+func (rd *NSEC3) unpack(msg []byte, off, end int) error {
+	if end-off < 5 || off+5+int(msg[off+4]) >= end {
+		return rdataLengthError(TypeNSEC3, end-off)
+	}
+	rd.HashAlgorithm = msg[off]
+	rd.Flags = msg[off+1]
+	rd.Iterations = binary.BigEndian.Uint16(msg[off+2 : off+4])
+	saltEnd := off + 5 + int(msg[off+4])
+	rd.Salt = bytes.Clone(msg[off+5 : saltEnd])
+	hashEnd := saltEnd + 1 + int(msg[saltEnd])
+	if hashEnd == saltEnd+1 || hashEnd > end {
+		return rdataLengthError(TypeNSEC3, end-off)
+	}
+	rd.NextHashedOwner = bytes.Clone(msg[saltEnd+1 : hashEnd])
+	var err error
+	rd.TypeBitMap, err = unpackTypeBitMap(msg[hashEnd:end])
+	return err
+}
+
+func (rd *NSEC3) parse(tokens []zoneToken, _ string) error {
+	if len(tokens) < 5 {
+		return fmt.Errorf("expected at least 5 fields, got %d", len(tokens))
+	}
+	hashAlgorithm, flags, iterations, salt, err := parseNSEC3Params(tokens[:4])
+	if err != nil {
+		return err
+	}
+	nextHashedOwner, err := base32Hex.DecodeString(strings.ToUpper(tokens[4].text))
+	if err != nil || len(nextHashedOwner) == 0 {
+		return fmt.Errorf("invalid base32hex next hashed owner name: %s", tokens[4].text)
+	}
+	types, err := parseTypeBitMap(tokens[5:])
+	if err != nil {
+		return err
+	}
+	rd.HashAlgorithm, rd.Flags, rd.Iterations, rd.Salt = hashAlgorithm, flags, iterations, salt
+	rd.NextHashedOwner, rd.TypeBitMap = nextHashedOwner, types
+	return nil
+}
+
+// NSEC3PARAM is the resource data of an NSEC3PARAM record, the parameters used by the authoritative servers
+// to hash the names of the zone. It is held by the apex of the zone.
+//
+// See https://datatracker.ietf.org/doc/html/rfc5155#section-4 for more information
+type NSEC3PARAM struct {
+	HashAlgorithm uint8  // HashAlgorithm is the algorithm used to hash the owner names, NSEC3HashSHA1.
+	Flags         uint8  // Flags must be zero.
+	Iterations    uint16 // Iterations is the number of additional times the hash is applied.
+	Salt          []byte // Salt is appended to the name before each hash.
+}
+
+// Type returns TypeNSEC3PARAM.
+func (rd *NSEC3PARAM) Type() uint16 { return TypeNSEC3PARAM }
+
+// String returns the hash parameters followed by the salt in hex, or - if empty.
+func (rd *NSEC3PARAM) String() string {
+	return fmt.Sprintf("%d %d %d %s", rd.HashAlgorithm, rd.Flags, rd.Iterations, formatSalt(rd.Salt))
+}
+
+// Equal reports whether other is an NSEC3PARAM record with the same fields.
+func (rd *NSEC3PARAM) Equal(other RData) bool {
+	o, ok := other.(*NSEC3PARAM)
+	return ok && rd.HashAlgorithm == o.HashAlgorithm && rd.Flags == o.Flags && rd.Iterations == o.Iterations && bytes.Equal(rd.Salt, o.Salt)
+}
+
+func (rd *NSEC3PARAM) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	if len(rd.Salt) > 255 {
+		return nil, fmt.Errorf("invalid NSEC3 salt length: %d", len(rd.Salt))
+	}
+	msg = append(msg, rd.HashAlgorithm, rd.Flags)
+	msg = binary.BigEndian.AppendUint16(msg, rd.Iterations)
+	msg = append(msg, byte(len(rd.Salt)))
+	return append(msg, rd.Salt...), nil
+}
+
+func (rd *NSEC3PARAM) unpack(msg []byte, off, end int) error {
+	if end-off < 5 || off+5+int(msg[off+4]) != end {
+		return rdataLengthError(TypeNSEC3PARAM, end-off)
+	}
+	rd.HashAlgorithm = msg[off]
+	rd.Flags = msg[off+1]
+	rd.Iterations = binary.BigEndian.Uint16(msg[off+2 : off+4])
+	rd.Salt = bytes.Clone(msg[off+5 : end])
+	return nil
+}
+
+func (rd *NSEC3PARAM) parse(tokens []zoneToken, _ string) error {
+	if err := expectTokens(tokens, 4); err != nil {
+		return err
+	}
+	var err error
+	rd.HashAlgorithm, rd.Flags, rd.Iterations, rd.Salt, err = parseNSEC3Params(tokens)
+	return err
+}
+
+// parseNSEC3Params parses the hash algorithm, flags, iterations and salt shared by NSEC3 and NSEC3PARAM records.
+func parseNSEC3Params(tokens []zoneToken) (uint8, uint8, uint16, []byte, error) {
+	var values [3]uint64
+	for i, bitSize := range []int{8, 8, 16} {
+		value, err := parseUint(tokens[i], bitSize)
+		if err != nil {
+			return 0, 0, 0, nil, err
+		}
+		values[i] = value
+	}
+	salt := []byte{}
+	if tokens[3].text != "-" {
+		var err error
+		salt, err = hex.DecodeString(tokens[3].text)
+		if err != nil || len(salt) == 0 || len(salt) > 255 {
+			return 0, 0, 0, nil, fmt.Errorf("invalid NSEC3 salt: %s", tokens[3].text)
+		}
+	}
+	return uint8(values[0]), uint8(values[1]), uint16(values[2]), salt, nil
+}
+
+// formatSalt returns the salt of an NSEC3 or NSEC3PARAM record in hex, or - if it is empty.
+func formatSalt(salt []byte) string {
+	if len(salt) == 0 {
+		return "-"
+	}
+	return strings.ToUpper(hex.EncodeToString(salt))
+}
+
+// appendTypeBitMap appends the type bit map of the types to msg: for each window of 256 types holding any of them,
+// the window number, the length of its bitmap and the bitmap without its trailing zero octets.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-4.1.2 for more information
+func appendTypeBitMap(msg []byte, types []uint16) []byte {
+	sorted := slices.Clone(types)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	for i := 0; i < len(sorted); {
+		window := sorted[i] >> 8
+		var bitmap [maxTypeBitMapLen]byte
+		length := 0
+		for ; i < len(sorted) && sorted[i]>>8 == window; i++ {
+			low := sorted[i] & 0xFF
+			bitmap[low/8] |= 0x80 >> (low % 8)
+			length = int(low/8) + 1
+		}
+		msg = append(msg, byte(window), byte(length))
+		msg = append(msg, bitmap[:length]...)
+	}
+	return msg
+}
+
+// unpackTypeBitMap decodes a type bit map into the list of its types, in increasing order.
+// The windows must be in increasing order, with bitmaps of 1 to 32 octets.
+func unpackTypeBitMap(data []byte) ([]uint16, error) {
+	types := make([]uint16, 0)
+	lastWindow := -1
+	for off := 0; off < len(data); {
+		if off+2 > len(data) {
+			return nil, fmt.Errorf("type bit map window exceeds the resource data")
+		}
+		window, length := int(data[off]), int(data[off+1])
+		if window <= lastWindow {
+			return nil, fmt.Errorf("type bit map window %d is duplicate or out of order", window)
+		}
+		if length == 0 || length > maxTypeBitMapLen || off+2+length > len(data) {
+			return nil, fmt.Errorf("invalid type bit map length: %d", length)
+		}
+		for i, b := range data[off+2 : off+2+length] {
+			for bit := 0; bit < 8; bit++ {
+				if b&(0x80>>bit) != 0 {
+					types = append(types, uint16(window<<8|i*8+bit))
+				}
+			}
+		}
+		lastWindow = window
+		off += 2 + length
+	}
+	return types, nil
+}
+
+// formatTypeBitMap returns the mnemonics of the types in increasing order, each preceded by a space.
+func formatTypeBitMap(types []uint16) string {
+	sorted := slices.Clone(types)
+	slices.Sort(sorted)
+	var b strings.Builder
+	for _, rType := range slices.Compact(sorted) {
+		b.WriteByte(' ')
+		b.WriteString(RTypeToString(rType))
+	}
+	return b.String()
+}
+
+// parseTypeBitMap parses the mnemonics of the types of a type bit map.
+func parseTypeBitMap(tokens []zoneToken) ([]uint16, error) {
+	types := make([]uint16, 0, len(tokens))
+	for _, token := range tokens {
+		rType, ok := typeFromString(token.text)
+		if !ok {
+			return nil, fmt.Errorf("unknown record type: %s", token.text)
+		}
+		types = append(types, rType)
+	}
+	slices.Sort(types)
+	return slices.Compact(types), nil
+}
+
+// equalTypes reports whether the two lists hold the same types, ignoring their order and duplicates.
+func equalTypes(a, b []uint16) bool {
+	sortedA, sortedB := slices.Clone(a), slices.Clone(b)
+	slices.Sort(sortedA)
+	slices.Sort(sortedB)
+	return slices.Equal(slices.Compact(sortedA), slices.Compact(sortedB))
+}
+
+// parseBase64 decodes the base64 data split over the fields.
+func parseBase64(tokens []zoneToken) ([]byte, error) {
+	var text strings.Builder
+	for _, token := range tokens {
+		text.WriteString(token.text)
+	}
+	data, err := base64.StdEncoding.DecodeString(text.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 data: %s", text.String())
+	}
+	return data, nil
+}
+
+// parseHex decodes the hex data split over the fields.
+func parseHex(tokens []zoneToken) ([]byte, error) {
+	var text strings.Builder
+	for _, token := range tokens {
+		text.WriteString(token.text)
+	}
+	data, err := hex.DecodeString(text.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex data: %s", text.String())
+	}
+	return data, nil
+}
+
+// CompareNames compares two domain names in the canonical order of DNSSEC, in which the NSEC records of a zone
+// are chained: label by label starting from the root, each label compared as lowercase octets.
+// It returns -1 if a sorts before b, 1 if a sorts after b and 0 if the names are equal.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-6.1 for more information
+func CompareNames(a, b string) int {
+	labelsA, labelsB := canonicalLabels(a), canonicalLabels(b)
+	for i, j := len(labelsA)-1, len(labelsB)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := strings.Compare(labelsA[i], labelsB[j]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(labelsA), len(labelsB))
+}
+
+// NSEC3Hash returns the hash of the domain name used as owner name of its NSEC3 record: the SHA-1 digest of the
+// canonical wire format of the name followed by the salt, hashed again with the salt the given number of iterations.
+//
+// See https://datatracker.ietf.org/doc/html/rfc5155#section-5 for more information
+func NSEC3Hash(name string, hashAlgorithm uint8, iterations uint16, salt []byte) ([]byte, error) {
+	if hashAlgorithm != NSEC3HashSHA1 {
+		return nil, fmt.Errorf("unsupported NSEC3 hash algorithm: %d", hashAlgorithm)
+	}
+	digest, err := appendName(nil, canonicalName(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i <= int(iterations); i++ {
+		h := sha1.New()
+		h.Write(digest)
+		h.Write(salt)
+		digest = h.Sum(nil)
+	}
+	return digest, nil
+}
+
+// canonicalName returns the domain name with its uppercase ASCII letters lowered, as in the canonical form of RFC 4034,
+// and its escapes in their shortest form. The other bytes are left unchanged.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-6.2 for more information
+func canonicalName(name string) string {
+	if n, err := NewName(name); err == nil {
+		name = string(n)
+	}
+	return lowerASCII(name)
+}
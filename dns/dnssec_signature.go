@@ -0,0 +1,336 @@
+package dns
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedAlgorithm is returned when a key or a signature uses a DNSSEC algorithm that is not implemented.
+// Validators treat the zones signed only with such algorithms as unsigned.
+var ErrUnsupportedAlgorithm = errors.New("unsupported DNSSEC algorithm")
+
+// NewDNSKEY creates the resource data of a DNSKEY record holding the public key, for the given algorithm.
+// The key must be an *rsa.PublicKey, an *ecdsa.PublicKey or an ed25519.PublicKey matching the algorithm.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3110#section-2, https://datatracker.ietf.org/doc/html/rfc6605#section-4
+// and https://datatracker.ietf.org/doc/html/rfc8080#section-3 for more information
+func NewDNSKEY(flags uint16, algorithm uint8, publicKey crypto.PublicKey) (*DNSKEY, error) {
+	if err := checkKeyAlgorithm(publicKey, algorithm); err != nil {
+		return nil, err
+	}
+	var encoded []byte
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		exponent := big.NewInt(int64(key.E)).Bytes()
+		if len(exponent) > 255 {
+			encoded = binary.BigEndian.AppendUint16([]byte{0}, uint16(len(exponent)))
+		} else {
+			encoded = []byte{byte(len(exponent))}
+		}
+		encoded = append(encoded, exponent...)
+		encoded = append(encoded, key.N.Bytes()...)
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		encoded = make([]byte, 2*size)
+		key.X.FillBytes(encoded[:size])
+		key.Y.FillBytes(encoded[size:])
+	case ed25519.PublicKey:
+		encoded = bytes.Clone(key)
+	}
+	return &DNSKEY{Flags: flags, Protocol: DNSKEYProtocol, Algorithm: algorithm, PublicKey: encoded}, nil
+}
+
+// CryptoPublicKey decodes the public key of the DNSKEY into an *rsa.PublicKey, an *ecdsa.PublicKey or an
+// ed25519.PublicKey, depending on its algorithm. It fails with ErrUnsupportedAlgorithm for the other algorithms.
+func (rd *DNSKEY) CryptoPublicKey() (crypto.PublicKey, error) {
+	key := rd.PublicKey
+	switch rd.Algorithm {
+	case AlgorithmRSASHA1, AlgorithmRSASHA1NSEC3SHA1, AlgorithmRSASHA256, AlgorithmRSASHA512:
+		if len(key) < 1 {
+			return nil, fmt.Errorf("invalid RSA public key length: %d", len(key))
+		}
+		exponentLen, off := int(key[0]), 1
+		if exponentLen == 0 && len(key) >= 3 {
+			exponentLen, off = int(binary.BigEndian.Uint16(key[1:3])), 3
+		}
+		// Exponents larger than 32 bits are allowed by RFC 3110 but not used in practice
+		if exponentLen == 0 || exponentLen > 4 || off+exponentLen >= len(key) {
+			return nil, fmt.Errorf("invalid RSA public key exponent length: %d", exponentLen)
+		}
+		exponent := new(big.Int).SetBytes(key[off : off+exponentLen])
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(key[off+exponentLen:]), E: int(exponent.Int64())}, nil
+	case AlgorithmECDSAP256SHA256, AlgorithmECDSAP384SHA384:
+		curve, exchangeCurve := elliptic.P256(), ecdh.P256()
+		if rd.Algorithm == AlgorithmECDSAP384SHA384 {
+			curve, exchangeCurve = elliptic.P384(), ecdh.P384()
+		}
+		// The key is the uncompressed point without its 0x04 prefix, which ecdh checks to be on the curve
+		if _, err := exchangeCurve.NewPublicKey(append([]byte{4}, key...)); err != nil {
+			return nil, fmt.Errorf("invalid ECDSA public key: %w", err)
+		}
+		size := len(key) / 2
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(key[:size]), Y: new(big.Int).SetBytes(key[size:])}, nil
+	case AlgorithmED25519:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(key))
+		}
+		return ed25519.PublicKey(bytes.Clone(key)), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, rd.Algorithm)
+	}
+}
+
+// IsSupportedAlgorithm reports whether the signatures of the DNSSEC algorithm can be verified.
+func IsSupportedAlgorithm(algorithm uint8) bool {
+	_, err := (&DNSKEY{Algorithm: algorithm}).hash()
+	return err == nil
+}
+
+// ValidAt reports whether the time is within the validity period of the signature, from its inception
+// to its expiration. The times are compared with serial number arithmetic, so that they wrap around in 2106.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-3.1.5 for more information
+func (rd *RRSIG) ValidAt(t time.Time) bool {
+	now := uint32(t.Unix())
+	return int32(now-rd.Inception) >= 0 && int32(rd.Expiration-now) >= 0
+}
+
+// Sign signs the RRset with the private key, and sets the type covered, labels, original TTL and signature
+// of the RRSIG. The algorithm, inception, expiration, key tag and signer name must be set beforehand.
+// The key must be an *rsa.PrivateKey, an *ecdsa.PrivateKey or an ed25519.PrivateKey matching the algorithm.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-3.1.8.1 for more information
+func (rd *RRSIG) Sign(signer crypto.Signer, rrset []ResourceRecord) error {
+	if len(rrset) == 0 {
+		return fmt.Errorf("the RRset to sign is empty")
+	}
+	if err := checkKeyAlgorithm(signer.Public(), rd.Algorithm); err != nil {
+		return err
+	}
+	rd.TypeCovered = rrset[0].Type
+	rd.OriginalTTL = rrset[0].TTL
+	rd.Labels = uint8(CountLabels(strings.TrimPrefix(rrset[0].Name, "*.")))
+	if trimFQDN(rrset[0].Name) == "*" {
+		rd.Labels = 0
+	}
+	data, err := rd.signedData(rrset)
+	if err != nil {
+		return err
+	}
+	h, _ := (&DNSKEY{Algorithm: rd.Algorithm}).hash()
+	digest := data
+	if h != 0 {
+		hasher := h.New()
+		hasher.Write(data)
+		digest = hasher.Sum(nil)
+	}
+	signature, err := signer.Sign(rand.Reader, digest, h)
+	if err != nil {
+		return fmt.Errorf("failed to sign the RRset: %w", err)
+	}
+	if key, ok := signer.Public().(*ecdsa.PublicKey); ok {
+		// crypto.Signer returns an ASN.1 signature, while DNSSEC uses the concatenation of r and s
+		var values struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &values); err != nil {
+			return fmt.Errorf("failed to decode the ECDSA signature: %w", err)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		values.R.FillBytes(signature[:size])
+		values.S.FillBytes(signature[size:])
+	}
+	rd.Signature = signature
+	return nil
+}
+
+// Verify checks that the signature of the RRset was made by the private key of the DNSKEY.
+// It does not check the validity period of the signature, see ValidAt.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-5.3 for more information
+func (rd *RRSIG) Verify(key *DNSKEY, rrset []ResourceRecord) error {
+	if key.Algorithm != rd.Algorithm || key.KeyTag() != rd.KeyTag {
+		return fmt.Errorf("the signature was not made by the DNSKEY with key tag %d", key.KeyTag())
+	}
+	h, err := key.hash()
+	if err != nil {
+		return err
+	}
+	publicKey, err := key.CryptoPublicKey()
+	if err != nil {
+		return err
+	}
+	data, err := rd.signedData(rrset)
+	if err != nil {
+		return err
+	}
+	digest := data
+	if h != 0 {
+		hasher := h.New()
+		hasher.Write(data)
+		digest = hasher.Sum(nil)
+	}
+
+	valid := false
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(publicKey, h, digest, rd.Signature) == nil
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(rd.Signature) == 2*size {
+			r, s := new(big.Int).SetBytes(rd.Signature[:size]), new(big.Int).SetBytes(rd.Signature[size:])
+			valid = ecdsa.Verify(publicKey, digest, r, s)
+		}
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(publicKey, digest, rd.Signature)
+	}
+	if !valid {
+		return fmt.Errorf("invalid signature of the %s RRset of %s", RTypeToString(rd.TypeCovered), fqdn(rrset[0].Name))
+	}
+	return nil
+}
+
+// signedData returns the data covered by the signature: the resource data of the RRSIG without its signature,
+// followed by the records of the RRset in canonical form and order, with the original TTL.
+// The owner name of the records is the wildcard they were expanded from if the RRSIG has fewer labels.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-6 for more information
+func (rd *RRSIG) signedData(rrset []ResourceRecord) ([]byte, error) {
+	if len(rrset) == 0 {
+		return nil, fmt.Errorf("the RRset is empty")
+	}
+	header := *rd
+	header.SignerName = canonicalName(rd.SignerName)
+	header.Signature = nil
+	data, err := header.pack(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := canonicalName(trimFQDN(rrset[0].Name))
+	labels := SplitLabels(owner)
+	if int(rd.Labels) > len(labels) {
+		return nil, fmt.Errorf("the RRSIG has more labels than the owner name %s", fqdn(owner))
+	}
+	if int(rd.Labels) < len(labels) {
+		owner = strings.Join(append([]string{"*"}, labels[len(labels)-int(rd.Labels):]...), ".")
+	}
+
+	rDatas := make([][]byte, 0, len(rrset))
+	for _, rr := range rrset {
+		if rr.Type != rd.TypeCovered || rr.Class != rrset[0].Class || !equalNames(rr.Name, rrset[0].Name) {
+			return nil, fmt.Errorf("the records do not form a %s RRset of %s", RTypeToString(rd.TypeCovered), fqdn(rrset[0].Name))
+		}
+		rData, err := canonicalRData(rr)
+		if err != nil {
+			return nil, err
+		}
+		rDatas = append(rDatas, rData)
+	}
+	slices.SortFunc(rDatas, bytes.Compare)
+	rDatas = slices.CompactFunc(rDatas, bytes.Equal)
+
+	ownerWire, err := appendName(nil, owner, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, rData := range rDatas {
+		data = append(data, ownerWire...)
+		data = binary.BigEndian.AppendUint16(data, rd.TypeCovered)
+		data = binary.BigEndian.AppendUint16(data, rrset[0].Class)
+		data = binary.BigEndian.AppendUint32(data, rd.OriginalTTL)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(rData)))
+		data = append(data, rData...)
+	}
+	return data, nil
+}
+
+// canonicalRData returns the resource data of the record in canonical form: uncompressed, with the domain names
+// of the types listed in RFC 4034 lowered.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-6.2 for more information
+func canonicalRData(rr ResourceRecord) ([]byte, error) {
+	var data RData
+	switch rd := rr.Data.(type) {
+	case nil:
+		return rr.RData, nil
+	case *NS:
+		data = &NS{Host: canonicalName(rd.Host)}
+	case *CNAME:
+		data = &CNAME{Target: canonicalName(rd.Target)}
+	case *PTR:
+		data = &PTR{Target: canonicalName(rd.Target)}
+	case *DNAME:
+		data = &DNAME{Target: canonicalName(rd.Target)}
+	case *MX:
+		data = &MX{Preference: rd.Preference, Exchange: canonicalName(rd.Exchange)}
+	case *SRV:
+		data = &SRV{Priority: rd.Priority, Weight: rd.Weight, Port: rd.Port, Target: canonicalName(rd.Target)}
+	case *NAPTR:
+		naptr := *rd
+		naptr.Replacement = canonicalName(rd.Replacement)
+		data = &naptr
+	case *SOA:
+		soa := *rd
+		soa.MName, soa.RName = canonicalName(rd.MName), canonicalName(rd.RName)
+		data = &soa
+	case *RRSIG:
+		rrsig := *rd
+		rrsig.SignerName = canonicalName(rd.SignerName)
+		data = &rrsig
+	default:
+		data = rd
+	}
+	return PackRData(data)
+}
+
+// hash returns the hash function of the algorithm of the key, or 0 for Ed25519 which signs the data itself.
+func (rd *DNSKEY) hash() (crypto.Hash, error) {
+	switch rd.Algorithm {
+	case AlgorithmRSASHA1, AlgorithmRSASHA1NSEC3SHA1:
+		return crypto.SHA1, nil
+	case AlgorithmRSASHA256, AlgorithmECDSAP256SHA256:
+		return crypto.SHA256, nil
+	case AlgorithmRSASHA512:
+		return crypto.SHA512, nil
+	case AlgorithmECDSAP384SHA384:
+		return crypto.SHA384, nil
+	case AlgorithmED25519:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, rd.Algorithm)
+	}
+}
+
+// checkKeyAlgorithm checks that the public key can be used with the DNSSEC algorithm.
+func checkKeyAlgorithm(publicKey crypto.PublicKey, algorithm uint8) error {
+	if _, err := (&DNSKEY{Algorithm: algorithm}).hash(); err != nil {
+		return err
+	}
+	valid := false
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		valid = algorithm == AlgorithmRSASHA1 || algorithm == AlgorithmRSASHA1NSEC3SHA1 || algorithm == AlgorithmRSASHA256 || algorithm == AlgorithmRSASHA512
+	case *ecdsa.PublicKey:
+		valid = (algorithm == AlgorithmECDSAP256SHA256 && key.Curve == elliptic.P256()) || (algorithm == AlgorithmECDSAP384SHA384 && key.Curve == elliptic.P384())
+	case ed25519.PublicKey:
+		valid = algorithm == AlgorithmED25519
+	}
+	if !valid {
+		return fmt.Errorf("the %T key can not be used with the DNSSEC algorithm %d", publicKey, algorithm)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package dns
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSSECSignature(t *testing.T) {
+	mx := func(owner string, preference uint16, exchange string) ResourceRecord {
+		record, err := NewResourceRecordWithData(owner, ClassIN, 3600, &MX{Preference: preference, Exchange: exchange})
+		if err != nil {
+			t.Fatalf("failed to create the MX record: %v", err)
+		}
+		return *record
+	}
+
+	t.Run("Should verify a signature of RFC 8080", func(t *testing.T) {
+		key, _ := base64.StdEncoding.DecodeString("l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4=")
+		signature, _ := base64.StdEncoding.DecodeString("oL9krJun7xfBOIWcGHi7mag5/hdZrKWw15jPGrHpjQeRAvTdszaPD+QLs3fx8A4M3e23mRZ9VrbpMngwcrqNAg==")
+		dnskey := &DNSKEY{Flags: DNSKEYFlagZone | DNSKEYFlagSEP, Protocol: DNSKEYProtocol, Algorithm: AlgorithmED25519, PublicKey: key}
+		rrsig := &RRSIG{TypeCovered: TypeMX, Algorithm: AlgorithmED25519, Labels: 2, OriginalTTL: 3600, Expiration: 1440021600,
+			Inception: 1438207200, KeyTag: 3613, SignerName: "example.com", Signature: signature}
+		assert.Equal(t, uint16(3613), dnskey.KeyTag())
+		assert.NoError(t, rrsig.Verify(dnskey, []ResourceRecord{mx("example.com", 10, "mail.example.com")}))
+		assert.Error(t, rrsig.Verify(dnskey, []ResourceRecord{mx("example.com", 20, "mail.example.com")}))
+	})
+
+	t.Run("Should sign and verify RRsets with every supported algorithm", func(t *testing.T) {
+		rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		p256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+		rrset := []ResourceRecord{mx("example.com", 20, "Backup.Example.com"), mx("example.com", 10, "mail.example.com")}
+
+		for algorithm, signer := range map[uint8]crypto.Signer{
+			AlgorithmRSASHA256:       rsaKey,
+			AlgorithmRSASHA512:       rsaKey,
+			AlgorithmECDSAP256SHA256: p256Key,
+			AlgorithmECDSAP384SHA384: p384Key,
+			AlgorithmED25519:         ed25519Key,
+		} {
+			dnskey, err := NewDNSKEY(DNSKEYFlagZone, algorithm, signer.Public())
+			assert.NoError(t, err)
+			publicKey, err := dnskey.CryptoPublicKey()
+			assert.NoError(t, err)
+			assert.True(t, publicKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(signer.Public()))
+
+			rrsig := &RRSIG{Algorithm: algorithm, Expiration: 2000000000, Inception: 1000000000, KeyTag: dnskey.KeyTag(), SignerName: "example.com"}
+			assert.NoError(t, rrsig.Sign(signer, rrset))
+			assert.Equal(t, TypeMX, rrsig.TypeCovered)
+			assert.Equal(t, uint8(2), rrsig.Labels)
+
+			// The canonical form ignores the order and the case of the names
+			reordered := []ResourceRecord{mx("EXAMPLE.com.", 10, "mail.example.com"), mx("example.com", 20, "backup.example.com")}
+			assert.NoError(t, rrsig.Verify(dnskey, reordered), "algorithm %d", algorithm)
+
+			reordered[0].TTL = 60
+			assert.NoError(t, rrsig.Verify(dnskey, reordered), "the TTL is replaced with the original TTL")
+			assert.Error(t, rrsig.Verify(dnskey, rrset[:1]), "algorithm %d", algorithm)
+		}
+	})
+
+	t.Run("Should verify the records expanded from a wildcard", func(t *testing.T) {
+		_, key, _ := ed25519.GenerateKey(rand.Reader)
+		dnskey, _ := NewDNSKEY(DNSKEYFlagZone, AlgorithmED25519, key.Public())
+		rrsig := &RRSIG{Algorithm: AlgorithmED25519, Expiration: 2000000000, KeyTag: dnskey.KeyTag(), SignerName: "example.com"}
+		assert.NoError(t, rrsig.Sign(key, []ResourceRecord{mx("*.example.com", 10, "mail.example.com")}))
+		assert.Equal(t, uint8(2), rrsig.Labels)
+
+		assert.NoError(t, rrsig.Verify(dnskey, []ResourceRecord{mx("a.b.example.com", 10, "mail.example.com")}))
+		assert.Error(t, rrsig.Verify(dnskey, []ResourceRecord{mx("com", 10, "mail.example.com")}))
+	})
+
+	t.Run("Should reject keys and signatures that do not match the algorithm", func(t *testing.T) {
+		_, key, _ := ed25519.GenerateKey(rand.Reader)
+		_, err := NewDNSKEY(DNSKEYFlagZone, AlgorithmECDSAP256SHA256, key.Public())
+		assert.Error(t, err)
+		assert.Error(t, (&RRSIG{Algorithm: AlgorithmRSASHA256}).Sign(key, []ResourceRecord{mx("example.com", 10, "mail.example.com")}))
+
+		_, err = (&DNSKEY{Algorithm: AlgorithmED448, PublicKey: make([]byte, 57)}).CryptoPublicKey()
+		assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+		assert.False(t, IsSupportedAlgorithm(AlgorithmED448))
+		assert.True(t, IsSupportedAlgorithm(AlgorithmECDSAP256SHA256))
+
+		for _, invalid := range []*DNSKEY{
+			{Algorithm: AlgorithmRSASHA256, PublicKey: []byte{3, 1, 0, 1}},
+			{Algorithm: AlgorithmECDSAP256SHA256, PublicKey: make([]byte, 64)},
+			{Algorithm: AlgorithmED25519, PublicKey: make([]byte, 31)},
+		} {
+			_, err := invalid.CryptoPublicKey()
+			assert.Error(t, err, "algorithm %d", invalid.Algorithm)
+		}
+	})
+
+	t.Run("Should check the validity period of a signature", func(t *testing.T) {
+		rrsig := &RRSIG{Inception: 1000, Expiration: 2000}
+		assert.True(t, rrsig.ValidAt(time.Unix(1500, 0)))
+		assert.False(t, rrsig.ValidAt(time.Unix(2001, 0)))
+		assert.False(t, rrsig.ValidAt(time.Unix(999, 0)))
+
+		// The period may wrap around the 32 bits of the times
+		rrsig = &RRSIG{Inception: 0xFFFFFF00, Expiration: 0x100}
+		assert.True(t, rrsig.ValidAt(time.Unix(0x100000000, 0)))
+	})
+
+	t.Run("Should sort names in the canonical order", func(t *testing.T) {
+		expected := []string{"example", "a.example", "yljkjljk.a.example", "Z.a.example", "zABC.a.EXAMPLE", "z.example", "*.z.example", "zz.example"}
+		names := slices.Clone(expected)
+		slices.Reverse(names)
+		slices.SortFunc(names, CompareNames)
+		assert.Equal(t, expected, names)
+		assert.Equal(t, 0, CompareNames("Example.com.", "example.com"))
+		assert.Equal(t, 2, CountLabels("example.com."))
+		assert.Equal(t, 0, CountLabels("."))
+	})
+
+	t.Run("Should hash names as in RFC 5155", func(t *testing.T) {
+		salt := []byte{0xAA, 0xBB, 0xCC, 0xDD}
+		for name, expected := range map[string]string{
+			"example":     "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom",
+			"a.example":   "35mthgpgcu1qg68fab165klnsnk3dpvl",
+			"*.w.example": "r53bq7cc2uvmubfu5ocmm6pers9tk9en",
+		} {
+			hash, err := NSEC3Hash(name, NSEC3HashSHA1, 12, salt)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, strings.ToLower(base32Hex.EncodeToString(hash)), name)
+		}
+		_, err := NSEC3Hash("example", 2, 12, salt)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,147 @@
+package dns
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSSEC(t *testing.T) {
+	// Examples of RFC 4034
+	decodeBase64 := func(s string) []byte {
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("invalid base64: %v", err)
+		}
+		return data
+	}
+	dnskey := &DNSKEY{Flags: DNSKEYFlagZone, Protocol: DNSKEYProtocol, Algorithm: AlgorithmRSASHA1, PublicKey: decodeBase64(
+		"AQPSKmynfzW4kyBv015MUG2DeIQ3Cbl+BBZH4b/0PY1kxkmvHjcZc8nokfzj31GajIQKY+5CptLr3buXA10hWqTkF7H6RfoRqXQeogmMHfpftf6zMv1LyBUgia7za6ZEzOJBOztyvhjL742iU/TpPSEDhm2SNKLijfUppn1UaNvv4w==")}
+	dsKey := &DNSKEY{Flags: DNSKEYFlagZone, Protocol: DNSKEYProtocol, Algorithm: AlgorithmRSASHA1, PublicKey: decodeBase64(
+		"AQOeiiR0GOMYkDshWoSKz9XzfwJr1AYtsmx3TGkJaNXVbfi/2pHm822aJ5iI9BMzNXxeYCmZDRD99WYwYqUSdjMmmAphXdvxegXd/M5+X7OrzKBaMbCVdFLUUh6DhweJBjEVv5f2wwjM9XzcnOf+EPbtG9DMBmADjFDc2w/rljwvFw==")}
+
+	t.Run("Should compute the key tag of a DNSKEY", func(t *testing.T) {
+		assert.Equal(t, uint16(2642), dnskey.KeyTag())
+		assert.Equal(t, uint16(60485), dsKey.KeyTag())
+		assert.Equal(t, uint16(0x0203), (&DNSKEY{Algorithm: AlgorithmRSAMD5, PublicKey: []byte{1, 2, 3, 4}}).KeyTag())
+	})
+
+	t.Run("Should compute the DS record of a DNSKEY", func(t *testing.T) {
+		ds, err := dsKey.ToDS("DSKEY.example.com.", DigestSHA1)
+		assert.NoError(t, err)
+		assert.Equal(t, "60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118", ds.String())
+
+		ds, err = dsKey.ToDS("dskey.example.com", DigestSHA256)
+		assert.NoError(t, err)
+		assert.Equal(t, 32, len(ds.Digest))
+		_, err = dsKey.ToDS("dskey.example.com", 3)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should pack and unpack the DNSSEC records", func(t *testing.T) {
+		for _, record := range []RData{
+			dnskey,
+			&DS{KeyTag: 60485, Algorithm: AlgorithmRSASHA1, DigestType: DigestSHA1, Digest: []byte{0x2B, 0xB1, 0x83}},
+			&RRSIG{TypeCovered: TypeA, Algorithm: AlgorithmRSASHA1, Labels: 3, OriginalTTL: 86400, Expiration: 1048354263,
+				Inception: 1045762263, KeyTag: 2642, SignerName: "example.com", Signature: []byte{1, 2, 3}},
+			&NSEC{NextDomain: "host.example.com", TypeBitMap: []uint16{TypeA, TypeMX, TypeRRSIG, TypeNSEC, 1234}},
+			&NSEC{NextDomain: "example.com", TypeBitMap: []uint16{}},
+			&NSEC3{HashAlgorithm: NSEC3HashSHA1, Flags: NSEC3FlagOptOut, Iterations: 12, Salt: []byte{0xAA, 0xBB},
+				NextHashedOwner: []byte{1, 2, 3, 4}, TypeBitMap: []uint16{TypeNS, TypeDS, TypeRRSIG}},
+			&NSEC3PARAM{HashAlgorithm: NSEC3HashSHA1, Iterations: 12, Salt: []byte{}},
+		} {
+			rData, err := PackRData(record)
+			assert.NoError(t, err)
+			unpacked, err := UnpackRData(record.Type(), rData)
+			assert.NoError(t, err)
+			assert.True(t, record.Equal(unpacked), "%s", record)
+			assert.Equal(t, record.String(), unpacked.String())
+		}
+	})
+
+	t.Run("Should encode the type bit map of an NSEC record", func(t *testing.T) {
+		expected, _ := hex.DecodeString("04686f7374076578616d706c6503636f6d00" + "0006400100000003" + "041b" + strings.Repeat("00", 26) + "20")
+		rData, err := PackRData(&NSEC{NextDomain: "host.example.com", TypeBitMap: []uint16{1234, TypeNSEC, TypeA, TypeMX, TypeRRSIG, TypeA}})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, rData)
+
+		unpacked, err := UnpackRData(TypeNSEC, rData)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint16{TypeA, TypeMX, TypeRRSIG, TypeNSEC, 1234}, unpacked.(*NSEC).TypeBitMap)
+		assert.Equal(t, "host.example.com. A MX RRSIG NSEC TYPE1234", unpacked.String())
+	})
+
+	t.Run("Should reject invalid type bit maps", func(t *testing.T) {
+		for _, bitMap := range [][]byte{
+			{0},                      // truncated window
+			{0, 0},                   // empty bitmap
+			{0, 33},                  // bitmap too long
+			{0, 2, 0x40},             // bitmap exceeding the data
+			{1, 1, 0x40, 0, 1, 0x40}, // windows out of order
+			{0, 1, 0x40, 0, 1, 0x20}, // duplicate window
+		} {
+			_, err := UnpackRData(TypeNSEC, append([]byte{0}, bitMap...))
+			assert.Error(t, err, "%v", bitMap)
+		}
+	})
+
+	t.Run("Should reject truncated DNSSEC records", func(t *testing.T) {
+		for rType, rData := range map[uint16][]byte{
+			TypeDNSKEY:     {1, 0, 3},
+			TypeDS:         {0, 1, 5},
+			TypeRRSIG:      {0, 1, 5, 2, 0, 0, 0, 1},
+			TypeNSEC:       {},
+			TypeNSEC3:      {1, 0, 0, 12, 2, 0xAA},
+			TypeNSEC3PARAM: {1, 0, 0, 12, 2, 0xAA},
+		} {
+			_, err := UnpackRData(rType, rData)
+			assert.Error(t, err, RTypeToString(rType))
+		}
+		_, err := UnpackRData(TypeNSEC3, []byte{1, 0, 0, 12, 0, 0})
+		assert.Error(t, err, "NSEC3 with an empty next hashed owner name")
+	})
+
+	t.Run("Should read and write DNSSEC records in zone files", func(t *testing.T) {
+		zone := `$TTL 86400
+example.com. DNSKEY 256 3 5 ( AQPSKmynfzW4kyBv015MUG2DeIQ3Cbl+BBZH4b/0PY1kxkmvHjcZc8nokfzj31GajIQKY+5CptLr3buXA10hWqTkF7H6RfoRqXQeogmMHfpftf6zMv1LyBUgia7za6ZEzOJBOztyvhjL742iU/TpPSEDhm2SNKLijfUppn1UaNvv4w== )
+host RRSIG A 5 3 86400 20030322173103 ( 20030220173103 2642 example.com.
+  oJB1W6WNGv+ldvQ3WDG0MQkg5IEhjRip8WTrPYGv07h108dUKGMeDPKijVCHX3DDKdfb+v6oB9wfuh3DTJXUAfI/M0zmO/zz8bW0Rznl8O3tGNazPwQKkRN20XPXV6nwwfoXmJQbsLNrLfkGJ5D6fwFm8nN+6pBzeDQfsS3Ap3o= )
+dskey DS 60485 5 1 ( 2BB183AF5F22588179A53B0A98631FAD1A292118 )
+alfa NSEC host A MX RRSIG NSEC TYPE1234
+0p9mhaveqvm6t7vbl5lop2u3t2rp3tom NSEC3 1 1 12 aabbccdd ( 2t7b4g4vsa5smi47k61mv5bv1a22bojr MX DNSKEY NS SOA NSEC3PARAM RRSIG )
+@ NSEC3PARAM 1 0 12 aabbccdd
+`
+		records, err := ParseZone(strings.NewReader(zone), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 6, len(records)) {
+			assert.True(t, dnskey.Equal(records[0].Data))
+			rrsig := records[1].Data.(*RRSIG)
+			assert.Equal(t, uint32(1048354263), rrsig.Expiration)
+			assert.Equal(t, uint32(1045762263), rrsig.Inception)
+			assert.Equal(t, "example.com", rrsig.SignerName)
+			assert.Equal(t, "A 5 3 86400 20030322173103 20030220173103 2642 example.com. oJB1W6WNGv+ldvQ3WDG0MQkg5IEhjRip8WTrPYGv07h108dUKGMeDPKijVCHX3DDKdfb+v6oB9wfuh3DTJXUAfI/M0zmO/zz8bW0Rznl8O3tGNazPwQKkRN20XPXV6nwwfoXmJQbsLNrLfkGJ5D6fwFm8nN+6pBzeDQfsS3Ap3o=", rrsig.String())
+			assert.Equal(t, "60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118", records[2].Data.String())
+			assert.Equal(t, "host.example.com. A MX RRSIG NSEC TYPE1234", records[3].Data.String())
+			assert.Equal(t, "1 1 12 AABBCCDD 2t7b4g4vsa5smi47k61mv5bv1a22bojr NS SOA MX RRSIG DNSKEY NSEC3PARAM", records[4].Data.String())
+			assert.True(t, records[4].Data.(*NSEC3).IsOptOut())
+			assert.Equal(t, "1 0 12 AABBCCDD", records[5].Data.String())
+		}
+
+		rrsig := &RRSIG{}
+		assert.NoError(t, rrsig.parse(lexRData(t, "A 5 3 86400 1048354263 1045762263 2642 example.com. AQID"), ""))
+		assert.Equal(t, "20030322173103", formatSignatureTime(rrsig.Expiration))
+		assert.NoError(t, (&NSEC3PARAM{}).parse(lexRData(t, "1 0 0 -"), ""))
+
+		for _, invalid := range []string{
+			"@ DNSKEY 256 3 5 !!!", "@ DS 1 5 1 XYZ", "@ RRSIG A 5 3 86400 20031322173103 20030220173103 2642 example.com. AQID",
+			"@ RRSIG BOGUS 5 3 86400 1 0 2642 example.com. AQID", "@ NSEC host A BOGUS", "@ NSEC3 1 1 12 aabbccdd 2t7b4g4vs!",
+			"@ NSEC3PARAM 1 0 12 xyz",
+		} {
+			_, err := ParseZone(strings.NewReader(invalid), "example.com.", "")
+			assert.Error(t, err, invalid)
+		}
+	})
+}
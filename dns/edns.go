@@ -0,0 +1,314 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// EDNS defaults
+const (
+	MaxUDPSize         = 512  // maximum size of a UDP message without EDNS
+	DefaultEDNSUDPSize = 1232 // UDP payload size advertised by default, which avoids IP fragmentation
+	EDNSVersion        = 0    // version of EDNS implemented by this package
+)
+
+// EDNS option codes
+const (
+	EDNSOptionNSID         uint16 = 3  // name server identifier (RFC 5001)
+	EDNSOptionClientSubnet uint16 = 8  // client subnet (RFC 7871)
+	EDNSOptionCookie       uint16 = 10 // DNS cookie (RFC 7873)
+	EDNSOptionPadding      uint16 = 12 // padding (RFC 7830)
+)
+
+// Extended response codes, which need the upper 8 bits stored in the OPT pseudo-record.
+const (
+	RCodeBadVersion uint16 = 16 // Bad version - The responder does not implement the EDNS version of the request
+	RCodeBadCookie  uint16 = 23 // Bad cookie - The server cookie of the request is missing or invalid, see CookieOption
+)
+
+// EDNSOption is a typed option carried in the resource data of an OPT pseudo-record.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6891#section-6.1.2 for more information
+type EDNSOption interface {
+	// Code returns the option code.
+	Code() uint16
+	// String returns a human readable representation of the option.
+	String() string
+
+	// pack appends the option data, without the code and length, to b.
+	pack(b []byte) ([]byte, error)
+	// unpack decodes the option data, without the code and length.
+	unpack(data []byte) error
+}
+
+// newEDNSOption returns an empty EDNSOption of the given code, or an UnknownOption if the code is not supported.
+func newEDNSOption(code uint16) EDNSOption {
+	switch code {
+	case EDNSOptionNSID:
+		return &NSIDOption{}
+	case EDNSOptionClientSubnet:
+		return &ClientSubnetOption{}
+	case EDNSOptionCookie:
+		return &CookieOption{}
+	default:
+		return &UnknownOption{OptionCode: code}
+	}
+}
+
+// NSIDOption is the name server identifier option.
+// Queries carry it empty to request the identifier, which responses carry back.
+//
+// See https://datatracker.ietf.org/doc/html/rfc5001 for more information
+type NSIDOption struct {
+	ID []byte
+}
+
+// Code returns EDNSOptionNSID.
+func (o *NSIDOption) Code() uint16 { return EDNSOptionNSID }
+
+// String returns the identifier in hex, followed by its printable form.
+func (o *NSIDOption) String() string {
+	return fmt.Sprintf("NSID: %s (%q)", hex.EncodeToString(o.ID), string(o.ID))
+}
+
+func (o *NSIDOption) pack(b []byte) ([]byte, error) { return append(b, o.ID...), nil }
+
+func (o *NSIDOption) unpack(data []byte) error {
+	o.ID = bytes.Clone(data)
+	return nil
+}
+
+// UnknownOption is an EDNS option whose code is not supported. Its data is kept as is.
+type UnknownOption struct {
+	OptionCode uint16
+	Data       []byte
+}
+
+// Code returns the code of the option.
+func (o *UnknownOption) Code() uint16 { return o.OptionCode }
+
+// String returns the option code followed by the hex encoded data.
+func (o *UnknownOption) String() string {
+	return fmt.Sprintf("OPT%d: %s", o.OptionCode, hex.EncodeToString(o.Data))
+}
+
+func (o *UnknownOption) pack(b []byte) ([]byte, error) { return append(b, o.Data...), nil }
+
+func (o *UnknownOption) unpack(data []byte) error {
+	o.Data = bytes.Clone(data)
+	return nil
+}
+
+// OPT is the resource data of the OPT pseudo-record, the list of EDNS options.
+// The other EDNS fields are stored in the class and TTL of the record, see EDNS.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6891#section-6.1 for more information
+type OPT struct {
+	Options []EDNSOption
+}
+
+// Type returns TypeOPT.
+func (rd *OPT) Type() uint16 { return TypeOPT }
+
+// String returns the options separated by semicolons.
+func (rd *OPT) String() string {
+	options := make([]string, len(rd.Options))
+	for i, option := range rd.Options {
+		options[i] = option.String()
+	}
+	return strings.Join(options, "; ")
+}
+
+// Equal reports whether other is an OPT record holding the same options.
+func (rd *OPT) Equal(other RData) bool {
+	o, ok := other.(*OPT)
+	if !ok {
+		return false
+	}
+	a, errA := PackRData(rd)
+	b, errB := PackRData(o)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+func (rd *OPT) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	for _, option := range rd.Options {
+		msg = binary.BigEndian.AppendUint16(msg, option.Code())
+		lengthOffset := len(msg)
+		msg = append(msg, 0, 0)
+		var err error
+		if msg, err = option.pack(msg); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint16(msg[lengthOffset:], uint16(len(msg)-lengthOffset-2))
+	}
+	return msg, nil
+}
+
+func (rd *OPT) unpack(msg []byte, off, end int) error {
+	rd.Options = make([]EDNSOption, 0)
+	for off < end {
+		if off+4 > end {
+			return fmt.Errorf("EDNS option header exceeds the resource data")
+		}
+		code := binary.BigEndian.Uint16(msg[off : off+2])
+		length := int(binary.BigEndian.Uint16(msg[off+2 : off+4]))
+		off += 4
+		if off+length > end {
+			return fmt.Errorf("EDNS option %d exceeds the resource data", code)
+		}
+		option := newEDNSOption(code)
+		if err := option.unpack(msg[off : off+length]); err != nil {
+			return fmt.Errorf("invalid EDNS option %d: %w", code, err)
+		}
+		rd.Options = append(rd.Options, option)
+		off += length
+	}
+	return nil
+}
+
+// parse always fails: OPT pseudo-records only exist in messages, not in zone files.
+func (rd *OPT) parse(_ []zoneToken, _ string) error {
+	return fmt.Errorf("OPT pseudo-records can not appear in zone files")
+}
+
+// EDNS holds the content of an OPT pseudo-record: the fields stored in its class and TTL and its options.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6891#section-6.1.3 for more information
+type EDNS struct {
+	UDPSize       uint16       // UDPSize is the largest UDP payload the sender can reassemble.
+	ExtendedRCode uint8        // ExtendedRCode holds the upper 8 bits of the 12-bit response code.
+	Version       uint8        // Version is the EDNS version of the sender.
+	DO            bool         // DO indicates that the sender supports DNSSEC records.
+	Z             uint16       // Z holds the remaining 15 reserved flag bits.
+	Options       []EDNSOption // Options is the list of EDNS options.
+}
+
+// NewEDNS creates a new EDNS instance advertising the given UDP payload size.
+func NewEDNS(udpSize uint16, options ...EDNSOption) *EDNS {
+	return &EDNS{
+		UDPSize: udpSize,
+		Version: EDNSVersion,
+		Options: options,
+	}
+}
+
+// Option returns the first option with the given code, or nil if there is none.
+func (e *EDNS) Option(code uint16) EDNSOption {
+	for _, option := range e.Options {
+		if option.Code() == code {
+			return option
+		}
+	}
+	return nil
+}
+
+// SetOption replaces the options with the same code as the given option by it, or adds it.
+func (e *EDNS) SetOption(option EDNSOption) {
+	options := make([]EDNSOption, 0, len(e.Options)+1)
+	for _, o := range e.Options {
+		if o.Code() != option.Code() {
+			options = append(options, o)
+		}
+	}
+	e.Options = append(options, option)
+}
+
+// String returns the EDNS pseudo-section as printed by dig.
+func (e *EDNS) String() string {
+	flags := ""
+	if e.DO {
+		flags = " do"
+	}
+	lines := []string{fmt.Sprintf("; EDNS: version: %d, flags:%s; udp: %d", e.Version, flags, e.UDPSize)}
+	for _, option := range e.Options {
+		lines = append(lines, "; "+option.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ResourceRecord returns the OPT pseudo-record holding the EDNS fields, owned by the root domain.
+// The resource data is written from the Data field of the record: if an option is not valid, RData is nil
+// and packing the record, or the message holding it, fails with the error of the option.
+func (e *EDNS) ResourceRecord() *ResourceRecord {
+	ttl := uint32(e.ExtendedRCode)<<24 | uint32(e.Version)<<16 | uint32(e.Z&0x7FFF)
+	if e.DO {
+		ttl |= 1 << 15
+	}
+	data := &OPT{Options: e.Options}
+	rData, err := PackRData(data)
+	if err != nil {
+		rData = nil
+	}
+	return &ResourceRecord{
+		Name:        "",
+		Type:        TypeOPT,
+		Class:       e.UDPSize,
+		TTL:         ttl,
+		RDLength:    uint16(len(rData)),
+		RData:       rData,
+		RDataParsed: data.String(),
+		Data:        data,
+	}
+}
+
+// EDNSFromResourceRecord decodes the EDNS fields of an OPT pseudo-record.
+func EDNSFromResourceRecord(rr *ResourceRecord) (*EDNS, error) {
+	if rr.Type != TypeOPT {
+		return nil, fmt.Errorf("not an OPT record: %s", RTypeToString(rr.Type))
+	}
+	e := &EDNS{
+		UDPSize:       rr.Class,
+		ExtendedRCode: uint8(rr.TTL >> 24),
+		Version:       uint8(rr.TTL >> 16),
+		DO:            rr.TTL&(1<<15) != 0,
+		Z:             uint16(rr.TTL & 0x7FFF),
+	}
+	if opt, ok := rr.Data.(*OPT); ok {
+		e.Options = opt.Options
+	}
+	if e.UDPSize < MaxUDPSize {
+		// Values lower than 512 must be treated as 512
+		e.UDPSize = MaxUDPSize
+	}
+	return e, nil
+}
+
+// EDNS returns the EDNS fields of the OPT pseudo-record of the message, or nil if the message has none.
+func (m *DNSMessage) EDNS() *EDNS {
+	for i := range m.AdditionalRRs {
+		if m.AdditionalRRs[i].Type == TypeOPT {
+			e, _ := EDNSFromResourceRecord(&m.AdditionalRRs[i])
+			return e
+		}
+	}
+	return nil
+}
+
+// SetEDNS adds the OPT pseudo-record describing e to the additional section, replacing any existing one.
+// If e is nil, the OPT pseudo-record is removed. The additional count of the header is kept up to date.
+func (m *DNSMessage) SetEDNS(e *EDNS) {
+	additionalRRs := make([]ResourceRecord, 0, len(m.AdditionalRRs)+1)
+	for _, rr := range m.AdditionalRRs {
+		if rr.Type != TypeOPT {
+			additionalRRs = append(additionalRRs, rr)
+		}
+	}
+	if e != nil {
+		additionalRRs = append(additionalRRs, *e.ResourceRecord())
+	}
+	m.AdditionalRRs = additionalRRs
+	m.Header.ARCount = uint16(len(additionalRRs))
+}
+
+// RCode returns the full response code of the message, combining the 4 bits of the header
+// with the 8 bits stored in the OPT pseudo-record, if any.
+func (m *DNSMessage) RCode() uint16 {
+	rcode := m.Header.Flags & 0b1111
+	if e := m.EDNS(); e != nil {
+		rcode |= uint16(e.ExtendedRCode) << 4
+	}
+	return rcode
+}
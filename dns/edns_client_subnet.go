@@ -0,0 +1,128 @@
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+)
+
+// Address families of the client subnet option, as registered by IANA.
+const (
+	FamilyIPv4 uint16 = 1
+	FamilyIPv6 uint16 = 2
+)
+
+// Source prefix lengths sent by default, which hide the client address as recommended by RFC 7871 section 11.1.
+const (
+	DefaultClientSubnetPrefixIPv4 = 24
+	DefaultClientSubnetPrefixIPv6 = 56
+)
+
+// ClientSubnetOption is the EDNS Client Subnet option.
+// Resolvers send the subnet of their client so that authoritative servers can tailor the answer to it,
+// and the servers reply with the scope of the subnet the answer is valid for.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7871 for more information
+type ClientSubnetOption struct {
+	Source      netip.Prefix // Source is the client subnet, whose bits is the source prefix length.
+	ScopePrefix uint8        // ScopePrefix is the prefix length of the subnet the answer is valid for, set by servers.
+}
+
+// NewClientSubnetOption creates a new ClientSubnetOption for the given subnet, truncating its address to the prefix length.
+func NewClientSubnetOption(source netip.Prefix) *ClientSubnetOption {
+	return &ClientSubnetOption{Source: source.Masked()}
+}
+
+// DefaultClientSubnet returns the subnet of the address to send in a ClientSubnetOption,
+// using the default source prefix length of its address family.
+func DefaultClientSubnet(addr netip.Addr) netip.Prefix {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return netip.PrefixFrom(addr, DefaultClientSubnetPrefixIPv4).Masked()
+	}
+	return netip.PrefixFrom(addr, DefaultClientSubnetPrefixIPv6).Masked()
+}
+
+// Code returns EDNSOptionClientSubnet.
+func (o *ClientSubnetOption) Code() uint16 { return EDNSOptionClientSubnet }
+
+// String returns the subnet followed by the scope prefix length, as printed by dig.
+func (o *ClientSubnetOption) String() string {
+	return fmt.Sprintf("CLIENT-SUBNET: %s/%d", o.Source, o.ScopePrefix)
+}
+
+// Scope returns the subnet the answer carrying the option is valid for.
+// The scope prefix length is capped to the source prefix length, since an answer can not be
+// more specific than the subnet that was sent.
+func (o *ClientSubnetOption) Scope() netip.Prefix {
+	bits := min(int(o.ScopePrefix), o.Source.Bits())
+	return netip.PrefixFrom(o.Source.Addr(), bits).Masked()
+}
+
+func (o *ClientSubnetOption) pack(b []byte) ([]byte, error) {
+	if !o.Source.IsValid() {
+		return nil, fmt.Errorf("invalid client subnet: %s", o.Source)
+	}
+	family := FamilyIPv6
+	if o.Source.Addr().Is4() {
+		family = FamilyIPv4
+	}
+	source := o.Source.Masked()
+	b = binary.BigEndian.AppendUint16(b, family)
+	b = append(b, uint8(source.Bits()), o.ScopePrefix)
+	// Only the octets covered by the source prefix length are sent
+	return append(b, source.Addr().AsSlice()[:(source.Bits()+7)/8]...), nil
+}
+
+func (o *ClientSubnetOption) unpack(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("invalid client subnet length: %d", len(data))
+	}
+	family := binary.BigEndian.Uint16(data[0:2])
+	sourcePrefix := int(data[2])
+	address := data[4:]
+
+	var addr [16]byte
+	addrLength := 0
+	switch family {
+	case FamilyIPv4:
+		addrLength = 4
+	case FamilyIPv6:
+		addrLength = 16
+	default:
+		return fmt.Errorf("unsupported client subnet family: %d", family)
+	}
+	if sourcePrefix > addrLength*8 || len(address) != (sourcePrefix+7)/8 {
+		return fmt.Errorf("invalid client subnet address length %d for the source prefix length %d", len(address), sourcePrefix)
+	}
+	copy(addr[:], address)
+
+	ip := netip.AddrFrom16(addr)
+	if family == FamilyIPv4 {
+		ip = netip.AddrFrom4([4]byte(addr[:4]))
+	}
+	source := netip.PrefixFrom(ip, sourcePrefix)
+	if source.Masked() != source {
+		return fmt.Errorf("client subnet %s has bits set beyond the source prefix length", source)
+	}
+	o.Source = source
+	o.ScopePrefix = data[3]
+	return nil
+}
+
+// ParseClientSubnet parses a client subnet given as an address with an optional prefix length, such as
+// "192.0.2.1" or "2001:db8::/48". Without prefix length, the default one of the address family is used.
+// The address is truncated to the prefix length.
+func ParseClientSubnet(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return DefaultClientSubnet(addr), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid client subnet: %s", s)
+	}
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
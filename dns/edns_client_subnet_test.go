@@ -0,0 +1,85 @@
+package dns
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientSubnetOption(t *testing.T) {
+	t.Run("Should encode only the octets covered by the source prefix", func(t *testing.T) {
+		option := NewClientSubnetOption(netip.MustParsePrefix("192.0.2.77/22"))
+		data, err := option.pack(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 1, 22, 0, 192, 0, 0}, data)
+
+		option = NewClientSubnetOption(netip.MustParsePrefix("2001:db8:1234::/48"))
+		data, err = option.pack(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 2, 48, 0, 0x20, 0x01, 0x0d, 0xb8, 0x12, 0x34}, data)
+
+		option = NewClientSubnetOption(netip.MustParsePrefix("0.0.0.0/0"))
+		data, err = option.pack(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 1, 0, 0}, data)
+	})
+
+	t.Run("Should decode the subnet and the scope set by the server", func(t *testing.T) {
+		option := &ClientSubnetOption{}
+		assert.NoError(t, option.unpack([]byte{0, 1, 24, 16, 192, 0, 2}))
+		assert.Equal(t, netip.MustParsePrefix("192.0.2.0/24"), option.Source)
+		assert.Equal(t, uint8(16), option.ScopePrefix)
+		assert.Equal(t, netip.MustParsePrefix("192.0.0.0/16"), option.Scope())
+		assert.Equal(t, "CLIENT-SUBNET: 192.0.2.0/24/16", option.String())
+
+		option.ScopePrefix = 32
+		assert.Equal(t, netip.MustParsePrefix("192.0.2.0/24"), option.Scope())
+	})
+
+	t.Run("Should reject invalid client subnets", func(t *testing.T) {
+		invalidOptions := [][]byte{
+			{0, 1, 24},
+			{0, 3, 0, 0},
+			{0, 1, 33, 0, 192, 0, 2, 1, 1},
+			{0, 1, 24, 0, 192, 0},
+			{0, 1, 23, 0, 192, 0, 3},
+		}
+		for _, data := range invalidOptions {
+			assert.Error(t, (&ClientSubnetOption{}).unpack(data), "%v", data)
+		}
+	})
+
+	t.Run("Should apply the default source prefix lengths", func(t *testing.T) {
+		assert.Equal(t, netip.MustParsePrefix("198.51.100.0/24"), DefaultClientSubnet(netip.MustParseAddr("198.51.100.42")))
+		assert.Equal(t, netip.MustParsePrefix("198.51.100.0/24"), DefaultClientSubnet(netip.MustParseAddr("::ffff:198.51.100.42")))
+		assert.Equal(t, netip.MustParsePrefix("2001:db8:aa:bb00::/56"), DefaultClientSubnet(netip.MustParseAddr("2001:db8:aa:bbcc::1")))
+	})
+
+	t.Run("Should parse client subnets with or without prefix length", func(t *testing.T) {
+		subnet, err := ParseClientSubnet("192.0.2.77")
+		assert.NoError(t, err)
+		assert.Equal(t, netip.MustParsePrefix("192.0.2.0/24"), subnet)
+
+		subnet, err = ParseClientSubnet("192.0.2.77/20")
+		assert.NoError(t, err)
+		assert.Equal(t, netip.MustParsePrefix("192.0.0.0/20"), subnet)
+
+		subnet, err = ParseClientSubnet("2001:db8:aa:bbcc::1")
+		assert.NoError(t, err)
+		assert.Equal(t, netip.MustParsePrefix("2001:db8:aa:bb00::/56"), subnet)
+
+		_, err = ParseClientSubnet("192.0.2.0/33")
+		assert.Error(t, err)
+		_, err = ParseClientSubnet("example.com")
+		assert.Error(t, err)
+	})
+
+	t.Run("Should carry the option in an OPT pseudo-record", func(t *testing.T) {
+		edns := NewEDNS(1232, NewClientSubnetOption(netip.MustParsePrefix("192.0.2.0/24")))
+		rr := ResourceRecordFromBytes(edns.ResourceRecord().ToBytes())
+		decoded, err := EDNSFromResourceRecord(rr)
+		assert.NoError(t, err)
+		assert.Equal(t, edns.Options, decoded.Options)
+	})
+}
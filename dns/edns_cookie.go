@@ -0,0 +1,72 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+)
+
+// Lengths of the cookies carried in the cookie option.
+const (
+	ClientCookieLength    = 8
+	MinServerCookieLength = 8
+	MaxServerCookieLength = 32
+)
+
+// CookieOption is the DNS cookie option, which protects clients and servers from off-path spoofing.
+// Clients send their client cookie along with the last server cookie received from the server, if any.
+// Servers reply with the client cookie and a fresh server cookie, whose content is only meaningful to them.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7873#section-4 and
+// https://datatracker.ietf.org/doc/html/rfc9018 for more information
+type CookieOption struct {
+	Client []byte // Client is the 8 bytes client cookie.
+	Server []byte // Server is the server cookie of 8 to 32 bytes, empty if the client has none yet.
+}
+
+// Code returns EDNSOptionCookie.
+func (o *CookieOption) Code() uint16 { return EDNSOptionCookie }
+
+// String returns the client cookie followed by the server cookie in hex, as printed by dig.
+func (o *CookieOption) String() string {
+	return fmt.Sprintf("COOKIE: %s%s", hex.EncodeToString(o.Client), hex.EncodeToString(o.Server))
+}
+
+// Equal reports whether the two options hold the same cookies.
+func (o *CookieOption) Equal(other *CookieOption) bool {
+	return other != nil && bytes.Equal(o.Client, other.Client) && bytes.Equal(o.Server, other.Server)
+}
+
+func (o *CookieOption) pack(b []byte) ([]byte, error) {
+	if err := validCookieLengths(len(o.Client), len(o.Server)); err != nil {
+		return nil, err
+	}
+	b = append(b, o.Client...)
+	return append(b, o.Server...), nil
+}
+
+func (o *CookieOption) unpack(data []byte) error {
+	if len(data) < ClientCookieLength {
+		return fmt.Errorf("invalid cookie length: %d", len(data))
+	}
+	if err := validCookieLengths(ClientCookieLength, len(data)-ClientCookieLength); err != nil {
+		return err
+	}
+	o.Client = bytes.Clone(data[:ClientCookieLength])
+	o.Server = nil
+	if len(data) > ClientCookieLength {
+		o.Server = bytes.Clone(data[ClientCookieLength:])
+	}
+	return nil
+}
+
+// validCookieLengths checks the lengths of the client and server cookies.
+func validCookieLengths(client, server int) error {
+	if client != ClientCookieLength {
+		return fmt.Errorf("invalid client cookie length: %d", client)
+	}
+	if server != 0 && (server < MinServerCookieLength || server > MaxServerCookieLength) {
+		return fmt.Errorf("invalid server cookie length: %d", server)
+	}
+	return nil
+}
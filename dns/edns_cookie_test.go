@@ -0,0 +1,50 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookieOption(t *testing.T) {
+	clientCookie := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	serverCookie := []byte{1, 0, 0, 0, 0x5f, 0x5e, 0x5d, 0x5c, 0xe1, 0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8}
+
+	t.Run("Should encode the client cookie followed by the server cookie", func(t *testing.T) {
+		data, err := (&CookieOption{Client: clientCookie}).pack(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, clientCookie, data)
+
+		data, err = (&CookieOption{Client: clientCookie, Server: serverCookie}).pack(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, append(append([]byte{}, clientCookie...), serverCookie...), data)
+	})
+
+	t.Run("Should decode the cookies", func(t *testing.T) {
+		option := &CookieOption{}
+		assert.NoError(t, option.unpack(append(append([]byte{}, clientCookie...), serverCookie...)))
+		assert.Equal(t, clientCookie, option.Client)
+		assert.Equal(t, serverCookie, option.Server)
+		assert.Equal(t, "COOKIE: 0102030405060708010000005f5e5d5ce1e2e3e4e5e6e7e8", option.String())
+
+		assert.NoError(t, option.unpack(clientCookie))
+		assert.Nil(t, option.Server)
+		assert.True(t, option.Equal(&CookieOption{Client: clientCookie}))
+	})
+
+	t.Run("Should reject cookies of invalid lengths", func(t *testing.T) {
+		assert.Error(t, (&CookieOption{}).unpack([]byte{1, 2, 3}))
+		assert.Error(t, (&CookieOption{}).unpack(append(append([]byte{}, clientCookie...), 1, 2, 3)))
+		assert.Error(t, (&CookieOption{}).unpack(make([]byte, ClientCookieLength+MaxServerCookieLength+1)))
+		_, err := (&CookieOption{Client: []byte{1}}).pack(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should carry the option in an OPT pseudo-record", func(t *testing.T) {
+		edns := NewEDNS(1232, &CookieOption{Client: clientCookie, Server: serverCookie})
+		rr := ResourceRecordFromBytes(edns.ResourceRecord().ToBytes())
+		decoded, err := EDNSFromResourceRecord(rr)
+		assert.NoError(t, err)
+		assert.Equal(t, edns.Options, decoded.Options)
+	})
+}
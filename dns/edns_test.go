@@ -0,0 +1,86 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEDNS(t *testing.T) {
+	t.Run("Should encode the EDNS fields in an OPT pseudo-record", func(t *testing.T) {
+		edns := NewEDNS(1232, &NSIDOption{})
+		edns.DO = true
+		rr := edns.ResourceRecord()
+		expected := []byte{0, 0, 41, 4, 208, 0, 0, 128, 0, 0, 4, 0, 3, 0, 0}
+		assert.Equal(t, expected, rr.ToBytes())
+	})
+
+	t.Run("Should fail to pack the OPT pseudo-records with invalid options", func(t *testing.T) {
+		edns := NewEDNS(1232, &ClientSubnetOption{})
+		rr := edns.ResourceRecord()
+		assert.Nil(t, rr.RData)
+		assert.Nil(t, rr.ToBytes())
+
+		_, err := NewQuery("example.com", TypeA).WithEDNS(1232).WithEDNSOption(&ClientSubnetOption{}).Pack()
+		assert.ErrorContains(t, err, "invalid client subnet")
+	})
+
+	t.Run("Should decode the EDNS fields of an OPT pseudo-record", func(t *testing.T) {
+		rr := ResourceRecordFromBytes([]byte{0, 0, 41, 16, 0, 1, 0, 128, 0, 0, 10, 0, 3, 0, 2, 110, 115, 0, 99, 0, 0})
+		edns, err := EDNSFromResourceRecord(rr)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(4096), edns.UDPSize)
+		assert.Equal(t, uint8(1), edns.ExtendedRCode)
+		assert.Equal(t, uint8(0), edns.Version)
+		assert.True(t, edns.DO)
+		assert.Equal(t, &NSIDOption{ID: []byte("ns")}, edns.Option(EDNSOptionNSID))
+		assert.Equal(t, &UnknownOption{OptionCode: 99, Data: []byte{}}, edns.Option(99))
+		assert.Nil(t, edns.Option(EDNSOptionCookie))
+	})
+
+	t.Run("Should treat UDP payload sizes lower than 512 as 512", func(t *testing.T) {
+		edns, err := EDNSFromResourceRecord(NewEDNS(100).ResourceRecord())
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(MaxUDPSize), edns.UDPSize)
+
+		_, err = EDNSFromResourceRecord(NewResourceRecord("dns.google.com", TypeA, ClassIN, 0, 4, []byte{8, 8, 8, 8}))
+		assert.Error(t, err)
+	})
+
+	t.Run("Should add and remove the OPT pseudo-record of a message", func(t *testing.T) {
+		flag := NewHeaderFlag(false, 0, false, false, true, false, 0, 0).GenerateFlag()
+		header := NewHeader(22, flag, 1, 0, 0, 0)
+		message := NewDNSMessage(*header, []Question{*NewQuestion("dns.google.com", TypeA, ClassIN)})
+		assert.Nil(t, message.EDNS())
+
+		message.SetEDNS(NewEDNS(1232))
+		message.SetEDNS(NewEDNS(4096))
+		assert.Equal(t, uint16(1), message.Header.ARCount)
+		assert.Equal(t, uint16(4096), message.EDNS().UDPSize)
+
+		parsed, err := ParseMessage(message.ToBytes())
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(4096), parsed.EDNS().UDPSize)
+
+		message.SetEDNS(nil)
+		assert.Equal(t, uint16(0), message.Header.ARCount)
+		assert.Nil(t, message.EDNS())
+	})
+
+	t.Run("Should combine the extended response code", func(t *testing.T) {
+		flag := NewHeaderFlag(true, 0, false, false, true, true, 0, 0).GenerateFlag()
+		message := NewDNSMessage(*NewHeader(22, flag, 0, 0, 0, 0), []Question{})
+		edns := NewEDNS(1232)
+		edns.ExtendedRCode = 1
+		message.SetEDNS(edns)
+		assert.Equal(t, RCodeBadVersion, message.RCode())
+	})
+
+	t.Run("Should replace an option with the same code", func(t *testing.T) {
+		edns := NewEDNS(1232, &NSIDOption{ID: []byte("a")}, &UnknownOption{OptionCode: 99})
+		edns.SetOption(&NSIDOption{ID: []byte("b")})
+		assert.Equal(t, 2, len(edns.Options))
+		assert.Equal(t, &NSIDOption{ID: []byte("b")}, edns.Option(EDNSOptionNSID))
+		assert.Equal(t, "; EDNS: version: 0, flags:; udp: 1232\n; OPT99: \n; NSID: 62 (\"b\")", edns.String())
+	})
+}
@@ -0,0 +1,125 @@
+package dns
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds returns well-formed messages used to seed the fuzz targets.
+func fuzzSeeds() [][]byte {
+	return [][]byte{
+		{0, 22, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 1, 0, 1},
+		{0, 22, 129, 128, 0, 1, 0, 2, 0, 0, 0, 0, 3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 1, 0, 1, 192, 12, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8, 4, 4, 192, 12, 0, 1, 0, 1, 0, 0, 3, 132, 0, 4, 8, 8, 8, 8},
+		{0, 22, 129, 128, 0, 1, 0, 1, 0, 0, 0, 0, 3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 15, 0, 1, 192, 12, 0, 15, 0, 1, 0, 0, 3, 132, 0, 9, 0, 10, 4, 109, 97, 105, 108, 192, 16},
+		{0, 22, 129, 128, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+}
+
+// FuzzParseMessage checks that ParseMessage never panics and that the messages it accepts can be encoded again.
+func FuzzParseMessage(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		message, err := ParseMessage(data)
+		if err != nil {
+			if message != nil {
+				t.Fatalf("ParseMessage returned a message along with the error %v", err)
+			}
+			return
+		}
+		if len(message.Questions) != int(message.Header.QDCount) || len(message.Answers) != int(message.Header.ANCount) ||
+			len(message.AuthorityRRs) != int(message.Header.NSCount) || len(message.AdditionalRRs) != int(message.Header.ARCount) {
+			t.Fatalf("section lengths do not match the header counts: %+v", message.Header)
+		}
+		message.ToBytes()
+		message.ToBytesUncompressed()
+	})
+}
+
+// FuzzParser checks that the Parser never panics, and that it reads the messages that ParseMessage accepts.
+func FuzzParser(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, parseErr := ParseMessage(data)
+		var p Parser
+		name := make([]byte, 0, 1024)
+		_, err := p.Start(data)
+		for err == nil {
+			var q RawQuestion
+			if q, err = p.Question(); err == nil {
+				_, err = q.Name.AppendDecoded(name[:0])
+			}
+		}
+		if errors.Is(err, ErrSectionDone) {
+			err = nil
+		}
+		for section := SectionAnswer; section <= SectionAdditional && err == nil; section++ {
+			for err == nil {
+				var r RawRecord
+				if r, err = p.Record(section); err == nil {
+					_, err = r.Record()
+				}
+			}
+			if errors.Is(err, ErrSectionDone) {
+				err = nil
+			}
+		}
+		if parseErr == nil && err != nil {
+			t.Fatalf("the Parser failed on a message accepted by ParseMessage: %v", err)
+		}
+	})
+}
+
+// FuzzDecodeNameAt checks that DecodeNameAt never panics and always makes progress on success.
+func FuzzDecodeNameAt(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed, 12)
+	}
+	f.Fuzz(func(t *testing.T, msg []byte, offset int) {
+		_, next, err := DecodeNameAt(msg, offset)
+		if err == nil && (next <= offset || next > len(msg)) {
+			t.Fatalf("DecodeNameAt returned the offset %d for a name starting at %d", next, offset)
+		}
+	})
+}
+
+// FuzzTrimResourceRecordBytes checks that the buffer based helpers never panic on truncated input.
+func FuzzTrimResourceRecordBytes(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed[12:])
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		TrimResourceRecordBytes(bytes.NewBuffer(data))
+		appendFromBufferUntilNull(bytes.NewBuffer(data))
+		QuestionFromBytes(data)
+		ResourceRecordFromBytes(data)
+		DNSMessageFromBytes(data)
+	})
+}
+
+// FuzzParseZone checks that ParseZone never panics and that the records it accepts can be written and parsed again.
+func FuzzParseZone(f *testing.F) {
+	f.Add(testZone)
+	f.Add("$GENERATE 1-3 host-${1,2,x} A 192.0.2.$\n")
+	f.Add("$TTL 300\n@ HTTPS 1 . alpn=\"f\\\\\\\\oo\\\\,bar,h2\" mandatory=alpn ech=AAEC key667=\"\\210\"\n")
+	f.Add("$TTL 300\n@ NSEC3 1 1 12 aabbccdd 2t7b4g4vsa5smi47k61mv5bv1a22bojr MX DNSKEY TYPE1234\n@ DS 60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118\n")
+	f.Add("$TTL 300\n@ LOC 42 21 43.952 N 71 5 6.344 W -24m 1m 200m\n@ NAPTR 100 10 \"S\" \"SIP+D2U\" \"\" _sip._udp\n@ HINFO \"RFC8482\" \"\"\n")
+	f.Fuzz(func(t *testing.T, zone string) {
+		records, err := ParseZone(strings.NewReader(zone), "example.com", "")
+		if err != nil {
+			return
+		}
+		var b bytes.Buffer
+		if err := WriteZone(&b, records); err != nil {
+			t.Fatalf("WriteZone failed: %v", err)
+		}
+		if _, err := ParseZone(&b, "", ""); err != nil {
+			t.Fatalf("the written zone can not be parsed again: %v\n%s", err, b.String())
+		}
+	})
+}
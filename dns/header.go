@@ -1,8 +1,8 @@
 package dns
 
 import (
-	"bytes"
 	"encoding/binary"
+	"fmt"
 )
 
 // Header represents the DNS header section.
@@ -32,36 +32,49 @@ func NewHeader(id, flags, qdCount, anCount, nsCount, arCount uint16) *Header {
 
 // ToBytes converts the Header to its byte representation.
 func (h *Header) ToBytes() []byte {
-	buf := new(bytes.Buffer)
-
-	binary.Write(buf, binary.BigEndian, h.ID)
-	binary.Write(buf, binary.BigEndian, h.Flags)
-	binary.Write(buf, binary.BigEndian, h.QDCount)
-	binary.Write(buf, binary.BigEndian, h.ANCount)
-	binary.Write(buf, binary.BigEndian, h.NSCount)
-	binary.Write(buf, binary.BigEndian, h.ARCount)
+	return h.pack(make([]byte, 0, headerLength))
+}
 
-	return buf.Bytes()
+// pack appends the Header to msg.
+func (h *Header) pack(msg []byte) []byte {
+	for _, field := range []uint16{h.ID, h.Flags, h.QDCount, h.ANCount, h.NSCount, h.ARCount} {
+		msg = binary.BigEndian.AppendUint16(msg, field)
+	}
+	return msg
 }
 
 // HeaderFromBytes creates a Header instance from its byte representation.
+// The fields missing from a short byte slice are zero.
 func HeaderFromBytes(b []byte) *Header {
-	buf := bytes.NewReader(b)
+	var fields [6]uint16
+	for i := range fields {
+		if len(b) >= 2*i+2 {
+			fields[i] = binary.BigEndian.Uint16(b[2*i:])
+		}
+	}
+	return &Header{
+		ID:      fields[0],
+		Flags:   fields[1],
+		QDCount: fields[2],
+		ANCount: fields[3],
+		NSCount: fields[4],
+		ARCount: fields[5],
+	}
+}
 
-	var id, flags, qdCount, anCount, nsCount, arCount uint16
-	binary.Read(buf, binary.BigEndian, &id)
-	binary.Read(buf, binary.BigEndian, &flags)
-	binary.Read(buf, binary.BigEndian, &qdCount)
-	binary.Read(buf, binary.BigEndian, &anCount)
-	binary.Read(buf, binary.BigEndian, &nsCount)
-	binary.Read(buf, binary.BigEndian, &arCount)
+// String returns the header as printed by dig: the opcode, status and ID line followed by the flags and counts line.
+func (h *Header) String() string {
+	return h.format(h.Flags & 0b1111)
+}
 
-	return &Header{
-		ID:      id,
-		Flags:   flags,
-		QDCount: qdCount,
-		ANCount: anCount,
-		NSCount: nsCount,
-		ARCount: arCount,
+// format returns the header as printed by dig, with the given response code which may be an extended one.
+// The counts of the UPDATE messages are named after their zone, prerequisite and update sections.
+func (h *Header) format(rcode uint16) string {
+	flags := HeaderFlagFromUint16(h.Flags)
+	counts := "QUERY: %d, ANSWER: %d, AUTHORITY: %d, ADDITIONAL: %d"
+	if flags.Opcode == OpcodeUpdate {
+		counts = "ZONE: %d, PREREQ: %d, UPDATE: %d, ADDITIONAL: %d"
 	}
+	return fmt.Sprintf(";; ->>HEADER<<- opcode: %s, status: %s, id: %d\n;; flags: %s; "+counts,
+		OpcodeToString(flags.Opcode), RCodeToString(rcode), h.ID, flags, h.QDCount, h.ANCount, h.NSCount, h.ARCount)
 }
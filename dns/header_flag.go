@@ -3,6 +3,8 @@ package dns
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"strings"
 )
 
 // HeaderFlag represents the individual flags in the DNS header.
@@ -13,11 +15,22 @@ type HeaderFlag struct {
 	TC     bool  // TC indicates whether the message was truncated.
 	RD     bool  // RD indicates whether recursion is desired.
 	RA     bool  // RA indicates whether recursion is available in the name server.
-	Z      uint8 // Z is reserved for future use.
+	Z      uint8 // Z is reserved for future use. It is the single bit left between RA and AD.
+	AD     bool  // AD indicates whether the data of the response was authenticated with DNSSEC by the server.
+	CD     bool  // CD indicates whether the server should skip the DNSSEC validation of the data.
 	RCode  uint8 // RCode specifies the response code.
 }
 
+// DNSSEC bits of the 3 bits between RA and RCode, which RFC 1035 reserved as Z.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-3.2 for more information
+const (
+	headerBitAD uint8 = 0b010
+	headerBitCD uint8 = 0b001
+)
+
 // NewHeaderFlag creates a new HeaderFlag instance with the given values.
+// z holds the 3 bits between RA and RCode: Z, followed by the AD and CD bits.
 func NewHeaderFlag(qr bool, opcode uint8, aa bool, tc bool, rd bool, ra bool, z uint8, rcode uint8) *HeaderFlag {
 	return &HeaderFlag{
 		QR:     qr,
@@ -26,7 +39,9 @@ func NewHeaderFlag(qr bool, opcode uint8, aa bool, tc bool, rd bool, ra bool, z
 		TC:     tc,
 		RD:     rd,
 		RA:     ra,
-		Z:      z,
+		Z:      z >> 2 & 1,
+		AD:     z&headerBitAD != 0,
+		CD:     z&headerBitCD != 0,
 		RCode:  rcode,
 	}
 }
@@ -39,22 +54,26 @@ func (hf *HeaderFlag) GenerateFlag() uint16 {
 	tc := uint16(boolToInt(hf.TC))
 	rd := uint16(boolToInt(hf.RD))
 	ra := uint16(boolToInt(hf.RA))
-	z := uint16(hf.Z)
+	z := uint16(hf.Z & 1)
+	ad := uint16(boolToInt(hf.AD))
+	cd := uint16(boolToInt(hf.CD))
 	rcode := uint16(hf.RCode)
-	return uint16(qr<<15 | opcode<<11 | aa<<10 | tc<<9 | rd<<8 | ra<<7 | z<<4 | rcode)
+	return uint16(qr<<15 | opcode<<11 | aa<<10 | tc<<9 | rd<<8 | ra<<7 | z<<6 | ad<<5 | cd<<4 | rcode)
 
 }
 
 // HeaderFlagFromUint16 creates a HeaderFlag instance from the 16-bit flag value.
 func HeaderFlagFromUint16(flag uint16) *HeaderFlag {
 	return &HeaderFlag{
-		QR:     flag>>15 == 1,
+		QR:     flag>>15&1 == 1,
 		Opcode: uint8((flag >> 11) & 0b1111),
-		AA:     flag>>10 == 1,
-		TC:     flag>>9 == 1,
-		RD:     flag>>8 == 1,
-		RA:     flag>>7 == 1,
-		Z:      uint8((flag >> 4) & 0b111),
+		AA:     flag>>10&1 == 1,
+		TC:     flag>>9&1 == 1,
+		RD:     flag>>8&1 == 1,
+		RA:     flag>>7&1 == 1,
+		Z:      uint8(flag >> 6 & 1),
+		AD:     flag>>5&1 == 1,
+		CD:     flag>>4&1 == 1,
 		RCode:  uint8(flag & 0b1111),
 	}
 }
@@ -103,3 +122,78 @@ func (hf *HeaderFlag) IsQuery() bool {
 func (hf *HeaderFlag) IsResponse() bool {
 	return hf.QR
 }
+
+// String returns the list of the flags that are set, as printed by dig (e.g. "qr rd ra").
+func (hf *HeaderFlag) String() string {
+	flags := make([]string, 0, 7)
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{hf.QR, "qr"},
+		{hf.AA, "aa"},
+		{hf.TC, "tc"},
+		{hf.RD, "rd"},
+		{hf.RA, "ra"},
+		{hf.AD, "ad"},
+		{hf.CD, "cd"},
+	} {
+		if flag.set {
+			flags = append(flags, flag.name)
+		}
+	}
+	return strings.Join(flags, " ")
+}
+
+// OpcodeToString returns the mnemonic of the opcode, or OPCODE followed by its value if it has none.
+func OpcodeToString(opcode uint8) string {
+	switch opcode {
+	case OpcodeQuery:
+		return "QUERY"
+	case OpcodeIQuery:
+		return "IQUERY"
+	case OpcodeStatus:
+		return "STATUS"
+	case OpcodeNotify:
+		return "NOTIFY"
+	case OpcodeUpdate:
+		return "UPDATE"
+	default:
+		return fmt.Sprintf("OPCODE%d", opcode)
+	}
+}
+
+// RCodeToString returns the mnemonic of the response code, or RCODE followed by its value if it has none.
+// The code may be an extended response code, see DNSMessage.RCode.
+func RCodeToString(rcode uint16) string {
+	switch rcode {
+	case uint16(RCodeNoError):
+		return "NOERROR"
+	case uint16(RCodeFormatError):
+		return "FORMERR"
+	case uint16(RCodeServerFailure):
+		return "SERVFAIL"
+	case uint16(RCodeNameError):
+		return "NXDOMAIN"
+	case uint16(RCodeNotImplemented):
+		return "NOTIMP"
+	case uint16(RCodeRefused):
+		return "REFUSED"
+	case uint16(RCodeYXDomain):
+		return "YXDOMAIN"
+	case uint16(RCodeYXRRSet):
+		return "YXRRSET"
+	case uint16(RCodeNXRRSet):
+		return "NXRRSET"
+	case uint16(RCodeNotAuth):
+		return "NOTAUTH"
+	case uint16(RCodeNotZone):
+		return "NOTZONE"
+	case RCodeBadVersion:
+		return "BADVERS"
+	case RCodeBadCookie:
+		return "BADCOOKIE"
+	default:
+		return fmt.Sprintf("RCODE%d", rcode)
+	}
+}
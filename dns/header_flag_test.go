@@ -54,4 +54,40 @@ func TestHeaderFlag(t *testing.T) {
 		flag = NewHeaderFlag(true, 0, false, false, true, false, 0, 1)
 		assert.True(t, flag.IsResponse())
 	})
+
+	t.Run("Should decode every flag of a response", func(t *testing.T) {
+		expected := NewHeaderFlag(true, OpcodeUpdate, true, true, true, true, 0b011, RCodeRefused)
+		assert.Equal(t, expected, HeaderFlagFromUint16(expected.GenerateFlag()))
+		assert.Equal(t, NewHeaderFlag(true, 0, false, false, true, true, 0, 0), HeaderFlagFromUint16(0x8180))
+	})
+
+	t.Run("Should set and read the DNSSEC bits apart from Z", func(t *testing.T) {
+		flag := NewHeaderFlag(false, 0, false, false, true, false, 0, 0)
+		flag.AD = true
+		assert.Equal(t, uint16(0x0120), flag.GenerateFlag())
+		flag.AD, flag.CD = false, true
+		assert.Equal(t, uint16(0x0110), flag.GenerateFlag())
+
+		decoded := HeaderFlagFromUint16(0x81F0)
+		assert.True(t, decoded.AD)
+		assert.True(t, decoded.CD)
+		assert.Equal(t, uint8(1), decoded.Z)
+		assert.Equal(t, uint16(0x81F0), decoded.GenerateFlag())
+		assert.Equal(t, HeaderFlagFromUint16(0x81A0), NewHeaderFlag(true, 0, false, false, true, true, 0b010, 0))
+	})
+
+	t.Run("Should list the flags that are set as dig does", func(t *testing.T) {
+		assert.Equal(t, "qr rd ra", HeaderFlagFromUint16(0x8180).String())
+		assert.Equal(t, "qr aa tc ad cd", NewHeaderFlag(true, 0, true, true, false, false, 0b011, 0).String())
+		assert.Equal(t, "", NewHeaderFlag(false, 0, false, false, false, false, 0, 0).String())
+	})
+
+	t.Run("Should name the opcodes and the response codes", func(t *testing.T) {
+		assert.Equal(t, "QUERY", OpcodeToString(OpcodeQuery))
+		assert.Equal(t, "UPDATE", OpcodeToString(OpcodeUpdate))
+		assert.Equal(t, "OPCODE3", OpcodeToString(3))
+		assert.Equal(t, "SERVFAIL", RCodeToString(uint16(RCodeServerFailure)))
+		assert.Equal(t, "BADCOOKIE", RCodeToString(RCodeBadCookie))
+		assert.Equal(t, "RCODE11", RCodeToString(11))
+	})
 }
@@ -20,4 +20,12 @@ func TestHeader(t *testing.T) {
 		header := NewHeader(22, recursionFlag, 1, 0, 0, 0)
 		assert.Equal(t, header, HeaderFromBytes(headerBytes))
 	})
+
+	t.Run("Should print a header as dig does", func(t *testing.T) {
+		flag := NewHeaderFlag(true, 0, false, false, true, true, 0, RCodeNameError).GenerateFlag()
+		header := NewHeader(4242, flag, 1, 0, 1, 1)
+		expected := ";; ->>HEADER<<- opcode: QUERY, status: NXDOMAIN, id: 4242\n" +
+			";; flags: qr rd ra; QUERY: 1, ANSWER: 0, AUTHORITY: 1, ADDITIONAL: 1"
+		assert.Equal(t, expected, header.String())
+	})
 }
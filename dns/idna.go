@@ -0,0 +1,91 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidDomainName is returned when a domain name can not be converted to the A-labels sent on the wire.
+var ErrInvalidDomainName = errors.New("invalid domain name")
+
+// aceLabelPrefix is the prefix of the A-labels, the ASCII form of the labels holding other characters.
+const aceLabelPrefix = "xn--"
+
+// idnaLookup converts the labels of the names being looked up as UTS #46 does with nontransitional processing,
+// which maps them to lowercase and their normalization form C before encoding them in punycode.
+// Underscores and other ASCII characters are allowed, as DNS names such as _443._tcp.example.com hold them.
+var idnaLookup = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.Transitional(false), idna.StrictDomainName(false))
+
+// idnaDisplay converts the A-labels of the names to display back to their Unicode form.
+var idnaDisplay = idna.New(idna.BidiRule(), idna.Transitional(false))
+
+// ToASCII returns the domain name with its internationalized labels, the labels holding non-ASCII characters,
+// converted to A-labels such as xn--bcher-kva for bücher, and checks the A-labels the name already holds.
+// The other labels are kept as is. It fails with ErrInvalidDomainName if a label is not allowed by IDNA2008,
+// is empty or longer than 63 octets, and with ErrNameTooLong if the name is longer than 255 octets.
+//
+// See https://datatracker.ietf.org/doc/html/rfc5891#section-5 and https://www.unicode.org/reports/tr46 for more information
+func ToASCII(name string) (string, error) {
+	labels := SplitLabels(name)
+	if len(labels) == 0 {
+		return name, nil
+	}
+	for i, label := range labels {
+		if !isASCII(label) || hasACEPrefix(label) {
+			ascii, err := idnaLookup.ToASCII(label)
+			if err != nil {
+				return "", fmt.Errorf("%w: %s: %v", ErrInvalidDomainName, name, err)
+			}
+			labels[i] = ascii
+		}
+	}
+	ascii := strings.Join(labels, ".")
+	if _, err := decodeLabels(ascii); err != nil {
+		return "", err
+	}
+	if isFQDN(name) {
+		ascii += "."
+	}
+	return ascii, nil
+}
+
+// ToUnicode returns the domain name with its A-labels converted to U-labels, their Unicode form, to display it.
+// The A-labels that are not valid are kept as is.
+func ToUnicode(name string) string {
+	if !strings.Contains(strings.ToLower(name), aceLabelPrefix) {
+		return name
+	}
+	labels := SplitLabels(name)
+	for i, label := range labels {
+		if !hasACEPrefix(label) {
+			continue
+		}
+		if unicode, err := idnaDisplay.ToUnicode(strings.ToLower(label)); err == nil {
+			labels[i] = unicode
+		}
+	}
+	unicode := strings.Join(labels, ".")
+	if isFQDN(name) {
+		unicode += "."
+	}
+	return unicode
+}
+
+// isASCII reports whether the string only holds ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// hasACEPrefix reports whether the label starts with the prefix of the A-labels, ignoring its case.
+func hasACEPrefix(label string) bool {
+	return len(label) >= len(aceLabelPrefix) && strings.EqualFold(label[:len(aceLabelPrefix)], aceLabelPrefix)
+}
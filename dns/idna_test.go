@@ -0,0 +1,75 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDNA(t *testing.T) {
+	t.Run("Should convert the internationalized labels to A-labels", func(t *testing.T) {
+		for name, expected := range map[string]string{
+			"bücher.example":            "xn--bcher-kva.example",
+			"Bücher.Example.":           "xn--bcher-kva.Example.",
+			"faß.de":                    "xn--fa-hia.de",
+			"ＢÜＣＨＥＲ.example":            "xn--bcher-kva.example",
+			"www.münchen.de":            "www.xn--mnchen-3ya.de",
+			"XN--BCHER-KVA.example":     "xn--bcher-kva.example",
+			"_443._tcp.www.example.com": "_443._tcp.www.example.com",
+			"*.example.com":             "*.example.com",
+			"":                          "",
+			".":                         ".",
+		} {
+			ascii, err := ToASCII(name)
+			assert.NoError(t, err, name)
+			assert.Equal(t, expected, ascii, name)
+		}
+	})
+
+	t.Run("Should reject the names that are not valid", func(t *testing.T) {
+		for _, name := range []string{
+			"www..example",
+			strings.Repeat("a", 64) + ".example",
+			"xn--bcher-kv.example",
+			"١٢٣a.example", // a right-to-left label mixed with a left-to-right character
+			"bü\u0080cher.example",
+			"-bücher.example",
+		} {
+			_, err := ToASCII(name)
+			assert.ErrorIs(t, err, ErrInvalidDomainName, name)
+		}
+		_, err := ToASCII(strings.Repeat("bücher.", 40) + "example")
+		assert.ErrorIs(t, err, ErrNameTooLong)
+	})
+
+	t.Run("Should convert the A-labels to U-labels to display them", func(t *testing.T) {
+		assert.Equal(t, "bücher.example.", ToUnicode("xn--bcher-kva.example."))
+		assert.Equal(t, "www.münchen.de", ToUnicode("www.XN--MNCHEN-3YA.de"))
+		assert.Equal(t, "xn--bcher-kv.example", ToUnicode("xn--bcher-kv.example"), "invalid A-labels are kept")
+		assert.Equal(t, "www.example.com", ToUnicode("www.example.com"))
+	})
+
+	t.Run("Should send the A-labels of the names of the questions", func(t *testing.T) {
+		question := NewQuestion("bücher.example", TypeA, ClassIN)
+		assert.Equal(t, "xn--bcher-kva.example", question.Name)
+		assert.Equal(t, "\x0dxn--bcher-kva\x07example\x00", question.QName)
+
+		question.SetName("Straße.de")
+		assert.Equal(t, "xn--strae-oqa.de", question.Name)
+	})
+
+	t.Run("Should print the U-labels along with the A-labels", func(t *testing.T) {
+		cname, err := NewResourceRecordWithData("www.xn--bcher-kva.example", ClassIN, 300, &CNAME{Target: "xn--mnchen-3ya.de"})
+		assert.NoError(t, err)
+		a := NewResourceRecord("www.example.com", TypeA, ClassIN, 300, 4, []byte{192, 0, 2, 1})
+		header := NewHeader(1, 0x8180, 1, 2, 0, 0)
+		message := NewDNSMessage(*header, []Question{*NewQuestion("www.bücher.example", TypeA, ClassIN)}, []ResourceRecord{*cname, *a})
+
+		output := message.UnicodeString()
+		assert.Contains(t, output, ";www.xn--bcher-kva.example.\t\tIN\tA\t; www.bücher.example.\n")
+		assert.Contains(t, output, "www.xn--bcher-kva.example.\t300\tIN\tCNAME\txn--mnchen-3ya.de.\t; www.bücher.example. münchen.de.\n")
+		assert.Contains(t, output, "www.example.com.\t300\tIN\tA\t192.0.2.1\n")
+		assert.NotContains(t, message.String(), "bücher")
+	})
+}
@@ -0,0 +1,383 @@
+package dns
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The JSON representation of messages follows RFC 8427. Flags are written as 0 or 1 as in the examples
+// of the RFC, and both numbers and booleans are accepted when reading them.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8427 for more information
+
+// jsonFlag is a header flag, written as 0 or 1.
+type jsonFlag bool
+
+// MarshalJSON writes the flag as 0 or 1.
+func (f jsonFlag) MarshalJSON() ([]byte, error) {
+	if f {
+		return []byte("1"), nil
+	}
+	return []byte("0"), nil
+}
+
+// UnmarshalJSON reads the flag from 0, 1, true or false.
+func (f *jsonFlag) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "1", "true":
+		*f = true
+	case "0", "false", "null":
+		*f = false
+	default:
+		return fmt.Errorf("invalid flag: %s", data)
+	}
+	return nil
+}
+
+// headerJSON holds the members of a message object describing the header.
+type headerJSON struct {
+	ID      uint16   `json:"ID"`
+	QR      jsonFlag `json:"QR"`
+	Opcode  uint8    `json:"Opcode"`
+	AA      jsonFlag `json:"AA"`
+	TC      jsonFlag `json:"TC"`
+	RD      jsonFlag `json:"RD"`
+	RA      jsonFlag `json:"RA"`
+	AD      jsonFlag `json:"AD"`
+	CD      jsonFlag `json:"CD"`
+	RCODE   uint8    `json:"RCODE"`
+	QDCOUNT *uint16  `json:"QDCOUNT,omitempty"`
+	ANCOUNT *uint16  `json:"ANCOUNT,omitempty"`
+	NSCOUNT *uint16  `json:"NSCOUNT,omitempty"`
+	ARCOUNT *uint16  `json:"ARCOUNT,omitempty"`
+}
+
+// newHeaderJSON returns the members describing the header.
+func newHeaderJSON(h *Header) headerJSON {
+	flags := HeaderFlagFromUint16(h.Flags)
+	return headerJSON{
+		ID:      h.ID,
+		QR:      jsonFlag(flags.QR),
+		Opcode:  flags.Opcode,
+		AA:      jsonFlag(flags.AA),
+		TC:      jsonFlag(flags.TC),
+		RD:      jsonFlag(flags.RD),
+		RA:      jsonFlag(flags.RA),
+		AD:      jsonFlag(flags.AD),
+		CD:      jsonFlag(flags.CD),
+		RCODE:   flags.RCode,
+		QDCOUNT: &h.QDCount,
+		ANCOUNT: &h.ANCount,
+		NSCOUNT: &h.NSCount,
+		ARCOUNT: &h.ARCount,
+	}
+}
+
+// header returns the header described by the members. The counts that are missing are zero.
+func (j *headerJSON) header() (Header, error) {
+	if j.Opcode > 0b1111 || j.RCODE > 0b1111 {
+		return Header{}, fmt.Errorf("invalid opcode %d or response code %d", j.Opcode, j.RCODE)
+	}
+	flags := NewHeaderFlag(bool(j.QR), j.Opcode, bool(j.AA), bool(j.TC), bool(j.RD), bool(j.RA), 0, j.RCODE)
+	flags.AD, flags.CD = bool(j.AD), bool(j.CD)
+	count := func(c *uint16) uint16 {
+		if c == nil {
+			return 0
+		}
+		return *c
+	}
+	return *NewHeader(j.ID, flags.GenerateFlag(), count(j.QDCOUNT), count(j.ANCOUNT), count(j.NSCOUNT), count(j.ARCOUNT)), nil
+}
+
+// MarshalJSON returns the RFC 8427 members describing the header.
+func (h Header) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newHeaderJSON(&h))
+}
+
+// UnmarshalJSON reads the header from its RFC 8427 members.
+func (h *Header) UnmarshalJSON(data []byte) error {
+	var j headerJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	header, err := j.header()
+	if err != nil {
+		return err
+	}
+	*h = header
+	return nil
+}
+
+// questionJSON holds the members of an RFC 8427 resource record object describing a question.
+type questionJSON struct {
+	NAME      string  `json:"NAME"`
+	TYPE      *uint16 `json:"TYPE,omitempty"`
+	TYPEname  string  `json:"TYPEname,omitempty"`
+	CLASS     *uint16 `json:"CLASS,omitempty"`
+	CLASSname string  `json:"CLASSname,omitempty"`
+}
+
+// MarshalJSON returns the RFC 8427 members describing the question.
+func (q Question) MarshalJSON() ([]byte, error) {
+	return json.Marshal(questionJSON{
+		NAME:      jsonName(q.Name),
+		TYPE:      &q.QType,
+		TYPEname:  RTypeToString(q.QType),
+		CLASS:     &q.QClass,
+		CLASSname: ClassToString(q.QClass),
+	})
+}
+
+// UnmarshalJSON reads the question from its RFC 8427 members.
+func (q *Question) UnmarshalJSON(data []byte) error {
+	var j questionJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	qType, qClass, err := jsonTypeAndClass(j.TYPE, j.TYPEname, j.CLASS, j.CLASSname)
+	if err != nil {
+		return err
+	}
+	*q = *NewQuestion(trimFQDN(j.NAME), qType, qClass)
+	return nil
+}
+
+// resourceRecordJSON holds the members of an RFC 8427 resource record object.
+// The resource data is written in hex, and in presentation format for the types that have an rdata member.
+type resourceRecordJSON struct {
+	NAME       string  `json:"NAME"`
+	TYPE       *uint16 `json:"TYPE,omitempty"`
+	TYPEname   string  `json:"TYPEname,omitempty"`
+	CLASS      *uint16 `json:"CLASS,omitempty"`
+	CLASSname  string  `json:"CLASSname,omitempty"`
+	TTL        uint32  `json:"TTL"`
+	RDLENGTH   *uint16 `json:"RDLENGTH,omitempty"`
+	RDATAHEX   *string `json:"RDATAHEX,omitempty"`
+	RDataA     string  `json:"rdataA,omitempty"`
+	RDataAAAA  string  `json:"rdataAAAA,omitempty"`
+	RDataCNAME string  `json:"rdataCNAME,omitempty"`
+	RDataNS    string  `json:"rdataNS,omitempty"`
+	RDataPTR   string  `json:"rdataPTR,omitempty"`
+	RDataTXT   string  `json:"rdataTXT,omitempty"`
+}
+
+// rdataMember returns the rdata member of the given type, or nil if the type has none.
+func (j *resourceRecordJSON) rdataMember(rType uint16) *string {
+	switch rType {
+	case TypeA:
+		return &j.RDataA
+	case TypeAAAA:
+		return &j.RDataAAAA
+	case TypeCNAME:
+		return &j.RDataCNAME
+	case TypeNS:
+		return &j.RDataNS
+	case TypePTR:
+		return &j.RDataPTR
+	case TypeTXT:
+		return &j.RDataTXT
+	default:
+		return nil
+	}
+}
+
+// MarshalJSON returns the RFC 8427 members describing the resource record.
+func (rr ResourceRecord) MarshalJSON() ([]byte, error) {
+	rData := rr.RData
+	if rData == nil && rr.Data != nil {
+		var err error
+		if rData, err = PackRData(rr.Data); err != nil {
+			return nil, err
+		}
+	}
+	rdLength := uint16(len(rData))
+	rDataHex := strings.ToUpper(hex.EncodeToString(rData))
+	j := resourceRecordJSON{
+		NAME:      jsonName(rr.Name),
+		TYPE:      &rr.Type,
+		TYPEname:  RTypeToString(rr.Type),
+		CLASS:     &rr.Class,
+		CLASSname: ClassToString(rr.Class),
+		TTL:       rr.TTL,
+		RDLENGTH:  &rdLength,
+		RDATAHEX:  &rDataHex,
+	}
+	if member := j.rdataMember(rr.Type); member != nil && rr.Data != nil {
+		*member = rr.Data.String()
+	}
+	if rr.Type == TypeOPT {
+		j.CLASSname = ""
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON reads the resource record from its RFC 8427 members.
+// The resource data is read from RDATAHEX, or from the rdata member of the type if there is none.
+func (rr *ResourceRecord) UnmarshalJSON(data []byte) error {
+	var j resourceRecordJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	rType, class, err := jsonTypeAndClass(j.TYPE, j.TYPEname, j.CLASS, j.CLASSname)
+	if err != nil {
+		return err
+	}
+	name := trimFQDN(j.NAME)
+
+	if j.RDATAHEX != nil {
+		rData, err := hex.DecodeString(*j.RDATAHEX)
+		if err != nil {
+			return fmt.Errorf("invalid RDATAHEX: %w", err)
+		}
+		if j.RDLENGTH != nil && int(*j.RDLENGTH) != len(rData) {
+			return fmt.Errorf("RDLENGTH %d does not match the length of RDATAHEX: %d", *j.RDLENGTH, len(rData))
+		}
+		*rr = *NewResourceRecord(name, rType, class, j.TTL, uint16(len(rData)), rData)
+		return nil
+	}
+
+	member := j.rdataMember(rType)
+	if member == nil || *member == "" {
+		return fmt.Errorf("missing resource data of the %s record %s", RTypeToString(rType), j.NAME)
+	}
+	entries, err := lexZone([]byte(*member), "")
+	if err != nil || len(entries) != 1 {
+		return fmt.Errorf("invalid resource data of the %s record %s: %s", RTypeToString(rType), j.NAME, *member)
+	}
+	rData, err := parseRData(rType, entries[0].tokens, "")
+	if err != nil {
+		return fmt.Errorf("invalid resource data of the %s record %s: %w", RTypeToString(rType), j.NAME, err)
+	}
+	record, err := NewResourceRecordWithData(name, class, j.TTL, rData)
+	if err != nil {
+		return err
+	}
+	*rr = *record
+	return nil
+}
+
+// messageJSON holds the members of an RFC 8427 message object.
+// A single question is described by the QNAME, QTYPE and QCLASS members, and several by questionRRs.
+type messageJSON struct {
+	headerJSON
+	QNAME         *string          `json:"QNAME,omitempty"`
+	QTYPE         *uint16          `json:"QTYPE,omitempty"`
+	QTYPEname     string           `json:"QTYPEname,omitempty"`
+	QCLASS        *uint16          `json:"QCLASS,omitempty"`
+	QCLASSname    string           `json:"QCLASSname,omitempty"`
+	QuestionRRs   []Question       `json:"questionRRs,omitempty"`
+	AnswerRRs     []ResourceRecord `json:"answerRRs,omitempty"`
+	AuthorityRRs  []ResourceRecord `json:"authorityRRs,omitempty"`
+	AdditionalRRs []ResourceRecord `json:"additionalRRs,omitempty"`
+}
+
+// MarshalJSON returns the RFC 8427 message object describing the message.
+func (m DNSMessage) MarshalJSON() ([]byte, error) {
+	j := messageJSON{
+		headerJSON:    newHeaderJSON(&m.Header),
+		AnswerRRs:     m.Answers,
+		AuthorityRRs:  m.AuthorityRRs,
+		AdditionalRRs: m.AdditionalRRs,
+	}
+	if len(m.Questions) == 1 {
+		q := m.Questions[0]
+		name := jsonName(q.Name)
+		j.QNAME = &name
+		j.QTYPE = &q.QType
+		j.QTYPEname = RTypeToString(q.QType)
+		j.QCLASS = &q.QClass
+		j.QCLASSname = ClassToString(q.QClass)
+	} else {
+		j.QuestionRRs = m.Questions
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON reads the message from its RFC 8427 message object.
+// The counts of the header that are missing are the number of entries of their section.
+func (m *DNSMessage) UnmarshalJSON(data []byte) error {
+	var j messageJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	header, err := j.header()
+	if err != nil {
+		return err
+	}
+
+	questions := j.QuestionRRs
+	if j.QNAME != nil {
+		qType, qClass, err := jsonTypeAndClass(j.QTYPE, j.QTYPEname, j.QCLASS, j.QCLASSname)
+		if err != nil {
+			return err
+		}
+		questions = append([]Question{*NewQuestion(trimFQDN(*j.QNAME), qType, qClass)}, questions...)
+	}
+	if questions == nil {
+		questions = make([]Question, 0)
+	}
+
+	message := NewDNSMessage(header, questions, j.AnswerRRs, j.AuthorityRRs, j.AdditionalRRs)
+	for _, count := range []struct {
+		value  *uint16
+		header *uint16
+		length int
+	}{
+		{j.QDCOUNT, &message.Header.QDCount, len(message.Questions)},
+		{j.ANCOUNT, &message.Header.ANCount, len(message.Answers)},
+		{j.NSCOUNT, &message.Header.NSCount, len(message.AuthorityRRs)},
+		{j.ARCOUNT, &message.Header.ARCount, len(message.AdditionalRRs)},
+	} {
+		if count.value == nil {
+			*count.header = uint16(count.length)
+		}
+	}
+	for _, section := range []*[]ResourceRecord{&message.Answers, &message.AuthorityRRs, &message.AdditionalRRs} {
+		if *section == nil {
+			*section = make([]ResourceRecord, 0)
+		}
+	}
+	*m = *message
+	return nil
+}
+
+// jsonName returns the domain name as written in JSON: without the trailing dot, except for the root domain.
+func jsonName(name string) string {
+	if name == "" || name == "." {
+		return "."
+	}
+	return trimFQDN(name)
+}
+
+// jsonTypeAndClass returns the type and the class given by their values or, when missing, by their names.
+// The class defaults to IN.
+func jsonTypeAndClass(rType *uint16, typeName string, class *uint16, className string) (uint16, uint16, error) {
+	var t, c uint16
+	switch {
+	case rType != nil:
+		t = *rType
+	case typeName != "":
+		value, ok := typeFromString(typeName)
+		if !ok {
+			return 0, 0, fmt.Errorf("unknown type: %s", typeName)
+		}
+		t = value
+	default:
+		return 0, 0, fmt.Errorf("missing TYPE")
+	}
+	switch {
+	case class != nil:
+		c = *class
+	case className != "":
+		value, ok := classFromString(className)
+		if !ok {
+			return 0, 0, fmt.Errorf("unknown class: %s", className)
+		}
+		c = value
+	default:
+		c = ClassIN
+	}
+	return t, c, nil
+}
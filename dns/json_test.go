@@ -0,0 +1,83 @@
+package dns
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	t.Run("Should write a dns message as an RFC 8427 message object", func(t *testing.T) {
+		flag := NewHeaderFlag(true, 0, true, false, true, false, 0, 0).GenerateFlag()
+		question := NewQuestion("example.com", TypeA, ClassIN)
+		answers := []ResourceRecord{*NewResourceRecord("example.com", TypeA, ClassIN, 3600, 4, []byte{192, 0, 2, 1})}
+		message := NewDNSMessage(*NewHeader(32784, flag, 1, 1, 0, 0), []Question{*question}, answers)
+
+		data, err := json.Marshal(message)
+		assert.NoError(t, err)
+		expected := `{"ID":32784,"QR":1,"Opcode":0,"AA":1,"TC":0,"RD":1,"RA":0,"AD":0,"CD":0,"RCODE":0,` +
+			`"QDCOUNT":1,"ANCOUNT":1,"NSCOUNT":0,"ARCOUNT":0,` +
+			`"QNAME":"example.com","QTYPE":1,"QTYPEname":"A","QCLASS":1,"QCLASSname":"IN",` +
+			`"answerRRs":[{"NAME":"example.com","TYPE":1,"TYPEname":"A","CLASS":1,"CLASSname":"IN","TTL":3600,"RDLENGTH":4,"RDATAHEX":"C0000201","rdataA":"192.0.2.1"}]}`
+		assert.Equal(t, expected, string(data))
+	})
+
+	t.Run("Should round trip a dns message to the same wire format", func(t *testing.T) {
+		flag := NewHeaderFlag(true, 0, false, false, true, true, 0b010, 0).GenerateFlag()
+		questions := []Question{*NewQuestion("example.com", TypeMX, ClassIN), *NewQuestion("example.org", TypeTXT, ClassCH)}
+		answers := []ResourceRecord{
+			*NewResourceRecord("example.com", TypeMX, ClassIN, 300, 18, append([]byte{0, 10}, encodeName("mail.example.com")...)),
+			*NewResourceRecord("example.com", 65280, ClassIN, 60, 3, []byte{1, 2, 3}),
+		}
+		message := NewDNSMessage(*NewHeader(7, flag, 2, 2, 0, 0), questions, answers)
+		message.SetEDNS(NewEDNS(1232, &NSIDOption{ID: []byte("ns1")}))
+
+		data, err := json.Marshal(message)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"questionRRs":[{"NAME":"example.com","TYPE":15,"TYPEname":"MX","CLASS":1,"CLASSname":"IN"}`)
+		assert.Contains(t, string(data), `"TYPEname":"TYPE65280"`)
+
+		var decoded DNSMessage
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, message.ToBytes(), decoded.ToBytes())
+		assert.Equal(t, "ns1", string(decoded.EDNS().Option(EDNSOptionNSID).(*NSIDOption).ID))
+	})
+
+	t.Run("Should read the resource data from the rdata members", func(t *testing.T) {
+		var rr ResourceRecord
+		assert.NoError(t, json.Unmarshal([]byte(`{"NAME":"example.com.","TYPE":16,"TTL":60,"rdataTXT":"\"v=spf1\" \"-all\""}`), &rr))
+		assert.Equal(t, "example.com", rr.Name)
+		assert.Equal(t, ClassIN, rr.Class)
+		assert.Equal(t, &TXT{Strings: []string{"v=spf1", "-all"}}, rr.Data)
+
+		assert.NoError(t, json.Unmarshal([]byte(`{"NAME":"www.example.com","TYPEname":"CNAME","CLASS":1,"TTL":60,"rdataCNAME":"example.com."}`), &rr))
+		assert.Equal(t, &CNAME{Target: "example.com"}, rr.Data)
+	})
+
+	t.Run("Should read a minimal message object", func(t *testing.T) {
+		var message DNSMessage
+		data := `{"ID":19678,"QR":false,"Opcode":0,"AA":0,"TC":0,"RD":true,"RA":0,"AD":0,"CD":0,"RCODE":0,"QNAME":"example.com","QTYPE":1,"QCLASS":1}`
+		assert.NoError(t, json.Unmarshal([]byte(data), &message))
+		expected := NewDNSMessage(*NewHeader(19678, NewHeaderFlag(false, 0, false, false, true, false, 0, 0).GenerateFlag(), 1, 0, 0, 0), []Question{*NewQuestion("example.com", TypeA, ClassIN)})
+		assert.Equal(t, expected, &message)
+	})
+
+	t.Run("Should reject invalid objects", func(t *testing.T) {
+		invalidRecords := []string{
+			`{"NAME":"example.com","TTL":60,"RDATAHEX":"C0000201"}`,
+			`{"NAME":"example.com","TYPE":1,"TTL":60,"RDLENGTH":3,"RDATAHEX":"C0000201"}`,
+			`{"NAME":"example.com","TYPE":1,"TTL":60,"RDATAHEX":"XYZ"}`,
+			`{"NAME":"example.com","TYPE":1,"TTL":60}`,
+			`{"NAME":"example.com","TYPE":1,"TTL":60,"rdataA":"not an address"}`,
+			`{"NAME":"example.com","TYPEname":"BOGUS","TTL":60}`,
+		}
+		for _, data := range invalidRecords {
+			var rr ResourceRecord
+			assert.Error(t, json.Unmarshal([]byte(data), &rr), data)
+		}
+		var header Header
+		assert.Error(t, json.Unmarshal([]byte(`{"ID":1,"QR":2}`), &header))
+		assert.Error(t, json.Unmarshal([]byte(`{"ID":1,"RCODE":16}`), &header))
+	})
+}
@@ -0,0 +1,238 @@
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// locEquator is the value of the latitude at the equator and of the longitude at the prime meridian,
+// in thousandths of a second of arc.
+const locEquator = 1 << 31
+
+// locBaseAltitude is the value of the altitude at the WGS 84 reference spheroid, in centimeters:
+// the altitude is counted from 100,000 meters below it.
+const locBaseAltitude = 10000000
+
+// LOC precisions written in zone files when they are omitted, in centimeters.
+const (
+	defaultLOCSize     = 100     // 1m
+	defaultLOCHorizPre = 1000000 // 10,000m
+	defaultLOCVertPre  = 1000    // 10m
+)
+
+// LOC is the resource data of a LOC record, the geographical location of the owner.
+// The size and the precisions are encoded as in the wire format: a mantissa in the high nibble and a power
+// of ten in the low nibble, in centimeters. See Coordinates for the location in degrees and meters.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1876#section-2 for more information
+type LOC struct {
+	Version   uint8  // Version is the version of the format, always 0.
+	Size      uint8  // Size is the diameter of the sphere enclosing the entity.
+	HorizPre  uint8  // HorizPre is the horizontal precision of the location, the diameter of its circle of error.
+	VertPre   uint8  // VertPre is the vertical precision of the location, the total of its range of error.
+	Latitude  uint32 // Latitude is in thousandths of a second of arc, from locEquator towards the north.
+	Longitude uint32 // Longitude is in thousandths of a second of arc, from locEquator towards the east.
+	Altitude  uint32 // Altitude is in centimeters, from 100,000 meters below the WGS 84 reference spheroid.
+}
+
+// Type returns TypeLOC.
+func (rd *LOC) Type() uint16 { return TypeLOC }
+
+// String returns the latitude and longitude in degrees, minutes and seconds followed by the altitude,
+// the size and the precisions in meters, such as 42 21 54 N 71 6 18 W -24m 30m 10000m 10m.
+func (rd *LOC) String() string {
+	return fmt.Sprintf("%s %s %sm %sm %sm %sm", formatLOCAngle(rd.Latitude, "N", "S"), formatLOCAngle(rd.Longitude, "E", "W"),
+		formatCentimeters(int64(rd.Altitude)-locBaseAltitude), formatCentimeters(locPrecision(rd.Size)),
+		formatCentimeters(locPrecision(rd.HorizPre)), formatCentimeters(locPrecision(rd.VertPre)))
+}
+
+// Equal reports whether other is a LOC record with the same fields.
+func (rd *LOC) Equal(other RData) bool {
+	o, ok := other.(*LOC)
+	return ok && *rd == *o
+}
+
+// Coordinates returns the latitude and the longitude in degrees, negative to the south and the west,
+// and the altitude in meters above the WGS 84 reference spheroid.
+func (rd *LOC) Coordinates() (latitude, longitude, altitude float64) {
+	latitude = float64(int64(rd.Latitude)-locEquator) / 3600000
+	longitude = float64(int64(rd.Longitude)-locEquator) / 3600000
+	altitude = float64(int64(rd.Altitude)-locBaseAltitude) / 100
+	return latitude, longitude, altitude
+}
+
+func (rd *LOC) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	if err := rd.check(); err != nil {
+		return nil, err
+	}
+	msg = append(msg, rd.Version, rd.Size, rd.HorizPre, rd.VertPre)
+	msg = binary.BigEndian.AppendUint32(msg, rd.Latitude)
+	msg = binary.BigEndian.AppendUint32(msg, rd.Longitude)
+	return binary.BigEndian.AppendUint32(msg, rd.Altitude), nil
+}
+
+func (rd *LOC) unpack(msg []byte, off, end int) error {
+	if end-off != 16 {
+		return rdataLengthError(TypeLOC, end-off)
+	}
+	rd.Version, rd.Size, rd.HorizPre, rd.VertPre = msg[off], msg[off+1], msg[off+2], msg[off+3]
+	rd.Latitude = binary.BigEndian.Uint32(msg[off+4 : off+8])
+	rd.Longitude = binary.BigEndian.Uint32(msg[off+8 : off+12])
+	rd.Altitude = binary.BigEndian.Uint32(msg[off+12 : off+16])
+	return rd.check()
+}
+
+// check checks the version, the encoding of the size and the precisions, and the range of the coordinates.
+func (rd *LOC) check() error {
+	if rd.Version != 0 {
+		return fmt.Errorf("unsupported LOC version: %d", rd.Version)
+	}
+	for _, precision := range []uint8{rd.Size, rd.HorizPre, rd.VertPre} {
+		if precision>>4 > 9 || precision&0x0F > 9 {
+			return fmt.Errorf("invalid LOC size or precision: %#02x", precision)
+		}
+	}
+	if rd.Latitude < locEquator-90*3600000 || rd.Latitude > locEquator+90*3600000 {
+		return fmt.Errorf("LOC latitude out of range: %d", rd.Latitude)
+	}
+	if rd.Longitude < locEquator-180*3600000 || rd.Longitude > locEquator+180*3600000 {
+		return fmt.Errorf("LOC longitude out of range: %d", rd.Longitude)
+	}
+	return nil
+}
+
+// parse accepts the minutes and seconds of the coordinates to be omitted, as well as the size and the precisions,
+// which default to 1m, 10000m and 10m. The unit of the altitude, the size and the precisions is optional.
+func (rd *LOC) parse(tokens []zoneToken, _ string) error {
+	latitude, tokens, err := parseLOCAngle(tokens, 90, "N", "S")
+	if err != nil {
+		return err
+	}
+	longitude, tokens, err := parseLOCAngle(tokens, 180, "E", "W")
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 || len(tokens) > 4 {
+		return fmt.Errorf("expected the altitude and at most 3 precisions, got %d fields", len(tokens))
+	}
+	altitude, err := parseCentimeters(tokens[0].text)
+	if err != nil || altitude < -locBaseAltitude || altitude > 1<<32-1-locBaseAltitude {
+		return fmt.Errorf("invalid LOC altitude: %s", tokens[0].text)
+	}
+
+	precisions := []int64{defaultLOCSize, defaultLOCHorizPre, defaultLOCVertPre}
+	for i, token := range tokens[1:] {
+		if precisions[i], err = parseCentimeters(token.text); err != nil || precisions[i] < 0 || precisions[i] > 9e9 {
+			return fmt.Errorf("invalid LOC size or precision: %s", token.text)
+		}
+	}
+	*rd = LOC{
+		Size:      encodeLOCPrecision(precisions[0]),
+		HorizPre:  encodeLOCPrecision(precisions[1]),
+		VertPre:   encodeLOCPrecision(precisions[2]),
+		Latitude:  latitude,
+		Longitude: longitude,
+		Altitude:  uint32(altitude + locBaseAltitude),
+	}
+	return nil
+}
+
+// locPrecision returns the size or precision, encoded as a mantissa and a power of ten, in centimeters.
+func locPrecision(encoded uint8) int64 {
+	value := int64(encoded >> 4)
+	for i := uint8(0); i < encoded&0x0F; i++ {
+		value *= 10
+	}
+	return value
+}
+
+// encodeLOCPrecision encodes the size or precision in centimeters as a mantissa and a power of ten,
+// truncating the digits that do not fit in the mantissa.
+func encodeLOCPrecision(centimeters int64) uint8 {
+	var exponent uint8
+	for centimeters > 9 {
+		centimeters /= 10
+		exponent++
+	}
+	return uint8(centimeters)<<4 | exponent
+}
+
+// formatLOCAngle returns the latitude or longitude in degrees, minutes and seconds followed by its hemisphere.
+func formatLOCAngle(angle uint32, positive, negative string) string {
+	hemisphere, value := positive, int64(angle)-locEquator
+	if value < 0 {
+		hemisphere, value = negative, -value
+	}
+	seconds := strconv.FormatInt(value/1000%60, 10)
+	if value%1000 != 0 {
+		seconds += fmt.Sprintf(".%03d", value%1000)
+	}
+	return fmt.Sprintf("%d %d %s %s", value/3600000, value/60000%60, seconds, hemisphere)
+}
+
+// parseLOCAngle decodes the degrees, minutes and seconds of a latitude or longitude followed by its hemisphere,
+// at the start of the fields, and returns it along with the fields that follow.
+func parseLOCAngle(tokens []zoneToken, maxDegrees int64, positive, negative string) (uint32, []zoneToken, error) {
+	var parts []string
+	for len(tokens) > 0 && len(parts) < 3 && !strings.EqualFold(tokens[0].text, positive) && !strings.EqualFold(tokens[0].text, negative) {
+		parts = append(parts, tokens[0].text)
+		tokens = tokens[1:]
+	}
+	if len(parts) == 0 || len(tokens) == 0 || (!strings.EqualFold(tokens[0].text, positive) && !strings.EqualFold(tokens[0].text, negative)) {
+		return 0, nil, fmt.Errorf("expected degrees, minutes and seconds followed by %s or %s", positive, negative)
+	}
+
+	// The degrees and minutes are integers, the seconds have at most 3 decimals
+	units, limits := []int64{3600000, 60000, 1}, []int64{maxDegrees, 59, 59999}
+	var total int64
+	for i, part := range parts {
+		value, err := strconv.ParseInt(part, 10, 64)
+		if i == 2 {
+			value, err = parseDecimal(part, 3)
+		}
+		if err != nil || value < 0 || value > limits[i] {
+			return 0, nil, fmt.Errorf("invalid LOC coordinate: %s", strings.Join(parts, " "))
+		}
+		total += value * units[i]
+	}
+	if total > maxDegrees*3600000 {
+		return 0, nil, fmt.Errorf("LOC coordinate out of range: %s", strings.Join(parts, " "))
+	}
+	if strings.EqualFold(tokens[0].text, negative) {
+		total = -total
+	}
+	return uint32(locEquator + total), tokens[1:], nil
+}
+
+// formatCentimeters returns the length in centimeters in meters, without decimals if it is a whole number.
+func formatCentimeters(centimeters int64) string {
+	sign := ""
+	if centimeters < 0 {
+		sign, centimeters = "-", -centimeters
+	}
+	if centimeters%100 == 0 {
+		return fmt.Sprintf("%s%d", sign, centimeters/100)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, centimeters/100, centimeters%100)
+}
+
+// parseCentimeters decodes a length in meters, with an optional unit and at most 2 decimals, in centimeters.
+func parseCentimeters(text string) (int64, error) {
+	return parseDecimal(strings.TrimSuffix(text, "m"), 2)
+}
+
+// parseDecimal decodes a decimal number with at most the given number of decimals,
+// as an integer in the unit of the last decimal.
+func parseDecimal(text string, decimals int) (int64, error) {
+	whole, fraction, _ := strings.Cut(text, ".")
+	if len(fraction) > decimals || strings.ContainsAny(fraction, "+-") || (whole == "" || whole == "-") && fraction == "" {
+		return 0, fmt.Errorf("invalid decimal number: %s", text)
+	}
+	value, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", decimals-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid decimal number: %s", text)
+	}
+	return value, nil
+}
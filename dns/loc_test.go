@@ -0,0 +1,79 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLOC(t *testing.T) {
+	t.Run("Should read the LOC records of RFC 1876", func(t *testing.T) {
+		zone := "$TTL 300\ncambridge-net LOC 42 21 54 N 71 06 18 W -24m 30m\nloiosh LOC 42 21 43.952 N 71 5 6.344 W -24m 1m 200m\n" +
+			"pipex LOC 52 14 05 N 00 08 50 E 10m\ncurtin LOC 32 7 19 S 116 2 25 E 10m\nrwy04L LOC 42 21 28.764 N 71 00 51.617 W -44m 2000m\n"
+		records, err := ParseZone(strings.NewReader(zone), "kei.com.", "")
+		assert.NoError(t, err)
+		if !assert.Equal(t, 5, len(records)) {
+			return
+		}
+
+		assert.Equal(t, &LOC{Size: 0x33, HorizPre: 0x16, VertPre: 0x13, Latitude: 2299997648, Longitude: 1891505648, Altitude: 9997600}, records[0].Data)
+		for i, expected := range []string{
+			"42 21 54 N 71 6 18 W -24m 30m 10000m 10m",
+			"42 21 43.952 N 71 5 6.344 W -24m 1m 200m 10m",
+			"52 14 5 N 0 8 50 E 10m 1m 10000m 10m",
+			"32 7 19 S 116 2 25 E 10m 1m 10000m 10m",
+			"42 21 28.764 N 71 0 51.617 W -44m 2000m 10000m 10m",
+		} {
+			assert.Equal(t, expected, records[i].RDataParsed)
+			reparsed, err := parseRData(TypeLOC, lexRData(t, expected), "")
+			assert.NoError(t, err)
+			assert.True(t, records[i].Data.Equal(reparsed), expected)
+		}
+
+		latitude, longitude, altitude := records[3].Data.(*LOC).Coordinates()
+		assert.InDelta(t, -32.12194, latitude, 0.00001)
+		assert.InDelta(t, 116.04028, longitude, 0.00001)
+		assert.Equal(t, 10.0, altitude)
+	})
+
+	t.Run("Should pack and unpack a LOC record", func(t *testing.T) {
+		record := &LOC{Size: 0x12, HorizPre: 0x16, VertPre: 0x13, Latitude: 0x80000000, Longitude: 0x80000000, Altitude: 10000050}
+		rData, err := PackRData(record)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 0x12, 0x16, 0x13, 0x80, 0, 0, 0, 0x80, 0, 0, 0, 0, 0x98, 0x96, 0xB2}, rData)
+		unpacked, err := UnpackRData(TypeLOC, rData)
+		assert.NoError(t, err)
+		assert.True(t, record.Equal(unpacked))
+		assert.Equal(t, "0 0 0 N 0 0 0 E 0.50m 1m 10000m 10m", unpacked.String())
+
+		for _, invalid := range [][]byte{
+			{1, 0x12, 0x16, 0x13, 0x80, 0, 0, 0, 0x80, 0, 0, 0, 0, 0x98, 0x96, 0xB2},
+			{0, 0xA2, 0x16, 0x13, 0x80, 0, 0, 0, 0x80, 0, 0, 0, 0, 0x98, 0x96, 0xB2},
+			{0, 0x12, 0x16, 0x13, 0xFF, 0, 0, 0, 0x80, 0, 0, 0, 0, 0x98, 0x96, 0xB2},
+			{0, 0x12, 0x16, 0x13, 0x80, 0, 0, 0, 0x80, 0, 0, 0, 0, 0x98, 0x96},
+		} {
+			_, err := UnpackRData(TypeLOC, invalid)
+			assert.Error(t, err, "%v", invalid)
+		}
+	})
+
+	t.Run("Should reject invalid LOC records in zone files", func(t *testing.T) {
+		for _, invalid := range []string{
+			"@ 300 LOC 91 0 0 N 0 0 0 E 0m",
+			"@ 300 LOC 90 0 1 N 0 0 0 E 0m",
+			"@ 300 LOC 42 60 N 71 W 0m",
+			"@ 300 LOC 42 21 54.1234 N 71 W 0m",
+			"@ 300 LOC 42 N 181 W 0m",
+			"@ 300 LOC 42 N 71 W",
+			"@ 300 LOC 42 71 W 0m",
+			"@ 300 LOC 42 N 71 W -100000.01m",
+			"@ 300 LOC 42 N 71 W 0m 1m 1m 1m 1m",
+			"@ 300 LOC 42 N 71 W 0m 90000001m",
+			"@ 300 LOC 42 N 71 W 0.001m",
+		} {
+			_, err := ParseZone(strings.NewReader(invalid), "example.com.", "")
+			assert.Error(t, err, invalid)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Name is a domain name in its presentation format, as written in zone files: labels separated by dots,
+// in which the dots and backslashes that are part of a label, the characters with a special meaning in zone
+// files and the bytes that are not printable are escaped as \X or \DDD. For example, a\.b.example.com
+// holds the 3 labels "a.b", "example" and "com". A name ending with a dot that is not escaped is fully
+// qualified, the root being ".", and is relative otherwise.
+//
+// The domain names held as strings by the other types of the package, such as ResourceRecord.Name,
+// use the same format. Names decoded from messages are fully qualified, but are held without their trailing dot.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1035#section-5.1 for more information
+type Name string
+
+// NewName returns the domain name written in the presentation format, with its escapes in their shortest form.
+// It fails with ErrInvalidDomainName if the name is empty, holds an empty label, a label longer than
+// 63 octets or an incomplete escape, and with ErrNameTooLong if its wire format is longer than 255 octets.
+func NewName(s string) (Name, error) {
+	if s == "" {
+		return "", fmt.Errorf("%w: empty domain name", ErrInvalidDomainName)
+	}
+	labels, err := decodeLabels(s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(escapeLabel(label))
+	}
+	if isFQDN(s) {
+		b.WriteByte('.')
+	}
+	return Name(b.String()), nil
+}
+
+// String returns the name in its presentation format.
+func (n Name) String() string { return string(n) }
+
+// IsFQDN reports whether the name is fully qualified, ending with a dot that is not escaped.
+func (n Name) IsFQDN() bool { return isFQDN(string(n)) }
+
+// FQDN returns the fully qualified form of the name, ending with a dot.
+func (n Name) FQDN() Name { return Name(fqdn(string(n))) }
+
+// Labels returns the labels of the name in their presentation format, without the root label.
+func (n Name) Labels() []string { return SplitLabels(string(n)) }
+
+// CountLabels returns the number of labels of the name, without the root label.
+func (n Name) CountLabels() int { return CountLabels(string(n)) }
+
+// Parent returns the name without its first label, see Parent.
+func (n Name) Parent() Name { return Name(Parent(string(n))) }
+
+// IsSubdomain reports whether the name is equal to or below the parent name, see IsSubdomain.
+func (n Name) IsSubdomain(parent Name) bool { return IsSubdomain(string(n), string(parent)) }
+
+// Equal reports whether the two names hold the same labels, ignoring the case of the ASCII letters and the
+// form of the escapes. A relative name is equal to the fully qualified name holding the same labels, as the
+// names decoded from messages are held without their trailing dot.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4343#section-3 for more information
+func (n Name) Equal(other Name) bool {
+	labels, otherLabels := canonicalLabels(string(n)), canonicalLabels(string(other))
+	if len(labels) != len(otherLabels) {
+		return false
+	}
+	for i := range labels {
+		if labels[i] != otherLabels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Canonical returns the fully qualified form of the name with its ASCII letters lowered and its escapes
+// in their shortest form, as in the canonical form of RFC 4034. Names that are not valid are only lowered.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4034#section-6.2 for more information
+func (n Name) Canonical() Name {
+	return Name(fqdn(canonicalName(string(n))))
+}
+
+// SplitLabels returns the labels of the domain name in their presentation format, without the root label.
+// The name is split at the dots that are not escaped, and the labels keep their escapes.
+func SplitLabels(name string) []string {
+	name = trimFQDN(name)
+	if name == "" {
+		return nil
+	}
+	var labels []string
+	start := 0
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '\\':
+			i++
+		case '.':
+			labels = append(labels, name[start:i])
+			start = i + 1
+		}
+	}
+	return append(labels, name[start:])
+}
+
+// CountLabels returns the number of labels of the domain name, without the root label.
+func CountLabels(name string) int {
+	return len(SplitLabels(name))
+}
+
+// Parent returns the domain name without its first label, fully qualified if the name is.
+// The parent of a fully qualified name of a single label is the root, ".".
+// The root and the relative names of a single label have no parent, for which an empty name is returned.
+func Parent(name string) string {
+	labels := SplitLabels(name)
+	if len(labels) <= 1 {
+		if len(labels) == 1 && isFQDN(name) {
+			return "."
+		}
+		return ""
+	}
+	parent := strings.Join(labels[1:], ".")
+	if isFQDN(name) {
+		parent += "."
+	}
+	return parent
+}
+
+// IsSubdomain reports whether the domain name child is equal to or below the domain name parent,
+// comparing their labels as by Name.Equal. Every name is below the root.
+func IsSubdomain(child, parent string) bool {
+	childLabels, parentLabels := canonicalLabels(child), canonicalLabels(parent)
+	if len(parentLabels) > len(childLabels) {
+		return false
+	}
+	childLabels = childLabels[len(childLabels)-len(parentLabels):]
+	for i := range parentLabels {
+		if childLabels[i] != parentLabels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isFQDN reports whether the domain name ends with a dot that is not escaped.
+func isFQDN(name string) bool {
+	if !strings.HasSuffix(name, ".") {
+		return false
+	}
+	backslashes := 0
+	for i := len(name) - 2; i >= 0 && name[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 0
+}
+
+// trimFQDN returns the domain name without its trailing dot, unless the dot is escaped.
+func trimFQDN(name string) string {
+	if isFQDN(name) {
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// decodeLabels returns the labels of the domain name with their escapes decoded, without the root label.
+// It fails with ErrInvalidDomainName if a label is empty, longer than 63 octets or holds an incomplete escape,
+// and with ErrNameTooLong if the wire format of the name is longer than 255 octets.
+func decodeLabels(name string) ([]string, error) {
+	labels := SplitLabels(name)
+	wireLength := 1
+	for i, label := range labels {
+		decoded, err := unescapeCharacterString(label)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDomainName, name, err)
+		}
+		if len(decoded) == 0 || len(decoded) > maxLabelLength {
+			return nil, fmt.Errorf("%w: %s: label length must be between 1 and %d octets", ErrInvalidDomainName, name, maxLabelLength)
+		}
+		labels[i] = decoded
+		wireLength += len(decoded) + 1
+	}
+	if wireLength > maxNameLength {
+		return nil, fmt.Errorf("%w: %s", ErrNameTooLong, name)
+	}
+	return labels, nil
+}
+
+// canonicalLabels returns the labels of the domain name with their escapes decoded and their ASCII letters
+// lowered, without the root label. The labels holding an incomplete escape are kept as is.
+func canonicalLabels(name string) []string {
+	labels := SplitLabels(name)
+	for i, label := range labels {
+		if decoded, err := unescapeCharacterString(label); err == nil {
+			label = decoded
+		}
+		labels[i] = lowerASCII(label)
+	}
+	return labels
+}
+
+// specialNameCharacters are the characters of a label escaped as \X in the presentation format: the dot and the
+// backslash, which would be read as a label separator and an escape, and those with a special meaning in zone files.
+const specialNameCharacters = `."();\@$`
+
+// escapeLabel returns the presentation format of a label: its special characters are escaped as \X,
+// and the bytes that are not printable as \DDD.
+func escapeLabel(label string) string {
+	if !needsEscape(label) {
+		return label
+	}
+	return string(appendEscapedLabel(nil, []byte(label)))
+}
+
+// appendEscapedLabel appends the presentation format of a label to dst, see escapeLabel.
+func appendEscapedLabel(dst []byte, label []byte) []byte {
+	for _, c := range label {
+		switch {
+		case strings.IndexByte(specialNameCharacters, c) >= 0:
+			dst = append(dst, '\\', c)
+		case c <= ' ' || c > '~':
+			dst = append(dst, '\\', '0'+c/100, '0'+c/10%10, '0'+c%10)
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// needsEscape reports whether the label holds a special character or a byte that is not printable.
+func needsEscape(label string) bool {
+	for i := 0; i < len(label); i++ {
+		if c := label[i]; c <= ' ' || c > '~' || strings.IndexByte(specialNameCharacters, c) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerASCII returns the string with its uppercase ASCII letters lowered. The other bytes are left unchanged.
+func lowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
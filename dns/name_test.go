@@ -0,0 +1,129 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestName(t *testing.T) {
+	t.Run("Should create the names with their escapes in their shortest form", func(t *testing.T) {
+		for text, expected := range map[string]Name{
+			"www.example.com":       "www.example.com",
+			"www.example.com.":      "www.example.com.",
+			".":                     ".",
+			`a\.b.example.com.`:     `a\.b.example.com.`,
+			`\065\098c.example`:     "Abc.example",
+			`with\ space.example`:   `with\032space.example`,
+			`back\\slash.example`:   `back\\slash.example`,
+			`\000\255.example`:      `\000\255.example`,
+			`semi\;colon.example`:   `semi\;colon.example`,
+			`trailing\..`:           `trailing\..`,
+			`trailing\.`:            `trailing\.`,
+			`trailing\\.`:           `trailing\\.`,
+			"_sip._udp.example.com": "_sip._udp.example.com",
+		} {
+			name, err := NewName(text)
+			assert.NoError(t, err, text)
+			assert.Equal(t, expected, name, text)
+		}
+	})
+
+	t.Run("Should reject the names that are not valid", func(t *testing.T) {
+		for _, text := range []string{
+			"",
+			"www..example",
+			".example",
+			"..",
+			strings.Repeat("a", 64) + ".example",
+			`incomplete\`,
+			`incomplete\12.example`,
+			`out\256of.range`,
+		} {
+			_, err := NewName(text)
+			assert.ErrorIs(t, err, ErrInvalidDomainName, text)
+		}
+		_, err := NewName(strings.Repeat("a.", 127) + "a")
+		assert.ErrorIs(t, err, ErrNameTooLong)
+		escaped := strings.Repeat(`\.`, 63) + "." + strings.Repeat("a", 63) + "." + strings.Repeat("b", 63) + "."
+		_, err = NewName(escaped + strings.Repeat("c", 61))
+		assert.NoError(t, err, "the escapes count as a single octet")
+		_, err = NewName(escaped + strings.Repeat("c", 62))
+		assert.ErrorIs(t, err, ErrNameTooLong)
+		name, err := NewName(strings.Repeat("a.", 126) + "a")
+		assert.NoError(t, err)
+		assert.Equal(t, 127, name.CountLabels())
+	})
+
+	t.Run("Should tell the fully qualified names from the relative ones", func(t *testing.T) {
+		assert.True(t, Name("example.com.").IsFQDN())
+		assert.True(t, Name(".").IsFQDN())
+		assert.True(t, Name(`a\\.`).IsFQDN())
+		assert.False(t, Name("example.com").IsFQDN())
+		assert.False(t, Name(`a\.`).IsFQDN())
+		assert.Equal(t, Name("example.com."), Name("example.com").FQDN())
+		assert.Equal(t, Name("example.com."), Name("example.com.").FQDN())
+		assert.Equal(t, Name(`a\..`), Name(`a\.`).FQDN())
+	})
+
+	t.Run("Should split the names at the dots that are not escaped", func(t *testing.T) {
+		assert.Equal(t, []string{"www", "example", "com"}, SplitLabels("www.example.com."))
+		assert.Equal(t, []string{`a\.b`, `c\\`, "d"}, SplitLabels(`a\.b.c\\.d`))
+		assert.Equal(t, []string{`a\046b`, "c"}, Name(`a\046b.c`).Labels())
+		assert.Nil(t, SplitLabels("."))
+		assert.Nil(t, SplitLabels(""))
+		assert.Equal(t, 2, CountLabels(`a\.b.c\..`))
+	})
+
+	t.Run("Should return the parent of the names", func(t *testing.T) {
+		assert.Equal(t, "example.com.", Parent("www.example.com."))
+		assert.Equal(t, "example.com", Parent("www.example.com"))
+		assert.Equal(t, "b.c", Parent(`a\.b.b.c`))
+		assert.Equal(t, ".", Parent("com."))
+		assert.Equal(t, "", Parent("com"))
+		assert.Equal(t, "", Parent("."))
+		assert.Equal(t, Name("example."), Name("www.example.").Parent())
+	})
+
+	t.Run("Should compare the names in their canonical form", func(t *testing.T) {
+		assert.True(t, Name("WWW.Example.COM.").Equal("www.example.com"))
+		assert.True(t, Name(`\065.example`).Equal("a.example"))
+		assert.False(t, Name(`a\.b.example`).Equal("a.b.example"))
+		assert.False(t, Name("www.example.com").Equal("example.com"))
+		assert.Equal(t, Name("www.example.com."), Name("WWW.Example.COM").Canonical())
+		assert.Equal(t, Name(`a\.b.example.`), Name(`A\046B.example`).Canonical())
+	})
+
+	t.Run("Should tell whether a name is below another one", func(t *testing.T) {
+		assert.True(t, IsSubdomain("www.Example.com", "example.COM."))
+		assert.True(t, IsSubdomain("example.com", "example.com"))
+		assert.True(t, IsSubdomain("example.com", "."))
+		assert.True(t, Name(`a\.b.example`).IsSubdomain("example"))
+		assert.False(t, IsSubdomain(`www\.example.com`, "example.com"), "the escaped dot does not separate labels")
+		assert.False(t, IsSubdomain("wwwexample.com", "example.com"))
+		assert.False(t, IsSubdomain("example.com", "www.example.com"))
+	})
+
+	t.Run("Should escape the labels decoded from messages", func(t *testing.T) {
+		msg := []byte{3, 'a', '.', 'b', 4, 0, ' ', '\\', 0xFF, 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 0}
+		name, _, err := DecodeNameAt(msg, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, `a\.b.\000\032\\\255.example`, name)
+		assert.Equal(t, 3, CountLabels(name))
+		assert.Equal(t, string(msg), encodeName(name), "the escapes are decoded when the name is encoded")
+	})
+
+	t.Run("Should not encode the names that are not valid", func(t *testing.T) {
+		for _, name := range []string{"www..example", strings.Repeat("a", 64) + ".example", strings.Repeat("a.", 128)} {
+			assert.Equal(t, "", encodeName(name), name)
+		}
+		assert.Equal(t, "\x03www\x07example\x00", encodeName("www.example."), "the trailing dot does not add an empty label")
+
+		header := NewHeader(1, 0, 1, 0, 0, 0)
+		message := NewDNSMessage(*header, []Question{{Name: "www..example", QType: TypeA, QClass: ClassIN}})
+		_, err := message.Pack()
+		assert.ErrorIs(t, err, ErrInvalidDomainName)
+		assert.Nil(t, message.ToBytes())
+	})
+}
@@ -0,0 +1,98 @@
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// NAPTR is the resource data of a NAPTR record, a rule of the Dynamic Delegation Discovery System that rewrites
+// a string, such as a telephone number or a SIP domain, into a domain name or a URI.
+// Either the regular expression or the replacement is used: the replacement is the root when the regular
+// expression is set, and the regular expression is empty otherwise.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3403#section-4.1 for more information
+type NAPTR struct {
+	Order       uint16 // Order is the order in which the records must be processed, lowest first.
+	Preference  uint16 // Preference orders the records with the same order, lowest first.
+	Flags       string // Flags control the rewriting, such as "S" when the next lookup is for SRV records.
+	Services    string // Services are the protocols and services available down this rewrite path, such as "SIP+D2U".
+	Regexp      string // Regexp is the substitution expression applied to the string.
+	Replacement string // Replacement is the next domain name to query, or the root if Regexp is used.
+}
+
+// Type returns TypeNAPTR.
+func (rd *NAPTR) Type() uint16 { return TypeNAPTR }
+
+// String returns the order and preference, the quoted flags, services and regular expression, and the
+// fully qualified replacement.
+func (rd *NAPTR) String() string {
+	return fmt.Sprintf("%d %d %s %s %s %s", rd.Order, rd.Preference, quoteCharacterString(rd.Flags),
+		quoteCharacterString(rd.Services), quoteCharacterString(rd.Regexp), fqdn(rd.Replacement))
+}
+
+// Equal reports whether other is a NAPTR record with the same fields. The flags are compared case-insensitively.
+func (rd *NAPTR) Equal(other RData) bool {
+	o, ok := other.(*NAPTR)
+	return ok && rd.Order == o.Order && rd.Preference == o.Preference && strings.EqualFold(rd.Flags, o.Flags) &&
+		rd.Services == o.Services && rd.Regexp == o.Regexp && equalNames(rd.Replacement, o.Replacement)
+}
+
+// pack never compresses the replacement, as required by RFC 3403.
+func (rd *NAPTR) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg = binary.BigEndian.AppendUint16(msg, rd.Order)
+	msg = binary.BigEndian.AppendUint16(msg, rd.Preference)
+	msg, err := (&TXT{Strings: []string{rd.Flags, rd.Services, rd.Regexp}}).pack(msg, nil)
+	if err != nil {
+		return nil, err
+	}
+	return appendName(msg, rd.Replacement, nil)
+}
+
+func (rd *NAPTR) unpack(msg []byte, off, end int) error {
+	if end-off < 8 {
+		return rdataLengthError(TypeNAPTR, end-off)
+	}
+	rd.Order = binary.BigEndian.Uint16(msg[off : off+2])
+	rd.Preference = binary.BigEndian.Uint16(msg[off+2 : off+4])
+	off += 4
+	for _, field := range []*string{&rd.Flags, &rd.Services, &rd.Regexp} {
+		if off >= end || off+1+int(msg[off]) > end {
+			return fmt.Errorf("character-string exceeds the resource data")
+		}
+		*field = string(msg[off+1 : off+1+int(msg[off])])
+		off += 1 + int(msg[off])
+	}
+	replacement, next, err := unpackRDataName(msg, off, end)
+	if err != nil {
+		return err
+	}
+	if next != end {
+		return rdataLengthError(TypeNAPTR, end-off)
+	}
+	rd.Replacement = replacement
+	return nil
+}
+
+func (rd *NAPTR) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 6); err != nil {
+		return err
+	}
+	for i, field := range []*uint16{&rd.Order, &rd.Preference} {
+		value, err := parseUint(tokens[i], 16)
+		if err != nil {
+			return err
+		}
+		*field = uint16(value)
+	}
+	strs := &TXT{}
+	if err := strs.parse(tokens[2:5], origin); err != nil {
+		return err
+	}
+	replacement, err := parseZoneName(tokens[5].text, origin)
+	if err != nil {
+		return err
+	}
+	rd.Flags, rd.Services, rd.Regexp, rd.Replacement = strs.Strings[0], strs.Strings[1], strs.Strings[2], replacement
+	return nil
+}
@@ -0,0 +1,50 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNAPTR(t *testing.T) {
+	t.Run("Should pack, unpack and print a NAPTR record", func(t *testing.T) {
+		record := &NAPTR{Order: 100, Preference: 10, Flags: "S", Services: "SIP+D2U", Replacement: "_sip._udp.example.com"}
+		rData, err := PackRData(record)
+		assert.NoError(t, err)
+		expected := append([]byte{0, 100, 0, 10, 1, 'S', 7, 'S', 'I', 'P', '+', 'D', '2', 'U', 0}, encodeName("_sip._udp.example.com")...)
+		assert.Equal(t, expected, rData)
+
+		unpacked, err := UnpackRData(TypeNAPTR, rData)
+		assert.NoError(t, err)
+		assert.True(t, record.Equal(unpacked))
+		assert.True(t, record.Equal(&NAPTR{Order: 100, Preference: 10, Flags: "s", Services: "SIP+D2U", Replacement: "_SIP._udp.example.com."}))
+		assert.Equal(t, `100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`, unpacked.String())
+	})
+
+	t.Run("Should reject truncated NAPTR records", func(t *testing.T) {
+		for _, rData := range [][]byte{{0, 100, 0, 10}, {0, 100, 0, 10, 1, 'S', 0, 5, 'a', 0}, {0, 100, 0, 10, 0, 0, 0, 0, 0}} {
+			_, err := UnpackRData(TypeNAPTR, rData)
+			assert.Error(t, err, "%v", rData)
+		}
+	})
+
+	t.Run("Should read and write NAPTR records in zone files", func(t *testing.T) {
+		zone := `$TTL 300
+@ NAPTR 100 50 "a" "z3950+N2L+N2C" "" cidserver
+@ NAPTR 100 10 "" "" "!^urn:cid:.+@([^\\.]+\\.)(.*)$!\\2!i" .
+`
+		records, err := ParseZone(strings.NewReader(zone), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, &NAPTR{Order: 100, Preference: 50, Flags: "a", Services: "z3950+N2L+N2C", Replacement: "cidserver.example.com"}, records[0].Data)
+			assert.Equal(t, `!^urn:cid:.+@([^\.]+\.)(.*)$!\2!i`, records[1].Data.(*NAPTR).Regexp)
+			assert.Equal(t, "example.com.\t300\tIN\tNAPTR\t100 10 \"\" \"\" \"!^urn:cid:.+@([^\\\\.]+\\\\.)(.*)$!\\\\2!i\" .", records[1].String())
+		}
+
+		for _, invalid := range []string{`@ NAPTR 100 10 "S" "SIP+D2U" ""`, `@ NAPTR 100 65536 "" "" "" .`} {
+			_, err := ParseZone(strings.NewReader(invalid), "example.com.", "")
+			assert.Error(t, err, invalid)
+		}
+	})
+}
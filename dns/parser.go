@@ -0,0 +1,251 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Sections of a DNS message, in the order in which they are read by a Parser.
+const (
+	SectionQuestion = iota
+	SectionAnswer
+	SectionAuthority
+	SectionAdditional
+)
+
+// sectionNames are the names of the sections, as reported by ParseError.
+var sectionNames = [...]string{"question", "answer", "authority", "additional"}
+
+// ErrSectionDone is returned by a Parser when the section has no more entries to read.
+var ErrSectionDone = errors.New("no more entries in the section")
+
+// Parser reads a DNS message in its wire format lazily, without copying it: the entries of the sections are read
+// one at a time, in the order of the message, as RawQuestion and RawRecord values that refer to the message by
+// offsets. Their domain names and resource data are only decoded when asked for, so that walking a message does
+// not allocate. The zero value is ready to Start, and a Parser can be reused for the next message.
+//
+// The message must not be modified while it is being read, nor while the values read from it are in use.
+type Parser struct {
+	msg     []byte
+	header  Header
+	off     int   // off is the offset of the next entry.
+	section int   // section is the section of the next entry.
+	index   int   // index is the index of the next entry in its section.
+	err     error // err is the error that stopped the parsing, returned by every later call.
+}
+
+// Start resets the Parser to read the message and returns its header.
+// It fails with a *ParseError if the message is shorter than the header, or if the header counts
+// can not fit in the message.
+func (p *Parser) Start(msg []byte) (Header, error) {
+	*p = Parser{msg: msg, off: headerLength}
+	if len(msg) < headerLength {
+		p.err = &ParseError{Section: "header", Err: ErrShortHeader}
+		return Header{}, p.err
+	}
+	p.header = Header{
+		ID:      binary.BigEndian.Uint16(msg[0:]),
+		Flags:   binary.BigEndian.Uint16(msg[2:]),
+		QDCount: binary.BigEndian.Uint16(msg[4:]),
+		ANCount: binary.BigEndian.Uint16(msg[6:]),
+		NSCount: binary.BigEndian.Uint16(msg[8:]),
+		ARCount: binary.BigEndian.Uint16(msg[10:]),
+	}
+	minLength := headerLength + int(p.header.QDCount)*minQuestionLength +
+		(int(p.header.ANCount)+int(p.header.NSCount)+int(p.header.ARCount))*minResourceRecordLength
+	if minLength > len(msg) {
+		p.err = &ParseError{Section: "header", Err: ErrCountTooLarge}
+		return Header{}, p.err
+	}
+	return p.header, nil
+}
+
+// Question reads the next entry of the question section.
+// It returns ErrSectionDone once every question was read, and a *ParseError if the question is not valid.
+func (p *Parser) Question() (RawQuestion, error) {
+	if p.err != nil {
+		return RawQuestion{}, p.err
+	}
+	if p.section != SectionQuestion || p.index >= p.count(SectionQuestion) {
+		return RawQuestion{}, ErrSectionDone
+	}
+	off := p.off
+	next, err := skipName(p.msg, off)
+	if err == nil && next+4 > len(p.msg) {
+		err = fmt.Errorf("%w: question type and class at offset %d", ErrTruncated, next)
+	}
+	if err != nil {
+		return RawQuestion{}, p.fail(off, err)
+	}
+	p.off, p.index = next+4, p.index+1
+	return RawQuestion{
+		Name:  RawName{msg: p.msg, Offset: off},
+		Type:  binary.BigEndian.Uint16(p.msg[next:]),
+		Class: binary.BigEndian.Uint16(p.msg[next+2:]),
+	}, nil
+}
+
+// Record reads the next record of the given section: SectionAnswer, SectionAuthority or SectionAdditional.
+// The entries of the sections before it that were not read yet are skipped.
+// It returns ErrSectionDone once every record of the section was read, or if the Parser is past the section,
+// and a *ParseError if the record is not valid.
+func (p *Parser) Record(section int) (RawRecord, error) {
+	if section < SectionAnswer || section > SectionAdditional {
+		return RawRecord{}, fmt.Errorf("invalid section of records: %d", section)
+	}
+	if p.err != nil {
+		return RawRecord{}, p.err
+	}
+	if p.section > section {
+		return RawRecord{}, ErrSectionDone
+	}
+	for p.section < section {
+		if err := p.skip(); err != nil {
+			return RawRecord{}, err
+		}
+	}
+	if p.index >= p.count(section) {
+		return RawRecord{}, ErrSectionDone
+	}
+
+	off := p.off
+	next, err := skipName(p.msg, off)
+	if err == nil && next+10 > len(p.msg) { // 10 is the length of the fields before RData
+		err = fmt.Errorf("%w: resource record fields at offset %d", ErrTruncated, next)
+	}
+	if err != nil {
+		return RawRecord{}, p.fail(off, err)
+	}
+	rdLength := int(binary.BigEndian.Uint16(p.msg[next+8:]))
+	dataOffset := next + 10
+	if dataOffset+rdLength > len(p.msg) {
+		return RawRecord{}, p.fail(off, fmt.Errorf("%w: resource data of length %d at offset %d", ErrTruncated, rdLength, dataOffset))
+	}
+	p.off, p.index = dataOffset+rdLength, p.index+1
+	return RawRecord{
+		Name:       RawName{msg: p.msg, Offset: off},
+		Type:       binary.BigEndian.Uint16(p.msg[next:]),
+		Class:      binary.BigEndian.Uint16(p.msg[next+2:]),
+		TTL:        binary.BigEndian.Uint32(p.msg[next+4:]),
+		DataOffset: dataOffset,
+		Data:       p.msg[dataOffset : dataOffset+rdLength : dataOffset+rdLength],
+	}, nil
+}
+
+// skip skips the next entry of the current section, or moves to the next section if the current one was read.
+func (p *Parser) skip() error {
+	if p.index >= p.count(p.section) {
+		p.section, p.index = p.section+1, 0
+		return nil
+	}
+	var err error
+	if p.section == SectionQuestion {
+		_, err = p.Question()
+	} else {
+		_, err = p.Record(p.section)
+	}
+	return err
+}
+
+// count returns the number of entries of the section, from the header.
+func (p *Parser) count(section int) int {
+	switch section {
+	case SectionQuestion:
+		return int(p.header.QDCount)
+	case SectionAnswer:
+		return int(p.header.ANCount)
+	case SectionAuthority:
+		return int(p.header.NSCount)
+	}
+	return int(p.header.ARCount)
+}
+
+// fail stops the parsing with the error of the entry starting at off.
+func (p *Parser) fail(off int, err error) error {
+	p.err = &ParseError{Section: sectionNames[p.section], Index: p.index, Offset: off, Err: err}
+	return p.err
+}
+
+// skipName returns the offset of the first byte following the domain name starting at off.
+// It checks that the labels fit in the message, but does not follow the compression pointers,
+// which are checked when the name is decoded.
+func skipName(msg []byte, off int) (int, error) {
+	for {
+		if off >= len(msg) {
+			return 0, &NameError{Offset: off, Err: ErrNameTruncated}
+		}
+		length := int(msg[off])
+		switch length & 0xC0 {
+		case 0x00:
+			if length == 0 {
+				return off + 1, nil
+			}
+			off += 1 + length
+		case 0xC0:
+			if off+1 >= len(msg) {
+				return 0, &NameError{Offset: off, Err: ErrNameTruncated}
+			}
+			return off + 2, nil
+		default:
+			return 0, &NameError{Offset: off, Err: ErrInvalidLabelType}
+		}
+	}
+}
+
+// RawName is a domain name of a message read by a Parser, which is only decoded when asked for.
+type RawName struct {
+	msg    []byte
+	Offset int // Offset is the offset of the name in the message.
+}
+
+// Decode returns the presentation format of the name, as DecodeNameAt does.
+func (n RawName) Decode() (string, error) {
+	name, _, err := DecodeNameAt(n.msg, n.Offset)
+	return name, err
+}
+
+// AppendDecoded appends the presentation format of the name to b, as Decode returns it, and returns the
+// extended buffer. The root is appended as an empty name. It does not allocate when b has the capacity
+// to hold the name, at most 1003 bytes once escaped.
+func (n RawName) AppendDecoded(b []byte) ([]byte, error) {
+	b, _, err := appendDecodedName(b, n.msg, n.Offset)
+	return b, err
+}
+
+// RawQuestion is an entry of the question section of a message read by a Parser.
+type RawQuestion struct {
+	Name  RawName // Name is the domain name of the question.
+	Type  uint16  // Type is the question type.
+	Class uint16  // Class is the question class.
+}
+
+// Question decodes the question.
+func (q RawQuestion) Question() (*Question, error) {
+	question, _, err := unpackQuestion(q.Name.msg, q.Name.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// RawRecord is a resource record of a message read by a Parser.
+type RawRecord struct {
+	Name       RawName // Name is the owner name of the record.
+	Type       uint16  // Type is the type of the record.
+	Class      uint16  // Class is the class of the record.
+	TTL        uint32  // TTL is the time to live of the record.
+	DataOffset int     // DataOffset is the offset of the resource data in the message.
+	// Data is the resource data, a slice of the message. The domain names it holds may be compressed,
+	// in which case they are decoded with DecodeNameAt from their offset in the message.
+	Data []byte
+}
+
+// Record decodes the record, with its typed resource data, as ParseMessage does.
+func (r RawRecord) Record() (*ResourceRecord, error) {
+	rr, _, err := unpackResourceRecord(r.Name.msg, r.Name.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
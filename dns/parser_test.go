@@ -0,0 +1,185 @@
+package dns
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parserTestMessage returns a response with a record in each section, with compressed names.
+func parserTestMessage() []byte {
+	header := NewHeader(7, 0x8180, 1, 2, 1, 1)
+	ns := encodeName("ns.example.com")
+	message := NewDNSMessage(*header, []Question{*NewQuestion("www.example.com", TypeA, ClassIN)},
+		[]ResourceRecord{
+			*NewResourceRecord("www.example.com", TypeA, ClassIN, 300, 4, []byte{192, 0, 2, 1}),
+			*NewResourceRecord("www.example.com", TypeA, ClassIN, 300, 4, []byte{192, 0, 2, 2}),
+		},
+		[]ResourceRecord{*NewResourceRecord("example.com", TypeNS, ClassIN, 3600, uint16(len(ns)), []byte(ns))},
+		[]ResourceRecord{*NewResourceRecord("ns.example.com", TypeA, ClassIN, 3600, 4, []byte{192, 0, 2, 53})},
+	)
+	return message.ToBytes()
+}
+
+func TestParser(t *testing.T) {
+	t.Run("Should read the entries of every section in order", func(t *testing.T) {
+		msg := parserTestMessage()
+		var p Parser
+		header, err := p.Start(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(7), header.ID)
+		assert.Equal(t, uint16(2), header.ANCount)
+
+		question, err := p.Question()
+		assert.NoError(t, err)
+		assert.Equal(t, headerLength, question.Name.Offset)
+		assert.Equal(t, uint16(TypeA), question.Type)
+		name, err := question.Name.Decode()
+		assert.NoError(t, err)
+		assert.Equal(t, "www.example.com", name)
+		_, err = p.Question()
+		assert.ErrorIs(t, err, ErrSectionDone)
+
+		var addresses [][]byte
+		for {
+			record, err := p.Record(SectionAnswer)
+			if errors.Is(err, ErrSectionDone) {
+				break
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(300), record.TTL)
+			assert.Equal(t, msg[record.DataOffset:record.DataOffset+4], record.Data)
+			addresses = append(addresses, record.Data)
+		}
+		assert.Equal(t, [][]byte{{192, 0, 2, 1}, {192, 0, 2, 2}}, addresses)
+
+		authority, err := p.Record(SectionAuthority)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(TypeNS), authority.Type)
+		target, _, err := DecodeNameAt(msg, authority.DataOffset)
+		assert.NoError(t, err)
+		assert.Equal(t, "ns.example.com", target)
+
+		additional, err := p.Record(SectionAdditional)
+		assert.NoError(t, err)
+		rr, err := additional.Record()
+		assert.NoError(t, err)
+		assert.Equal(t, "ns.example.com", rr.Name)
+		assert.Equal(t, "192.0.2.53", rr.RDataParsed)
+		_, err = p.Record(SectionAdditional)
+		assert.ErrorIs(t, err, ErrSectionDone)
+		_, err = p.Record(SectionAnswer)
+		assert.ErrorIs(t, err, ErrSectionDone, "the Parser does not go back")
+	})
+
+	t.Run("Should skip the sections that are not read", func(t *testing.T) {
+		var p Parser
+		_, err := p.Start(parserTestMessage())
+		assert.NoError(t, err)
+		record, err := p.Record(SectionAdditional)
+		assert.NoError(t, err)
+		name, err := record.Name.AppendDecoded(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "ns.example.com", string(name))
+		_, err = p.Question()
+		assert.ErrorIs(t, err, ErrSectionDone)
+	})
+
+	t.Run("Should read the messages as ParseMessage does", func(t *testing.T) {
+		msg := parserTestMessage()
+		message, err := ParseMessage(msg)
+		assert.NoError(t, err)
+
+		var p Parser
+		_, err = p.Start(msg)
+		assert.NoError(t, err)
+		raw, err := p.Question()
+		assert.NoError(t, err)
+		question, err := raw.Question()
+		assert.NoError(t, err)
+		assert.Equal(t, message.Questions[0], *question)
+		for i, expected := range message.Answers {
+			raw, err := p.Record(SectionAnswer)
+			assert.NoError(t, err)
+			rr, err := raw.Record()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, *rr, i)
+		}
+	})
+
+	t.Run("Should report the section and the offset of the entries that are not valid", func(t *testing.T) {
+		msg := parserTestMessage()
+		var p Parser
+		_, err := p.Start(msg[:len(msg)-2])
+		assert.NoError(t, err)
+		_, err = p.Record(SectionAdditional)
+		var parseErr *ParseError
+		assert.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, "additional", parseErr.Section)
+		assert.Equal(t, 0, parseErr.Index)
+		assert.ErrorIs(t, err, ErrTruncated)
+		_, again := p.Record(SectionAdditional)
+		assert.Equal(t, err, again, "the Parser stops at the first error")
+
+		_, err = p.Start(msg[:headerLength-1])
+		assert.ErrorIs(t, err, ErrShortHeader)
+		_, err = p.Record(SectionAnswer)
+		assert.ErrorIs(t, err, ErrShortHeader)
+		_, err = p.Record(SectionQuestion)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should walk a message without allocating", func(t *testing.T) {
+		msg := parserTestMessage()
+		var p Parser
+		name := make([]byte, 0, 256)
+		allocs := testing.AllocsPerRun(100, func() {
+			walkMessage(&p, msg, name)
+		})
+		assert.Equal(t, 0.0, allocs)
+	})
+}
+
+// walkMessage reads every entry of the message and decodes their names into the buffer.
+func walkMessage(p *Parser, msg, name []byte) {
+	if _, err := p.Start(msg); err != nil {
+		panic(err)
+	}
+	for {
+		q, err := p.Question()
+		if err != nil {
+			break
+		}
+		_, _ = q.Name.AppendDecoded(name[:0])
+	}
+	for section := SectionAnswer; section <= SectionAdditional; section++ {
+		for {
+			r, err := p.Record(section)
+			if err != nil {
+				break
+			}
+			_, _ = r.Name.AppendDecoded(name[:0])
+		}
+	}
+}
+
+func BenchmarkParser(b *testing.B) {
+	msg := parserTestMessage()
+	var p Parser
+	name := make([]byte, 0, 256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		walkMessage(&p, msg, name)
+	}
+}
+
+func BenchmarkParseMessage(b *testing.B) {
+	msg := parserTestMessage()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseMessage(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"strings"
 )
 
 // Question represents a DNS question.
@@ -20,77 +19,58 @@ type Question struct {
 }
 
 // NewQuestion creates a new Question instance with the specified parameters.
+// The internationalized labels of the name are converted to A-labels, see ToASCII. If the name can not be
+// converted, it is kept as is: names should be checked with ToASCII first.
 func NewQuestion(name string, qType, qClass uint16) *Question {
 	q := &Question{
-		Name:   name,
 		QType:  qType,
 		QClass: qClass,
 	}
-	q.QName = encodeName(name)
+	q.SetName(name)
 	return q
 }
 
 // SetName sets the domain name of the Question and updates the converted domain name.
+// The internationalized labels of the name are converted to A-labels as by NewQuestion.
+// The converted domain name is empty if the name is not valid, see NewName.
 func (q *Question) SetName(name string) {
+	if ascii, err := ToASCII(name); err == nil {
+		name = ascii
+	}
 	q.Name = name
 	q.QName = encodeName(name)
 }
 
-// encodeName encodes the domain name to the format specified in RFC 1035.
+// encodeName encodes the domain name, written in its presentation format, to the format specified in RFC 1035.
+// It returns an empty string if the name is not valid.
 func encodeName(name string) string {
-	domainParts := strings.Split(name, ".")
-	qname := ""
-	for _, part := range domainParts {
-		newDomainPart := string(byte(len(part))) + part
-		qname += newDomainPart
+	encoded, err := appendName(nil, name, nil)
+	if err != nil {
+		return ""
 	}
-	return qname + "\x00"
+	return string(encoded)
 }
 
 // DecodeName decodes the encoded domain name to its original format.
+// If the encoded name contains compression pointers, the message it was read from must be given
+// so that the pointers can be followed. See DecodeNameAt for decoding a name in place.
 func DecodeName(qname string, messageBufs ...*bytes.Buffer) (string, error) {
-	encoded := []byte(qname)
-	var result bytes.Buffer
-	var messageBuf *bytes.Buffer
-	if messageBufs != nil {
-		messageBuf = messageBufs[0]
-	}
+	msg, offset := detachedData([]byte(qname), messageBufs...)
+	name, _, err := DecodeNameAt(msg, offset)
+	return name, err
+}
 
-	for i := 0; i < len(encoded); {
-		length := int(encoded[i])
-		if length == 0 {
-			break
-		}
-		if encoded[i]>>6 == 0b11 && messageBuf != nil {
-			// Check if the name is a pointer. Parse the pointer, get the offset and parse the name from the offset.
-			// See https://datatracker.ietf.org/doc/html/rfc1035#section-4.1.4 for more information
-			b := encoded[i+1]
-			offset := int(b & 0b11111111)
-			messageBytes := messageBuf.Bytes()
-			messageBytes = messageBytes[offset:]
-			name := appendFromBufferUntilNull(bytes.NewBuffer(messageBytes))
-			n, _ := DecodeName(string(name))
-			name = []byte(n)
-			length = len(name)
-			if result.Len() > 0 {
-				result.WriteByte('.')
-			}
-			result.Write(name)
-			i += length
-			break
-		}
-		i++
-		if i+length > len(encoded) {
-			return "", fmt.Errorf("invalid encoded domain name")
-		}
-		if result.Len() > 0 {
-			result.WriteByte('.')
-		}
-		result.Write(encoded[i : i+length])
-		i += length
+// detachedData returns a buffer containing the message followed by data and the offset of data in it.
+// It allows data that was cut out of a message, but still holds compression pointers into it,
+// to be decoded with the same functions that work on a full message.
+func detachedData(data []byte, messageBufs ...*bytes.Buffer) ([]byte, int) {
+	if len(messageBufs) == 0 || messageBufs[0] == nil {
+		return data, 0
 	}
-
-	return result.String(), nil
+	message := messageBufs[0].Bytes()
+	msg := make([]byte, 0, len(message)+len(data))
+	msg = append(msg, message...)
+	return append(msg, data...), len(message)
 }
 
 // ToBytes converts the Question to its byte representation.
@@ -104,17 +84,44 @@ func (q *Question) ToBytes() []byte {
 	return buf.Bytes()
 }
 
+// pack appends the Question to msg, which holds the message written so far.
+// If comp is not nil, the domain name is compressed against it.
+func (q *Question) pack(msg []byte, comp compressionMap) ([]byte, error) {
+	msg, err := appendName(msg, q.Name, comp)
+	if err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, q.QType)
+	return binary.BigEndian.AppendUint16(msg, q.QClass), nil
+}
+
 // QuestionFromBytes creates a Question instance from its byte representation.
 func QuestionFromBytes(b []byte) *Question {
-	length := len(b)
-	qname := string(b[:length-4])
+	q, _, _ := unpackQuestion(b, 0)
+	return q
+}
 
-	name, _ := DecodeName(qname)
+// unpackQuestion decodes the Question starting at the given offset of the full message msg.
+// It returns the Question and the offset of the first byte following it.
+func unpackQuestion(msg []byte, offset int) (*Question, int, error) {
+	name, off, err := DecodeNameAt(msg, offset)
+	if err != nil {
+		return &Question{}, offset, err
+	}
+	if off+4 > len(msg) {
+		return &Question{}, offset, fmt.Errorf("%w: question type and class at offset %d", ErrTruncated, off)
+	}
 
 	return &Question{
 		Name:   name,
-		QName:  qname,
-		QType:  binary.BigEndian.Uint16(b[length-4 : length-2]),
-		QClass: binary.BigEndian.Uint16(b[length-2:]),
-	}
+		QName:  encodeName(name),
+		QType:  binary.BigEndian.Uint16(msg[off : off+2]),
+		QClass: binary.BigEndian.Uint16(msg[off+2 : off+4]),
+	}, off + 4, nil
+}
+
+// String returns the question as printed by dig in the question section: the name, class and type,
+// commented out with a semicolon.
+func (q *Question) String() string {
+	return fmt.Sprintf(";%s\t\t%s\t%s", escapeZoneName(fqdn(q.Name)), ClassToString(q.QClass), RTypeToString(q.QType))
 }
@@ -59,4 +59,9 @@ func TestQuestion(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, expected, result)
 	})
+
+	t.Run("Should print a question as dig does", func(t *testing.T) {
+		question := NewQuestion("dns.google.com", TypeAAAA, ClassIN)
+		assert.Equal(t, ";dns.google.com.\t\tIN\tAAAA", question.String())
+	})
 }
@@ -0,0 +1,748 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// RData is the typed resource data of a resource record.
+// Every supported record type has a concrete RData type that knows how to pack, unpack, compare and print itself.
+// Record types that are not supported are represented by Unknown, which keeps the raw resource data.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1035#section-3.3 for more information
+type RData interface {
+	// Type returns the resource record type the data belongs to.
+	Type() uint16
+	// String returns the presentation format of the data, as used in zone files.
+	String() string
+	// Equal reports whether the data is equal to other. Domain names are compared case-insensitively.
+	Equal(other RData) bool
+
+	// pack appends the wire format of the data to msg, which holds the message written so far.
+	// If comp is not nil and the type allows it, the domain names are compressed against it.
+	pack(msg []byte, comp compressionMap) ([]byte, error)
+	// unpack decodes the data found between off and end of the full message msg.
+	unpack(msg []byte, off, end int) error
+	// parse decodes the presentation format of the data, split in fields as found in zone files.
+	// Relative domain names are completed with origin.
+	parse(tokens []zoneToken, origin string) error
+}
+
+// newRData returns an empty RData of the given type, or an Unknown if the type is not supported.
+func newRData(rType uint16) RData {
+	switch rType {
+	case TypeA:
+		return &A{}
+	case TypeAAAA:
+		return &AAAA{}
+	case TypeNS:
+		return &NS{}
+	case TypeCNAME:
+		return &CNAME{}
+	case TypeMX:
+		return &MX{}
+	case TypeSOA:
+		return &SOA{}
+	case TypeSRV:
+		return &SRV{}
+	case TypePTR:
+		return &PTR{}
+	case TypeTXT:
+		return &TXT{}
+	case TypeHINFO:
+		return &HINFO{}
+	case TypeLOC:
+		return &LOC{}
+	case TypeNAPTR:
+		return &NAPTR{}
+	case TypeDNAME:
+		return &DNAME{}
+	case TypeOPT:
+		return &OPT{}
+	case TypeDS:
+		return &DS{}
+	case TypeRRSIG:
+		return &RRSIG{}
+	case TypeNSEC:
+		return &NSEC{}
+	case TypeSSHFP:
+		return &SSHFP{}
+	case TypeDNSKEY:
+		return &DNSKEY{}
+	case TypeNSEC3:
+		return &NSEC3{}
+	case TypeNSEC3PARAM:
+		return &NSEC3PARAM{}
+	case TypeTLSA:
+		return &TLSA{}
+	case TypeSVCB:
+		return &SVCB{}
+	case TypeHTTPS:
+		return &HTTPS{}
+	case TypeURI:
+		return &URI{}
+	case TypeCAA:
+		return &CAA{}
+	case TypeTSIG:
+		return &TSIG{}
+	default:
+		return &Unknown{RRType: rType}
+	}
+}
+
+// PackRData returns the uncompressed wire format of the resource data.
+func PackRData(data RData) ([]byte, error) {
+	return data.pack(nil, nil)
+}
+
+// UnpackRData decodes the uncompressed wire format of the resource data of the given type.
+func UnpackRData(rType uint16, rData []byte) (RData, error) {
+	data := newRData(rType)
+	if err := data.unpack(rData, 0, len(rData)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// fqdn returns the domain name in its fully qualified presentation format, ending with a dot.
+func fqdn(name string) string {
+	if isFQDN(name) {
+		return name
+	}
+	return name + "."
+}
+
+// equalNames reports whether the two domain names are equal, ignoring the case and the trailing dot.
+func equalNames(a, b string) bool {
+	return strings.EqualFold(fqdn(a), fqdn(b))
+}
+
+// unpackRDataName decodes the domain name starting at off, which must end at or before end.
+// It returns the name and the offset following it.
+func unpackRDataName(msg []byte, off, end int) (string, int, error) {
+	name, next, err := DecodeNameAt(msg, off)
+	if err != nil {
+		return "", 0, err
+	}
+	if next > end {
+		return "", 0, fmt.Errorf("domain name exceeds the resource data")
+	}
+	return name, next, nil
+}
+
+// rdataLengthError returns the error reported for resource data of an invalid length.
+func rdataLengthError(rType uint16, length int) error {
+	return fmt.Errorf("invalid %s record length: %d", RTypeToString(rType), length)
+}
+
+// A is the resource data of an A record, an IPv4 address.
+type A struct {
+	Address net.IP
+}
+
+// Type returns TypeA.
+func (rd *A) Type() uint16 { return TypeA }
+
+// String returns the address in dotted decimal notation.
+func (rd *A) String() string { return rd.Address.String() }
+
+// Equal reports whether other is an A record with the same address.
+func (rd *A) Equal(other RData) bool {
+	o, ok := other.(*A)
+	return ok && rd.Address.Equal(o.Address)
+}
+
+func (rd *A) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	ip := rd.Address.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IPv4 address: %s", rd.Address)
+	}
+	return append(msg, ip...), nil
+}
+
+func (rd *A) unpack(msg []byte, off, end int) error {
+	if end-off != net.IPv4len {
+		return rdataLengthError(TypeA, end-off)
+	}
+	rd.Address = net.IP(bytes.Clone(msg[off:end]))
+	return nil
+}
+
+func (rd *A) parse(tokens []zoneToken, _ string) error {
+	if err := expectTokens(tokens, 1); err != nil {
+		return err
+	}
+	ip := net.ParseIP(tokens[0].text).To4()
+	if ip == nil || strings.Contains(tokens[0].text, ":") {
+		return fmt.Errorf("invalid IPv4 address: %s", tokens[0].text)
+	}
+	rd.Address = ip
+	return nil
+}
+
+// AAAA is the resource data of an AAAA record, an IPv6 address.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3596 for more information
+type AAAA struct {
+	Address net.IP
+}
+
+// Type returns TypeAAAA.
+func (rd *AAAA) Type() uint16 { return TypeAAAA }
+
+// String returns the address in its canonical text representation.
+func (rd *AAAA) String() string { return rd.Address.String() }
+
+// Equal reports whether other is an AAAA record with the same address.
+func (rd *AAAA) Equal(other RData) bool {
+	o, ok := other.(*AAAA)
+	return ok && rd.Address.Equal(o.Address)
+}
+
+func (rd *AAAA) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	ip := rd.Address.To16()
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IPv6 address: %s", rd.Address)
+	}
+	return append(msg, ip...), nil
+}
+
+func (rd *AAAA) unpack(msg []byte, off, end int) error {
+	if end-off != net.IPv6len {
+		return rdataLengthError(TypeAAAA, end-off)
+	}
+	rd.Address = net.IP(bytes.Clone(msg[off:end]))
+	return nil
+}
+
+func (rd *AAAA) parse(tokens []zoneToken, _ string) error {
+	if err := expectTokens(tokens, 1); err != nil {
+		return err
+	}
+	ip := net.ParseIP(tokens[0].text)
+	if ip == nil || !strings.Contains(tokens[0].text, ":") {
+		return fmt.Errorf("invalid IPv6 address: %s", tokens[0].text)
+	}
+	rd.Address = ip
+	return nil
+}
+
+// NS is the resource data of an NS record, the host name of an authoritative name server.
+type NS struct {
+	Host string
+}
+
+// Type returns TypeNS.
+func (rd *NS) Type() uint16 { return TypeNS }
+
+// String returns the fully qualified host name.
+func (rd *NS) String() string { return fqdn(rd.Host) }
+
+// Equal reports whether other is an NS record with the same host name.
+func (rd *NS) Equal(other RData) bool {
+	o, ok := other.(*NS)
+	return ok && equalNames(rd.Host, o.Host)
+}
+
+func (rd *NS) pack(msg []byte, comp compressionMap) ([]byte, error) {
+	return appendName(msg, rd.Host, comp)
+}
+
+func (rd *NS) unpack(msg []byte, off, end int) error {
+	if end-off == 0 {
+		return rdataLengthError(TypeNS, end-off)
+	}
+	host, _, err := unpackRDataName(msg, off, end)
+	rd.Host = host
+	return err
+}
+
+func (rd *NS) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 1); err != nil {
+		return err
+	}
+	host, err := parseZoneName(tokens[0].text, origin)
+	rd.Host = host
+	return err
+}
+
+// CNAME is the resource data of a CNAME record, the canonical name of an alias.
+type CNAME struct {
+	Target string
+}
+
+// Type returns TypeCNAME.
+func (rd *CNAME) Type() uint16 { return TypeCNAME }
+
+// String returns the fully qualified canonical name.
+func (rd *CNAME) String() string { return fqdn(rd.Target) }
+
+// Equal reports whether other is a CNAME record with the same canonical name.
+func (rd *CNAME) Equal(other RData) bool {
+	o, ok := other.(*CNAME)
+	return ok && equalNames(rd.Target, o.Target)
+}
+
+func (rd *CNAME) pack(msg []byte, comp compressionMap) ([]byte, error) {
+	return appendName(msg, rd.Target, comp)
+}
+
+func (rd *CNAME) unpack(msg []byte, off, end int) error {
+	if end-off == 0 {
+		return rdataLengthError(TypeCNAME, end-off)
+	}
+	target, _, err := unpackRDataName(msg, off, end)
+	rd.Target = target
+	return err
+}
+
+func (rd *CNAME) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 1); err != nil {
+		return err
+	}
+	target, err := parseZoneName(tokens[0].text, origin)
+	rd.Target = target
+	return err
+}
+
+// MX is the resource data of an MX record, a mail exchange and its preference.
+type MX struct {
+	Preference uint16
+	Exchange   string
+}
+
+// Type returns TypeMX.
+func (rd *MX) Type() uint16 { return TypeMX }
+
+// String returns the preference followed by the fully qualified mail exchange.
+func (rd *MX) String() string { return fmt.Sprintf("%d %s", rd.Preference, fqdn(rd.Exchange)) }
+
+// Equal reports whether other is an MX record with the same preference and mail exchange.
+func (rd *MX) Equal(other RData) bool {
+	o, ok := other.(*MX)
+	return ok && rd.Preference == o.Preference && equalNames(rd.Exchange, o.Exchange)
+}
+
+func (rd *MX) pack(msg []byte, comp compressionMap) ([]byte, error) {
+	msg = binary.BigEndian.AppendUint16(msg, rd.Preference)
+	return appendName(msg, rd.Exchange, comp)
+}
+
+func (rd *MX) unpack(msg []byte, off, end int) error {
+	if end-off < 3 {
+		return rdataLengthError(TypeMX, end-off)
+	}
+	rd.Preference = binary.BigEndian.Uint16(msg[off : off+2])
+	exchange, _, err := unpackRDataName(msg, off+2, end)
+	rd.Exchange = exchange
+	return err
+}
+
+func (rd *MX) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 2); err != nil {
+		return err
+	}
+	preference, err := parseUint(tokens[0], 16)
+	if err != nil {
+		return err
+	}
+	rd.Preference = uint16(preference)
+	rd.Exchange, err = parseZoneName(tokens[1].text, origin)
+	return err
+}
+
+// SOA is the resource data of an SOA record, which marks the start of a zone of authority.
+type SOA struct {
+	MName   string // MName is the name server that was the primary source of data for the zone.
+	RName   string // RName is the mailbox of the person responsible for the zone.
+	Serial  uint32
+	Refresh uint32
+	Retry   uint32
+	Expire  uint32
+	Minimum uint32
+}
+
+// Type returns TypeSOA.
+func (rd *SOA) Type() uint16 { return TypeSOA }
+
+// String returns the fully qualified names followed by the timers of the zone.
+func (rd *SOA) String() string {
+	return fmt.Sprintf("%s %s %d %d %d %d %d", fqdn(rd.MName), fqdn(rd.RName), rd.Serial, rd.Refresh, rd.Retry, rd.Expire, rd.Minimum)
+}
+
+// Equal reports whether other is an SOA record with the same names and timers.
+func (rd *SOA) Equal(other RData) bool {
+	o, ok := other.(*SOA)
+	return ok && equalNames(rd.MName, o.MName) && equalNames(rd.RName, o.RName) &&
+		rd.Serial == o.Serial && rd.Refresh == o.Refresh && rd.Retry == o.Retry &&
+		rd.Expire == o.Expire && rd.Minimum == o.Minimum
+}
+
+func (rd *SOA) pack(msg []byte, comp compressionMap) ([]byte, error) {
+	msg, err := appendName(msg, rd.MName, comp)
+	if err != nil {
+		return nil, err
+	}
+	if msg, err = appendName(msg, rd.RName, comp); err != nil {
+		return nil, err
+	}
+	for _, v := range []uint32{rd.Serial, rd.Refresh, rd.Retry, rd.Expire, rd.Minimum} {
+		msg = binary.BigEndian.AppendUint32(msg, v)
+	}
+	return msg, nil
+}
+
+func (rd *SOA) unpack(msg []byte, off, end int) error {
+	if end-off < 22 {
+		return rdataLengthError(TypeSOA, end-off)
+	}
+	mname, off, err := unpackRDataName(msg, off, end)
+	if err != nil {
+		return err
+	}
+	rname, off, err := unpackRDataName(msg, off, end)
+	if err != nil {
+		return err
+	}
+	if end-off != 20 {
+		return rdataLengthError(TypeSOA, end-off)
+	}
+
+	rd.MName = mname
+	rd.RName = rname
+	rd.Serial = binary.BigEndian.Uint32(msg[off : off+4])
+	rd.Refresh = binary.BigEndian.Uint32(msg[off+4 : off+8])
+	rd.Retry = binary.BigEndian.Uint32(msg[off+8 : off+12])
+	rd.Expire = binary.BigEndian.Uint32(msg[off+12 : off+16])
+	rd.Minimum = binary.BigEndian.Uint32(msg[off+16 : off+20])
+	return nil
+}
+
+// parse accepts the timers in seconds or with units, such as 1h30m.
+func (rd *SOA) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 7); err != nil {
+		return err
+	}
+	var err error
+	if rd.MName, err = parseZoneName(tokens[0].text, origin); err != nil {
+		return err
+	}
+	if rd.RName, err = parseZoneName(tokens[1].text, origin); err != nil {
+		return err
+	}
+	serial, err := parseUint(tokens[2], 32)
+	if err != nil {
+		return err
+	}
+	rd.Serial = uint32(serial)
+	for i, timer := range []*uint32{&rd.Refresh, &rd.Retry, &rd.Expire, &rd.Minimum} {
+		if *timer, err = parseTTL(tokens[3+i].text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SRV is the resource data of an SRV record, the location of a service.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2782 for more information
+type SRV struct {
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	Target   string
+}
+
+// Type returns TypeSRV.
+func (rd *SRV) Type() uint16 { return TypeSRV }
+
+// String returns the priority, weight and port followed by the fully qualified target.
+func (rd *SRV) String() string {
+	return fmt.Sprintf("%d %d %d %s", rd.Priority, rd.Weight, rd.Port, fqdn(rd.Target))
+}
+
+// Equal reports whether other is an SRV record with the same fields.
+func (rd *SRV) Equal(other RData) bool {
+	o, ok := other.(*SRV)
+	return ok && rd.Priority == o.Priority && rd.Weight == o.Weight && rd.Port == o.Port && equalNames(rd.Target, o.Target)
+}
+
+// pack never compresses the target, as required by RFC 2782.
+func (rd *SRV) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg = binary.BigEndian.AppendUint16(msg, rd.Priority)
+	msg = binary.BigEndian.AppendUint16(msg, rd.Weight)
+	msg = binary.BigEndian.AppendUint16(msg, rd.Port)
+	return appendName(msg, rd.Target, nil)
+}
+
+// unpack accepts a compressed target, as some servers compress it anyway.
+func (rd *SRV) unpack(msg []byte, off, end int) error {
+	if end-off < 7 {
+		return rdataLengthError(TypeSRV, end-off)
+	}
+	rd.Priority = binary.BigEndian.Uint16(msg[off : off+2])
+	rd.Weight = binary.BigEndian.Uint16(msg[off+2 : off+4])
+	rd.Port = binary.BigEndian.Uint16(msg[off+4 : off+6])
+	target, _, err := unpackRDataName(msg, off+6, end)
+	rd.Target = target
+	return err
+}
+
+func (rd *SRV) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 4); err != nil {
+		return err
+	}
+	for i, field := range []*uint16{&rd.Priority, &rd.Weight, &rd.Port} {
+		value, err := parseUint(tokens[i], 16)
+		if err != nil {
+			return err
+		}
+		*field = uint16(value)
+	}
+	target, err := parseZoneName(tokens[3].text, origin)
+	rd.Target = target
+	return err
+}
+
+// PTR is the resource data of a PTR record, the domain name an address or a name points to.
+// It is mostly used for reverse lookups in the in-addr.arpa and ip6.arpa domains.
+type PTR struct {
+	Target string
+}
+
+// Type returns TypePTR.
+func (rd *PTR) Type() uint16 { return TypePTR }
+
+// String returns the fully qualified domain name pointed to.
+func (rd *PTR) String() string { return fqdn(rd.Target) }
+
+// Equal reports whether other is a PTR record pointing to the same domain name.
+func (rd *PTR) Equal(other RData) bool {
+	o, ok := other.(*PTR)
+	return ok && equalNames(rd.Target, o.Target)
+}
+
+func (rd *PTR) pack(msg []byte, comp compressionMap) ([]byte, error) {
+	return appendName(msg, rd.Target, comp)
+}
+
+func (rd *PTR) unpack(msg []byte, off, end int) error {
+	if end-off == 0 {
+		return rdataLengthError(TypePTR, end-off)
+	}
+	target, _, err := unpackRDataName(msg, off, end)
+	rd.Target = target
+	return err
+}
+
+func (rd *PTR) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 1); err != nil {
+		return err
+	}
+	target, err := parseZoneName(tokens[0].text, origin)
+	rd.Target = target
+	return err
+}
+
+// maxCharacterStringLength is the maximum length of a character-string, whose length is stored in a single octet.
+const maxCharacterStringLength = 255
+
+// TXT is the resource data of a TXT record, a list of character-strings.
+// The strings may hold any byte, they are not required to be valid UTF-8.
+type TXT struct {
+	Strings []string
+}
+
+// Type returns TypeTXT.
+func (rd *TXT) Type() uint16 { return TypeTXT }
+
+// String returns the character-strings quoted and separated by spaces.
+// Quotes and backslashes are escaped with a backslash and non-printable bytes are written as \DDD.
+func (rd *TXT) String() string {
+	if len(rd.Strings) == 0 {
+		return `""`
+	}
+	quoted := make([]string, len(rd.Strings))
+	for i, str := range rd.Strings {
+		quoted[i] = quoteCharacterString(str)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Text returns the concatenation of the character-strings.
+// Long values such as SPF policies are split over several strings and must be read this way.
+func (rd *TXT) Text() string {
+	return strings.Join(rd.Strings, "")
+}
+
+// Equal reports whether other is a TXT record with the same character-strings.
+func (rd *TXT) Equal(other RData) bool {
+	o, ok := other.(*TXT)
+	if !ok || len(rd.Strings) != len(o.Strings) {
+		return false
+	}
+	for i := range rd.Strings {
+		if rd.Strings[i] != o.Strings[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (rd *TXT) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	for _, str := range rd.Strings {
+		if len(str) > maxCharacterStringLength {
+			return nil, fmt.Errorf("character-string is longer than %d bytes: %d", maxCharacterStringLength, len(str))
+		}
+	}
+	for _, str := range rd.Strings {
+		msg = append(msg, byte(len(str)))
+		msg = append(msg, str...)
+	}
+	return msg, nil
+}
+
+func (rd *TXT) unpack(msg []byte, off, end int) error {
+	rd.Strings = make([]string, 0)
+	for off < end {
+		length := int(msg[off])
+		if off+1+length > end {
+			return fmt.Errorf("character-string exceeds the resource data")
+		}
+		rd.Strings = append(rd.Strings, string(msg[off+1:off+1+length]))
+		off += 1 + length
+	}
+	return nil
+}
+
+// parse accepts quoted and unquoted character-strings.
+func (rd *TXT) parse(tokens []zoneToken, _ string) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("missing character-string")
+	}
+	rd.Strings = make([]string, len(tokens))
+	for i, token := range tokens {
+		str, err := unescapeCharacterString(token.text)
+		if err != nil {
+			return err
+		}
+		if len(str) > maxCharacterStringLength {
+			return fmt.Errorf("character-string is longer than %d bytes: %d", maxCharacterStringLength, len(str))
+		}
+		rd.Strings[i] = str
+	}
+	return nil
+}
+
+// quoteCharacterString returns the presentation format of a character-string, enclosed in quotes.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1035#section-5.1 for more information
+func quoteCharacterString(str string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// HINFO is the resource data of an HINFO record, the CPU and operating system of a host.
+// It is also the record of RFC 8482 that servers answer to ANY queries with, the CPU being "RFC8482".
+type HINFO struct {
+	CPU string
+	OS  string
+}
+
+// Type returns TypeHINFO.
+func (rd *HINFO) Type() uint16 { return TypeHINFO }
+
+// String returns the CPU and the operating system as quoted character-strings.
+func (rd *HINFO) String() string {
+	return quoteCharacterString(rd.CPU) + " " + quoteCharacterString(rd.OS)
+}
+
+// Equal reports whether other is an HINFO record with the same CPU and operating system.
+func (rd *HINFO) Equal(other RData) bool {
+	o, ok := other.(*HINFO)
+	return ok && rd.CPU == o.CPU && rd.OS == o.OS
+}
+
+func (rd *HINFO) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	return (&TXT{Strings: []string{rd.CPU, rd.OS}}).pack(msg, nil)
+}
+
+func (rd *HINFO) unpack(msg []byte, off, end int) error {
+	txt := &TXT{}
+	if err := txt.unpack(msg, off, end); err != nil {
+		return err
+	}
+	if len(txt.Strings) != 2 {
+		return fmt.Errorf("expected 2 character-strings in HINFO record, got %d", len(txt.Strings))
+	}
+	rd.CPU, rd.OS = txt.Strings[0], txt.Strings[1]
+	return nil
+}
+
+func (rd *HINFO) parse(tokens []zoneToken, origin string) error {
+	if err := expectTokens(tokens, 2); err != nil {
+		return err
+	}
+	txt := &TXT{}
+	if err := txt.parse(tokens, origin); err != nil {
+		return err
+	}
+	rd.CPU, rd.OS = txt.Strings[0], txt.Strings[1]
+	return nil
+}
+
+// Unknown is the resource data of a record type that is not supported.
+// The raw resource data is kept as is, so that the record can be written back unchanged.
+type Unknown struct {
+	RRType uint16
+	Data   []byte
+}
+
+// Type returns the type of the record the data was read from.
+func (rd *Unknown) Type() uint16 { return rd.RRType }
+
+// String returns the data in the generic format of RFC 3597: \# followed by the length and the hex encoded data.
+func (rd *Unknown) String() string {
+	if len(rd.Data) == 0 {
+		return `\# 0`
+	}
+	return fmt.Sprintf(`\# %d %s`, len(rd.Data), hex.EncodeToString(rd.Data))
+}
+
+// Equal reports whether other is an Unknown of the same type with the same data.
+func (rd *Unknown) Equal(other RData) bool {
+	o, ok := other.(*Unknown)
+	return ok && rd.RRType == o.RRType && bytes.Equal(rd.Data, o.Data)
+}
+
+func (rd *Unknown) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	return append(msg, rd.Data...), nil
+}
+
+func (rd *Unknown) unpack(msg []byte, off, end int) error {
+	rd.Data = bytes.Clone(msg[off:end])
+	return nil
+}
+
+// parse always fails: the data of types that are not supported can only be given in the \# format.
+func (rd *Unknown) parse(_ []zoneToken, _ string) error {
+	return fmt.Errorf(`the resource data of type %d must use the \# format`, rd.RRType)
+}
@@ -0,0 +1,108 @@
+package dns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRData(t *testing.T) {
+	t.Run("Should pack and unpack every supported type", func(t *testing.T) {
+		records := []RData{
+			&A{Address: net.ParseIP("8.8.8.8").To4()},
+			&AAAA{Address: net.ParseIP("2001:4860:4860::8888")},
+			&NS{Host: "ns1.google.com"},
+			&CNAME{Target: "google.com"},
+			&MX{Preference: 10, Exchange: "smtp.google.com"},
+			&SOA{MName: "ns1.google.com", RName: "dns-admin.google.com", Serial: 1, Refresh: 900, Retry: 900, Expire: 1800, Minimum: 60},
+			&SRV{Priority: 1, Weight: 2, Port: 5060, Target: "sip.google.com"},
+			&PTR{Target: "dns.google"},
+			&TXT{Strings: []string{"v=spf1 include:_spf.google.com", "~all"}},
+			&TXT{Strings: []string{"\x00\xff binary", ""}},
+			&SVCB{Priority: 0, Target: "svc.google.com"},
+			&HTTPS{SVCB{Priority: 1, Params: []SvcParam{&ALPNParam{Protocols: []string{"h2", "h3"}}, &PortParam{Port: 443}}}},
+			&HINFO{CPU: "RFC8482", OS: ""},
+			&Unknown{RRType: 99, Data: []byte{1, 2, 3}},
+		}
+		for _, record := range records {
+			rData, err := PackRData(record)
+			assert.NoError(t, err)
+			unpacked, err := UnpackRData(record.Type(), rData)
+			assert.NoError(t, err)
+			assert.True(t, record.Equal(unpacked), "%s did not survive a round trip", RTypeToString(record.Type()))
+		}
+	})
+
+	t.Run("Should print the presentation format", func(t *testing.T) {
+		assert.Equal(t, "8.8.8.8", (&A{Address: net.IPv4(8, 8, 8, 8)}).String())
+		assert.Equal(t, "2001:4860:4860::8888", (&AAAA{Address: net.ParseIP("2001:4860:4860::8888")}).String())
+		assert.Equal(t, "ns1.google.com.", (&NS{Host: "ns1.google.com"}).String())
+		assert.Equal(t, "10 smtp.google.com.", (&MX{Preference: 10, Exchange: "smtp.google.com"}).String())
+		assert.Equal(t, "1 2 5060 sip.google.com.", (&SRV{Priority: 1, Weight: 2, Port: 5060, Target: "sip.google.com."}).String())
+		assert.Equal(t, `"INTEL-386" "Linux"`, (&HINFO{CPU: "INTEL-386", OS: "Linux"}).String())
+		assert.Equal(t, `\# 3 010203`, (&Unknown{RRType: 99, Data: []byte{1, 2, 3}}).String())
+	})
+
+	t.Run("Should compare the domain names case-insensitively", func(t *testing.T) {
+		assert.True(t, (&CNAME{Target: "Google.COM"}).Equal(&CNAME{Target: "google.com."}))
+		assert.False(t, (&CNAME{Target: "google.com"}).Equal(&NS{Host: "google.com"}))
+		assert.False(t, (&MX{Preference: 10, Exchange: "google.com"}).Equal(&MX{Preference: 20, Exchange: "google.com"}))
+	})
+
+	t.Run("Should check whether a domain name is below another", func(t *testing.T) {
+		assert.True(t, IsSubdomain("www.Example.com", "example.COM."))
+		assert.True(t, IsSubdomain("example.com.", "example.com"))
+		assert.True(t, IsSubdomain("example.com", ""))
+		assert.False(t, IsSubdomain("badexample.com", "example.com"))
+		assert.False(t, IsSubdomain("example.com", "www.example.com"))
+	})
+
+	t.Run("Should reject resource data of an invalid length", func(t *testing.T) {
+		_, err := UnpackRData(TypeA, []byte{8, 8, 8})
+		assert.Error(t, err)
+		_, err = UnpackRData(TypeAAAA, []byte{8, 8, 8, 8})
+		assert.Error(t, err)
+		_, err = UnpackRData(TypeMX, []byte{0, 10})
+		assert.Error(t, err)
+		_, err = UnpackRData(TypeHINFO, []byte{3, 'x', '8', '6'})
+		assert.Error(t, err, "the operating system is missing")
+		_, err = PackRData(&A{Address: net.ParseIP("2001:4860:4860::8888")})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should create a resource record from typed data", func(t *testing.T) {
+		rr, err := NewResourceRecordWithData("google.com", ClassIN, 300, &MX{Preference: 10, Exchange: "smtp.google.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, TypeMX, rr.Type)
+		assert.Equal(t, uint16(19), rr.RDLength)
+		assert.Equal(t, "10 smtp.google.com.", rr.RDataParsed)
+		assert.Equal(t, rr, NewResourceRecord("google.com", TypeMX, ClassIN, 300, rr.RDLength, rr.RData))
+	})
+	t.Run("Should decode a PTR record with a compressed name", func(t *testing.T) {
+		// 8.8.8.8.in-addr.arpa PTR dns.google, the owner name of the answer pointing to the question
+		dnsMessageBytes := []byte{0, 22, 129, 128, 0, 1, 0, 1, 0, 0, 0, 0,
+			1, 56, 1, 56, 1, 56, 1, 56, 7, 105, 110, 45, 97, 100, 100, 114, 4, 97, 114, 112, 97, 0, 0, 12, 0, 1,
+			192, 12, 0, 12, 0, 1, 0, 0, 84, 96, 0, 12, 3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 0}
+		message, err := ParseMessage(dnsMessageBytes)
+		assert.NoError(t, err)
+		assert.Equal(t, "8.8.8.8.in-addr.arpa", message.Answers[0].Name)
+		assert.Equal(t, &PTR{Target: "dns.google"}, message.Answers[0].Data)
+		assert.Equal(t, "dns.google", message.Answers[0].RDataParsed, "the legacy format has no trailing dot")
+		assert.Equal(t, "dns.google.", message.Answers[0].Data.String())
+	})
+
+	t.Run("Should handle the character-strings of a TXT record", func(t *testing.T) {
+		rr := NewResourceRecord("google.com", TypeTXT, ClassIN, 300, 10, []byte{3, 102, 111, 111, 0, 4, 98, 34, 92, 1})
+		txt, ok := rr.Data.(*TXT)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"foo", "", "b\"\\\x01"}, txt.Strings)
+		assert.Equal(t, `"foo" "" "b\"\\\001"`, rr.RDataParsed)
+		assert.Equal(t, "foob\"\\\x01", txt.Text())
+
+		_, err := UnpackRData(TypeTXT, []byte{5, 102, 111, 111})
+		assert.Error(t, err)
+		_, err = PackRData(&TXT{Strings: []string{string(make([]byte, 256))}})
+		assert.Error(t, err)
+	})
+}
@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"net"
+	"strconv"
+	"strings"
 )
 
 // ResourceRecord represents a DNS resource record.
@@ -16,85 +17,194 @@ type ResourceRecord struct {
 	Class       uint16 // The class of the resource record
 	TTL         uint32 // The time to live of the resource record
 	RDLength    uint16 // The length of the resource data
-	RData       []byte // The resource data
-	RDataParsed string // The parsed resource data
+	RData       []byte // The resource data, in uncompressed wire format
+	RDataParsed string // The parsed resource data, in its legacy format, see legacyRData. It is derived from Data.
+	Data        RData  // The typed resource data. It is nil if RData is not valid for the type.
 }
 
-// NewResourceRecord creates a new ResourceRecord instance.
+// NewResourceRecord creates a new ResourceRecord instance from the wire format of its resource data.
 func NewResourceRecord(name string, rType uint16, class uint16, ttl uint32, rdLength uint16, rData []byte) *ResourceRecord {
-	rDataParsed, _ := parseRData(rType, rData)
+	rr := &ResourceRecord{
+		Name:     name,
+		Type:     rType,
+		Class:    class,
+		TTL:      ttl,
+		RDLength: rdLength,
+		RData:    rData,
+	}
+	if data, err := UnpackRData(rType, rData); err == nil {
+		rr.Data = data
+		rr.RDataParsed = legacyRData(data)
+	}
+	return rr
+}
+
+// NewResourceRecordWithData creates a new ResourceRecord instance from its typed resource data.
+// The type of the record is the type of the data.
+func NewResourceRecordWithData(name string, class uint16, ttl uint32, data RData) (*ResourceRecord, error) {
+	rData, err := PackRData(data)
+	if err != nil {
+		return nil, err
+	}
 	return &ResourceRecord{
 		Name:        name,
-		Type:        rType,
+		Type:        data.Type(),
 		Class:       class,
 		TTL:         ttl,
-		RDLength:    rdLength,
+		RDLength:    uint16(len(rData)),
 		RData:       rData,
-		RDataParsed: rDataParsed,
+		RDataParsed: legacyRData(data),
+		Data:        data,
+	}, nil
+}
+
+// legacyRData returns the resource data in the format of RDataParsed, kept for backward compatibility:
+// the presentation format, except for the resource data made of a single domain name, such as the target
+// of a CNAME record, which is written without its trailing dot. The presentation format is returned by Data.String.
+func legacyRData(data RData) string {
+	switch data.(type) {
+	case *CNAME, *NS, *PTR, *DNAME:
+		return trimFQDN(data.String())
+	default:
+		return data.String()
 	}
 }
 
+// String returns the record in the presentation format of zone files, as printed by dig:
+// the owner name, TTL, class, type and resource data separated by tabs.
+func (rr *ResourceRecord) String() string {
+	return zoneLine(rr)
+}
+
 // TrimResourceRecordBytes appends bytes from the buffer until it completely parses all the bytes of a resource record.
 // It is useful to trim the bytes of a resource record from a buffer.
+// If the buffer runs out before the end of the record, the bytes read so far are returned.
 func TrimResourceRecordBytes(buf *bytes.Buffer) []byte {
-	rrBytes := appendFromBufferUntilNull(buf)
-	rrBytes = append(rrBytes, buf.Next(7)...) // appending until ttl
+	rrBytes := make([]byte, 0)
+	// Append the owner name, which ends with a null byte or a compression pointer
+	for buf.Len() > 0 {
+		length, _ := buf.ReadByte()
+		rrBytes = append(rrBytes, length)
+		if length == 0 {
+			break
+		}
+		if length&0xC0 == 0xC0 {
+			rrBytes = append(rrBytes, buf.Next(1)...)
+			break
+		}
+		rrBytes = append(rrBytes, buf.Next(int(length&0x3F))...)
+	}
+	rrBytes = append(rrBytes, buf.Next(8)...) // appending type, class and ttl
 	rdLength := buf.Next(2)
 	rrBytes = append(rrBytes, rdLength...) // appending rdLength
-	rdLengthCasted := binary.BigEndian.Uint16(rdLength)
-	rrBytes = append(rrBytes, buf.Next(int(rdLengthCasted))...) // appending rdata
+	if len(rdLength) == 2 {
+		rdLengthCasted := binary.BigEndian.Uint16(rdLength)
+		rrBytes = append(rrBytes, buf.Next(int(rdLengthCasted))...) // appending rdata
+	}
 	return rrBytes
 }
 
 // ToBytes converts the ResourceRecord to a byte slice.
+// The names are written uncompressed since the record is encoded outside of a message.
+// It returns nil if the owner name or the resource data is not valid, see NewName.
 func (rr *ResourceRecord) ToBytes() []byte {
-	buf := new(bytes.Buffer)
+	msg, _ := rr.pack(nil, nil)
+	return msg
+}
 
-	buf.Write([]byte(encodeName(rr.Name)))
-	binary.Write(buf, binary.BigEndian, rr.Type)
-	binary.Write(buf, binary.BigEndian, rr.Class)
-	binary.Write(buf, binary.BigEndian, rr.TTL)
-	binary.Write(buf, binary.BigEndian, rr.RDLength)
-	buf.Write(rr.RData)
+// pack appends the ResourceRecord to msg, which holds the message written so far.
+// If comp is not nil, the owner name and the names in the resource data are compressed against it.
+// The resource data is written from Data when it is set and from RData otherwise.
+// The RDLength written is the length of the resource data as it appears in msg.
+// It fails if the owner name or the resource data is not valid.
+func (rr *ResourceRecord) pack(msg []byte, comp compressionMap) ([]byte, error) {
+	msg, err := appendName(msg, rr.Name, comp)
+	if err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, rr.Type)
+	msg = binary.BigEndian.AppendUint16(msg, rr.Class)
+	msg = binary.BigEndian.AppendUint32(msg, rr.TTL)
+
+	rdLengthOffset := len(msg)
+	msg = append(msg, 0, 0)
+	if rr.Data != nil {
+		if msg, err = rr.Data.pack(msg, comp); err != nil {
+			return nil, fmt.Errorf("invalid %s record %s: %w", RTypeToString(rr.Type), fqdn(rr.Name), err)
+		}
+	} else {
+		msg = append(msg, rr.RData...)
+	}
+	binary.BigEndian.PutUint16(msg[rdLengthOffset:], uint16(len(msg)-rdLengthOffset-2))
 
-	return buf.Bytes()
+	return msg, nil
 }
 
 // ResourceRecordFromBytes creates a ResourceRecord from a byte slice.
+// If the record contains compression pointers, the message it was read from must be given
+// so that the pointers can be followed.
+// It returns an empty ResourceRecord if the data is not a valid record.
+// Use ParseMessage to find out why the message holding the record could not be parsed.
 func ResourceRecordFromBytes(data []byte, messageBufs ...*bytes.Buffer) *ResourceRecord {
-	buf := bytes.NewBuffer(data)
-	var messageBuf *bytes.Buffer
-	if messageBufs != nil {
-		messageBuf = messageBufs[0]
+	msg, offset := detachedData(data, messageBufs...)
+	rr, _, _ := unpackResourceRecord(msg, offset)
+	return rr
+}
+
+// unpackResourceRecord decodes the ResourceRecord starting at the given offset of the full message msg.
+// It returns the ResourceRecord and the offset of the first byte following it.
+//
+// The compressed domain names in the resource data are expanded, so that RData and RDLength
+// describe the record independently of the message it was read from.
+func unpackResourceRecord(msg []byte, offset int) (*ResourceRecord, int, error) {
+	name, off, err := DecodeNameAt(msg, offset)
+	if err != nil {
+		return &ResourceRecord{}, offset, err
+	}
+	if off+10 > len(msg) { // 10 is the length of the fields before RData
+		return &ResourceRecord{}, offset, fmt.Errorf("%w: resource record fields at offset %d", ErrTruncated, off)
 	}
 
-	name := appendFromBufferUntilNull(buf)
-	nameLength := len(name) - 1
-	decodedName, err := DecodeName(string(name), messageBuf)
+	typ := binary.BigEndian.Uint16(msg[off : off+2])
+	class := binary.BigEndian.Uint16(msg[off+2 : off+4])
+	ttl := binary.BigEndian.Uint32(msg[off+4 : off+8])
+	rdLength := binary.BigEndian.Uint16(msg[off+8 : off+10])
+	off += 10
+	end := off + int(rdLength)
+	if end > len(msg) {
+		return &ResourceRecord{}, offset, fmt.Errorf("%w: resource data of length %d at offset %d", ErrTruncated, rdLength, off)
+	}
 
+	// The prerequisites and updates of UPDATE messages in the ANY and NONE classes may have no resource data,
+	// which is not valid for most types
+	if rdLength == 0 && (class == ClassAll || class == ClassNone) && typ != TypeOPT {
+		return &ResourceRecord{Name: name, Type: typ, Class: class, TTL: ttl}, end, nil
+	}
+	data := newRData(typ)
+	if err := data.unpack(msg, off, end); err != nil {
+		return &ResourceRecord{}, offset, fmt.Errorf("invalid resource data at offset %d: %w", off, err)
+	}
+	rData, err := PackRData(data)
 	if err != nil {
-		fmt.Printf("Failed to decode the name: %v\n", err)
+		return &ResourceRecord{}, offset, err
 	}
 
-	typ := binary.BigEndian.Uint16(data[nameLength : nameLength+2])
-	class := binary.BigEndian.Uint16(data[nameLength+2 : nameLength+4])
-	ttl := binary.BigEndian.Uint32(data[nameLength+4 : nameLength+8])
-	rdLength := binary.BigEndian.Uint16(data[nameLength+8 : nameLength+10])
-	rData := data[nameLength+10 : nameLength+10+int(rdLength)] // 10 is the length of the fields before RData
-	rDataParsed, _ := parseRData(typ, rData, messageBuf)
-
 	return &ResourceRecord{
-		Name:        decodedName,
+		Name:        name,
 		Type:        typ,
 		Class:       class,
 		TTL:         ttl,
-		RDLength:    rdLength,
+		RDLength:    uint16(len(rData)),
 		RData:       rData,
-		RDataParsed: rDataParsed,
-	}
+		RDataParsed: legacyRData(data),
+		Data:        data,
+	}, end, nil
 }
 
 // RTypeToString returns the string representation of the given DNS record type.
+// Types without mnemonic are written TYPE followed by their value, e.g. TYPE65280.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3597#section-5 for more information
 func RTypeToString(rType uint16) string {
 	switch rType {
 	case TypeA:
@@ -115,12 +225,54 @@ func RTypeToString(rType uint16) string {
 		return "SRV"
 	case TypeTXT:
 		return "TXT"
+	case TypeTSIG:
+		return "TSIG"
+	case TypeAll:
+		return "ANY"
+	case TypeHINFO:
+		return "HINFO"
+	case TypeLOC:
+		return "LOC"
+	case TypeNAPTR:
+		return "NAPTR"
+	case TypeDNAME:
+		return "DNAME"
+	case TypeOPT:
+		return "OPT"
+	case TypeDS:
+		return "DS"
+	case TypeSSHFP:
+		return "SSHFP"
+	case TypeRRSIG:
+		return "RRSIG"
+	case TypeNSEC:
+		return "NSEC"
+	case TypeDNSKEY:
+		return "DNSKEY"
+	case TypeNSEC3:
+		return "NSEC3"
+	case TypeNSEC3PARAM:
+		return "NSEC3PARAM"
+	case TypeTLSA:
+		return "TLSA"
+	case TypeSVCB:
+		return "SVCB"
+	case TypeHTTPS:
+		return "HTTPS"
+	case TypeURI:
+		return "URI"
+	case TypeCAA:
+		return "CAA"
 	default:
-		return "UNKNOWN"
+		return fmt.Sprintf("TYPE%d", rType)
 	}
 }
 
 // RTypeToInt returns the integer representation of the given DNS record type.
+// It also accepts the generic mnemonic of any type: TYPE followed by its value, e.g. TYPE65280.
+// It returns 0 if the mnemonic is not known.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3597#section-5 for more information
 func RTypeToInt(rType string) uint16 {
 	switch rType {
 	case "A":
@@ -141,108 +293,106 @@ func RTypeToInt(rType string) uint16 {
 		return TypeSRV
 	case "TXT":
 		return TypeTXT
+	case "TSIG":
+		return TypeTSIG
+	case "ANY":
+		return TypeAll
+	case "HINFO":
+		return TypeHINFO
+	case "LOC":
+		return TypeLOC
+	case "NAPTR":
+		return TypeNAPTR
+	case "DNAME":
+		return TypeDNAME
+	case "OPT":
+		return TypeOPT
+	case "DS":
+		return TypeDS
+	case "SSHFP":
+		return TypeSSHFP
+	case "RRSIG":
+		return TypeRRSIG
+	case "NSEC":
+		return TypeNSEC
+	case "DNSKEY":
+		return TypeDNSKEY
+	case "NSEC3":
+		return TypeNSEC3
+	case "NSEC3PARAM":
+		return TypeNSEC3PARAM
+	case "TLSA":
+		return TypeTLSA
+	case "SVCB":
+		return TypeSVCB
+	case "HTTPS":
+		return TypeHTTPS
+	case "URI":
+		return TypeURI
+	case "CAA":
+		return TypeCAA
 	default:
-		return 0
+		return genericMnemonicValue(rType, "TYPE")
 	}
 }
 
-// parseRData parses the resource data based on the resource record type.
-func parseRData(rType uint16, rData []byte, messageBufs ...*bytes.Buffer) (string, error) {
-	switch rType {
-	case TypeA:
-		return parseA(rData)
-	case TypeAAAA:
-		return parseAAAA(rData)
-	case TypeCNAME:
-		return parseCNAME(rData, messageBufs...)
-	case TypeMX:
-		return parseMX(rData)
-	case TypeNS:
-		return parseNS(rData, messageBufs...)
-	case TypePTR:
-		return "", fmt.Errorf("PTR resource record is not supported")
-	case TypeSOA:
-		return parseSOA(rData)
-	case TypeSRV:
-		return parseSRV(rData)
-	case TypeTXT:
-		return "", fmt.Errorf("TXT resource record is not supported")
+// ClassToString returns the string representation of the given DNS record class.
+// Classes without mnemonic are written CLASS followed by their value, e.g. CLASS42.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3597#section-5 for more information
+func ClassToString(class uint16) string {
+	switch class {
+	case ClassIN:
+		return "IN"
+	case ClassCS:
+		return "CS"
+	case ClassCH:
+		return "CH"
+	case ClassHS:
+		return "HS"
+	case ClassNone:
+		return "NONE"
+	case ClassAll:
+		return "ANY"
 	default:
-		return "", fmt.Errorf("unknown resource record type: %d", rType)
-	}
-}
-
-// parseA parses the A resource record.
-func parseA(rData []byte) (string, error) {
-	if len(rData) != 4 {
-		return "", fmt.Errorf("invalid A record length: %d", len(rData))
-	}
-
-	ip := net.IP(rData)
-	return ip.String(), nil
-}
-
-// parseAAAA parses the AAAA resource record.
-func parseAAAA(rData []byte) (string, error) {
-	if len(rData) != 16 {
-		return "", fmt.Errorf("invalid AAAA record length: %d", len(rData))
-	}
-
-	ip := net.IP(rData)
-	return ip.String(), nil
-}
-
-// parseCNAME parses the CNAME resource record.
-func parseCNAME(rData []byte, messageBufs ...*bytes.Buffer) (string, error) {
-	if len(rData) == 0 {
-		return "", fmt.Errorf("invalid CNAME record length: %d", len(rData))
+		return fmt.Sprintf("CLASS%d", class)
 	}
-
-	name, err := DecodeName(string(rData), messageBufs...)
-	return name, err
 }
 
-// parseMX parses the MX resource record.
-func parseMX(rData []byte) (string, error) {
-	if len(rData) < 2 {
-		return "", fmt.Errorf("invalid MX record length: %d", len(rData))
+// ClassToInt returns the integer representation of the given DNS record class.
+// It also accepts the generic mnemonic of any class: CLASS followed by its value, e.g. CLASS42.
+// It returns 0 if the mnemonic is not known.
+//
+// See https://datatracker.ietf.org/doc/html/rfc3597#section-5 for more information
+func ClassToInt(class string) uint16 {
+	switch class {
+	case "IN":
+		return ClassIN
+	case "CS":
+		return ClassCS
+	case "CH":
+		return ClassCH
+	case "HS":
+		return ClassHS
+	case "NONE":
+		return ClassNone
+	case "ANY":
+		return ClassAll
+	default:
+		return genericMnemonicValue(class, "CLASS")
 	}
-
-	priority := binary.BigEndian.Uint16(rData[0:2])
-	name := string(rData[2:])
-	return fmt.Sprintf("%d %s", priority, name), nil
 }
 
-// parseNS parses the NS resource record.
-func parseNS(rData []byte, messageBufs ...*bytes.Buffer) (string, error) {
-	if len(rData) == 0 {
-		return "", fmt.Errorf("invalid NS record length: %d", len(rData))
-	}
-
-	name, err := DecodeName(string(rData), messageBufs...)
-	return name, err
-}
-
-// parseSOA parses the SOA resource record.
-func parseSOA(rData []byte) (string, error) {
-	if len(rData) < 20 {
-		return "", fmt.Errorf("invalid SOA record length: %d", len(rData))
+// genericMnemonicValue returns the value of a generic mnemonic made of the prefix followed by a decimal value,
+// or 0 if the mnemonic does not have this form.
+func genericMnemonicValue(mnemonic string, prefix string) uint16 {
+	digits, ok := strings.CutPrefix(mnemonic, prefix)
+	if !ok {
+		return 0
 	}
-
-	mname := string(rData[0:rData[0]])
-	rname := string(rData[rData[0]:])
-	return fmt.Sprintf("%s %s %d %d %d %d %d", mname, rname, binary.BigEndian.Uint32(rData[rData[0]+1:rData[0]+5]), binary.BigEndian.Uint32(rData[rData[0]+5:rData[0]+9]), binary.BigEndian.Uint32(rData[rData[0]+9:rData[0]+13]), binary.BigEndian.Uint32(rData[rData[0]+13:rData[0]+17]), binary.BigEndian.Uint32(rData[rData[0]+17:rData[0]+21])), nil
-}
-
-// parseSRV parses the SRV resource record.
-func parseSRV(rData []byte) (string, error) {
-	if len(rData) < 6 {
-		return "", fmt.Errorf("invalid SRV record length: %d", len(rData))
+	value, err := strconv.ParseUint(digits, 10, 16)
+	if err != nil {
+		return 0
 	}
-
-	priority := binary.BigEndian.Uint16(rData[0:2])
-	weight := binary.BigEndian.Uint16(rData[2:4])
-	port := binary.BigEndian.Uint16(rData[4:6])
-	name := string(rData[6:])
-	return fmt.Sprintf("%d %d %d %s", priority, weight, port, name), nil
+	return uint16(value)
 }
@@ -14,8 +14,19 @@ func TestResourceRecord(t *testing.T) {
 		assert.Equal(t, expected, resourceRecord.ToBytes())
 	})
 
+	t.Run("Should fail to encode a record whose resource data is not valid", func(t *testing.T) {
+		record, err := NewResourceRecordWithData("example.com", ClassIN, 300, &CNAME{Target: "www.example.com"})
+		assert.NoError(t, err)
+		record.Data = &CNAME{Target: "www..example.com"}
+		assert.Nil(t, record.ToBytes(), "the stale RData is not written in place of Data")
+
+		query := NewQuery("example.com", TypeCNAME).Reply().AddAnswer(*record)
+		_, err = query.Pack()
+		assert.ErrorContains(t, err, "invalid CNAME record example.com.")
+	})
+
 	t.Run("Should decode a record from bytes", func(t *testing.T) {
-		resourceRecordBytes := []byte{3, 119, 119, 119, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 1, 0, 1, 0, 0, 0, 0, 0, 4, 8, 8, 8, 8}
+		resourceRecordBytes := []byte{3, 119, 119, 119, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 4, 8, 8, 8, 8}
 		resourceRecord := NewResourceRecord("www.google.com", TypeA, ClassIN, 0, 4, []byte{8, 8, 8, 8})
 		assert.Equal(t, resourceRecord, ResourceRecordFromBytes(resourceRecordBytes))
 	})
@@ -34,5 +45,55 @@ func TestResourceRecord(t *testing.T) {
 		assert.Equal(t, expected, TrimResourceRecordBytes(buf))
 	})
 
+	t.Run("Should expand the compressed names in the resource data", func(t *testing.T) {
+		// dns.google.com MX 10 mail.<pointer to google.com>
+		dnsMessageBytes := []byte{0, 22, 129, 128, 0, 1, 0, 1, 0, 0, 0, 0, 3, 100, 110, 115, 6, 103, 111, 111, 103, 108, 101, 3, 99, 111, 109, 0, 0, 15, 0, 1, 192, 12, 0, 15, 0, 1, 0, 0, 3, 132, 0, 9, 0, 10, 4, 109, 97, 105, 108, 192, 16}
+		rr, next, err := unpackResourceRecord(dnsMessageBytes, 32)
+		assert.NoError(t, err)
+		assert.Equal(t, len(dnsMessageBytes), next)
+		assert.Equal(t, "dns.google.com", rr.Name)
+		assert.Equal(t, "10 mail.google.com.", rr.RDataParsed)
+		assert.Equal(t, &MX{Preference: 10, Exchange: "mail.google.com"}, rr.Data)
+		assert.Equal(t, append([]byte{0, 10}, encodeName("mail.google.com")...), rr.RData)
+		assert.Equal(t, uint16(len(rr.RData)), rr.RDLength)
+	})
+
+	t.Run("Should parse the SOA resource record", func(t *testing.T) {
+		rData := []byte(encodeName("ns1.google.com") + encodeName("dns-admin.google.com"))
+		rData = append(rData, 0, 0, 0, 1, 0, 0, 3, 132, 0, 0, 3, 132, 0, 0, 7, 8, 0, 0, 0, 60)
+		rr := NewResourceRecord("google.com", TypeSOA, ClassIN, 60, uint16(len(rData)), rData)
+		assert.Equal(t, "ns1.google.com. dns-admin.google.com. 1 900 900 1800 60", rr.RDataParsed)
+	})
+
+	t.Run("Should fail to unpack a resource record exceeding the message", func(t *testing.T) {
+		_, _, err := unpackResourceRecord([]byte{0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 4, 8, 8}, 0)
+		assert.Error(t, err)
+		assert.Equal(t, &ResourceRecord{}, ResourceRecordFromBytes([]byte{0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 4, 8, 8}))
+	})
+
+	t.Run("Should print a resource record in the presentation format", func(t *testing.T) {
+		rr, err := NewResourceRecordWithData("google.com", ClassIN, 300, &MX{Preference: 10, Exchange: "smtp.google.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, "google.com.\t300\tIN\tMX\t10 smtp.google.com.", rr.String())
+	})
+
+	t.Run("Should name the types and classes without mnemonic generically", func(t *testing.T) {
+		assert.Equal(t, "MX", RTypeToString(TypeMX))
+		assert.Equal(t, "TYPE65280", RTypeToString(65280))
+		assert.Equal(t, "IN", ClassToString(ClassIN))
+		assert.Equal(t, "CLASS42", ClassToString(42))
+
+		assert.Equal(t, uint16(65280), RTypeToInt("TYPE65280"))
+		assert.Equal(t, TypeA, RTypeToInt("TYPE1"))
+		assert.Equal(t, uint16(42), ClassToInt("CLASS42"))
+		assert.Equal(t, ClassCH, ClassToInt("CH"))
+		for _, invalid := range []string{"UNKNOWN", "TYPE", "TYPE65536", "TYPE-1", "TYPE0x10"} {
+			assert.Equal(t, uint16(0), RTypeToInt(invalid), invalid)
+		}
+		for _, invalid := range []string{"CLASS", "CLASS65536", "TYPE42"} {
+			assert.Equal(t, uint16(0), ClassToInt(invalid), invalid)
+		}
+	})
+
 	// TODO: Add tests for parsing resource records
 }
@@ -0,0 +1,92 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Reverse lookup domains
+const (
+	ReverseIPv4Domain = "in-addr.arpa" // domain holding the reverse mapping of IPv4 addresses
+	ReverseIPv6Domain = "ip6.arpa"     // domain holding the reverse mapping of IPv6 addresses
+)
+
+// ReverseName returns the domain name under which the PTR records of the IP address are published.
+// IPv4 addresses (including IPv4-mapped IPv6 addresses) map to in-addr.arpa and
+// IPv6 addresses map to ip6.arpa using the nibble format.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1035#section-3.5 and
+// https://datatracker.ietf.org/doc/html/rfc3596#section-2.5 for more information
+func ReverseName(ip net.IP) (string, error) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.%s", ip4[3], ip4[2], ip4[1], ip4[0], ReverseIPv4Domain), nil
+	}
+	if len(ip) != net.IPv6len {
+		return "", fmt.Errorf("invalid IP address: %v", ip)
+	}
+
+	var b strings.Builder
+	for i := net.IPv6len - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "%x.%x.", ip[i]&0x0F, ip[i]>>4)
+	}
+	b.WriteString(ReverseIPv6Domain)
+	return b.String(), nil
+}
+
+// ReverseNameFromAddr returns the domain name under which the PTR records of the address are published.
+// It is the netip counterpart of ReverseName.
+func ReverseNameFromAddr(addr netip.Addr) (string, error) {
+	if !addr.IsValid() {
+		return "", fmt.Errorf("invalid IP address: %v", addr)
+	}
+	return ReverseName(net.IP(addr.Unmap().AsSlice()))
+}
+
+// ParseReverseName returns the IP address whose reverse mapping is published under the given domain name.
+// It is the inverse of ReverseName and accepts names with or without the trailing dot, in any case.
+func ParseReverseName(name string) (net.IP, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	if labels, ok := strings.CutSuffix(name, "."+ReverseIPv4Domain); ok {
+		parts := strings.Split(labels, ".")
+		if len(parts) != net.IPv4len {
+			return nil, fmt.Errorf("invalid %s name: %s", ReverseIPv4Domain, name)
+		}
+		ip := make(net.IP, net.IPv4len)
+		for i, part := range parts {
+			octet, err := strconv.ParseUint(part, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s name: %s", ReverseIPv4Domain, name)
+			}
+			ip[net.IPv4len-1-i] = byte(octet)
+		}
+		return ip, nil
+	}
+
+	if labels, ok := strings.CutSuffix(name, "."+ReverseIPv6Domain); ok {
+		nibbles := strings.Split(labels, ".")
+		if len(nibbles) != 2*net.IPv6len {
+			return nil, fmt.Errorf("invalid %s name: %s", ReverseIPv6Domain, name)
+		}
+		ip := make(net.IP, net.IPv6len)
+		for i, nibble := range nibbles {
+			value, err := strconv.ParseUint(nibble, 16, 4)
+			if err != nil || len(nibble) != 1 {
+				return nil, fmt.Errorf("invalid %s name: %s", ReverseIPv6Domain, name)
+			}
+			// The first nibble is the low nibble of the last byte
+			index := net.IPv6len - 1 - i/2
+			if i%2 == 0 {
+				ip[index] |= byte(value)
+			} else {
+				ip[index] |= byte(value) << 4
+			}
+		}
+		return ip, nil
+	}
+
+	return nil, fmt.Errorf("not a reverse lookup name: %s", name)
+}
@@ -0,0 +1,64 @@
+package dns
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverse(t *testing.T) {
+	t.Run("Should build the in-addr.arpa name of an IPv4 address", func(t *testing.T) {
+		name, err := ReverseName(net.ParseIP("8.8.4.4"))
+		assert.NoError(t, err)
+		assert.Equal(t, "4.4.8.8.in-addr.arpa", name)
+
+		name, err = ReverseName(net.ParseIP("::ffff:192.0.2.1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2.0.192.in-addr.arpa", name)
+	})
+
+	t.Run("Should build the ip6.arpa name of an IPv6 address", func(t *testing.T) {
+		name, err := ReverseName(net.ParseIP("2001:db8::567:89ab"))
+		assert.NoError(t, err)
+		assert.Equal(t, "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", name)
+
+		_, err = ReverseName(net.IP{1, 2, 3})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should build the reverse name of a netip address", func(t *testing.T) {
+		name, err := ReverseNameFromAddr(netip.MustParseAddr("8.8.8.8"))
+		assert.NoError(t, err)
+		assert.Equal(t, "8.8.8.8.in-addr.arpa", name)
+
+		_, err = ReverseNameFromAddr(netip.Addr{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should parse a reverse name back to the IP address", func(t *testing.T) {
+		ip, err := ParseReverseName("4.4.8.8.IN-ADDR.ARPA.")
+		assert.NoError(t, err)
+		assert.True(t, net.ParseIP("8.8.4.4").Equal(ip))
+
+		ip, err = ParseReverseName("b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa")
+		assert.NoError(t, err)
+		assert.True(t, net.ParseIP("2001:db8::567:89ab").Equal(ip))
+	})
+
+	t.Run("Should reject invalid reverse names", func(t *testing.T) {
+		invalidNames := []string{
+			"dns.google.com",
+			"4.8.8.in-addr.arpa",
+			"256.4.8.8.in-addr.arpa",
+			"1.0/25.2.0.192.in-addr.arpa",
+			"b.a.9.8.ip6.arpa",
+			"ba.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		}
+		for _, name := range invalidNames {
+			_, err := ParseReverseName(name)
+			assert.Error(t, err, name)
+		}
+	})
+}
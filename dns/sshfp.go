@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// SSHFP algorithms, the types of the SSH host keys.
+const (
+	SSHFPAlgorithmRSA     uint8 = 1 // ssh-rsa
+	SSHFPAlgorithmDSA     uint8 = 2 // ssh-dss
+	SSHFPAlgorithmECDSA   uint8 = 3 // ecdsa-sha2-nistp256, ecdsa-sha2-nistp384 and ecdsa-sha2-nistp521
+	SSHFPAlgorithmEd25519 uint8 = 4 // ssh-ed25519
+	SSHFPAlgorithmEd448   uint8 = 6 // ssh-ed448
+)
+
+// SSHFP fingerprint types, the digest algorithms of the fingerprints.
+const (
+	SSHFPFingerprintSHA1   uint8 = 1
+	SSHFPFingerprintSHA256 uint8 = 2
+)
+
+// SSHFP is the resource data of an SSHFP record, the fingerprint of an SSH host key of the owner,
+// which lets SSH clients check the key of a host they have never connected to.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4255#section-3.1 for more information
+type SSHFP struct {
+	Algorithm       uint8  // Algorithm is the type of the host key, such as SSHFPAlgorithmEd25519.
+	FingerprintType uint8  // FingerprintType is the digest algorithm of the fingerprint, such as SSHFPFingerprintSHA256.
+	Fingerprint     []byte // Fingerprint is the digest of the host key in the SSH wire format.
+}
+
+// Type returns TypeSSHFP.
+func (rd *SSHFP) Type() uint16 { return TypeSSHFP }
+
+// String returns the algorithm and fingerprint type followed by the fingerprint in uppercase hex.
+func (rd *SSHFP) String() string {
+	return fmt.Sprintf("%d %d %s", rd.Algorithm, rd.FingerprintType, strings.ToUpper(hex.EncodeToString(rd.Fingerprint)))
+}
+
+// Equal reports whether other is an SSHFP record with the same fields.
+func (rd *SSHFP) Equal(other RData) bool {
+	o, ok := other.(*SSHFP)
+	return ok && rd.Algorithm == o.Algorithm && rd.FingerprintType == o.FingerprintType && bytes.Equal(rd.Fingerprint, o.Fingerprint)
+}
+
+// Matches reports whether the fingerprint is the one of the host key, given in the SSH wire format
+// of RFC 4253, as found base64 encoded in the known_hosts files. It fails if the fingerprint type is not supported.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4255#section-3.1.3 for more information
+func (rd *SSHFP) Matches(hostKey []byte) (bool, error) {
+	var digest []byte
+	switch rd.FingerprintType {
+	case SSHFPFingerprintSHA1:
+		sum := sha1.Sum(hostKey)
+		digest = sum[:]
+	case SSHFPFingerprintSHA256:
+		sum := sha256.Sum256(hostKey)
+		digest = sum[:]
+	default:
+		return false, fmt.Errorf("unsupported SSHFP fingerprint type: %d", rd.FingerprintType)
+	}
+	return rd.Algorithm == sshKeyAlgorithm(hostKey) && bytes.Equal(rd.Fingerprint, digest), nil
+}
+
+// sshKeyAlgorithm returns the SSHFP algorithm of the host key from the name of its format, which starts
+// the key as a 32-bit length followed by the name, or 0 if the format is not known.
+func sshKeyAlgorithm(hostKey []byte) uint8 {
+	if len(hostKey) < 4 || uint64(len(hostKey)-4) < uint64(binary.BigEndian.Uint32(hostKey)) {
+		return 0
+	}
+	switch name := string(hostKey[4 : 4+binary.BigEndian.Uint32(hostKey)]); {
+	case name == "ssh-rsa":
+		return SSHFPAlgorithmRSA
+	case name == "ssh-dss":
+		return SSHFPAlgorithmDSA
+	case strings.HasPrefix(name, "ecdsa-sha2-"):
+		return SSHFPAlgorithmECDSA
+	case name == "ssh-ed25519":
+		return SSHFPAlgorithmEd25519
+	case name == "ssh-ed448":
+		return SSHFPAlgorithmEd448
+	}
+	return 0
+}
+
+func (rd *SSHFP) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg = append(msg, rd.Algorithm, rd.FingerprintType)
+	return append(msg, rd.Fingerprint...), nil
+}
+
+func (rd *SSHFP) unpack(msg []byte, off, end int) error {
+	if end-off < 2 {
+		return rdataLengthError(TypeSSHFP, end-off)
+	}
+	rd.Algorithm = msg[off]
+	rd.FingerprintType = msg[off+1]
+	rd.Fingerprint = bytes.Clone(msg[off+2 : end])
+	return nil
+}
+
+// parse accepts a fingerprint split over several fields.
+func (rd *SSHFP) parse(tokens []zoneToken, _ string) error {
+	if len(tokens) < 3 {
+		return fmt.Errorf("expected at least 3 fields, got %d", len(tokens))
+	}
+	algorithm, err := parseUint(tokens[0], 8)
+	if err != nil {
+		return err
+	}
+	fingerprintType, err := parseUint(tokens[1], 8)
+	if err != nil {
+		return err
+	}
+	fingerprint, err := parseHex(tokens[2:])
+	if err != nil {
+		return err
+	}
+	rd.Algorithm, rd.FingerprintType, rd.Fingerprint = uint8(algorithm), uint8(fingerprintType), fingerprint
+	return nil
+}
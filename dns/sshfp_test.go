@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSHFP(t *testing.T) {
+	t.Run("Should pack, unpack and print an SSHFP record", func(t *testing.T) {
+		fingerprint, _ := hex.DecodeString("123456789abcdef67890123456789abcdef67890")
+		record := &SSHFP{Algorithm: SSHFPAlgorithmDSA, FingerprintType: SSHFPFingerprintSHA1, Fingerprint: fingerprint}
+		rData, err := PackRData(record)
+		assert.NoError(t, err)
+		assert.Equal(t, append([]byte{2, 1}, fingerprint...), rData)
+
+		unpacked, err := UnpackRData(TypeSSHFP, rData)
+		assert.NoError(t, err)
+		assert.True(t, record.Equal(unpacked))
+		assert.Equal(t, "2 1 123456789ABCDEF67890123456789ABCDEF67890", unpacked.String())
+
+		_, err = UnpackRData(TypeSSHFP, []byte{2})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should match the fingerprint of a host key", func(t *testing.T) {
+		public, _, _ := ed25519.GenerateKey(rand.Reader)
+		var hostKey []byte
+		for _, field := range [][]byte{[]byte("ssh-ed25519"), public} {
+			hostKey = binary.BigEndian.AppendUint32(hostKey, uint32(len(field)))
+			hostKey = append(hostKey, field...)
+		}
+		digest := sha256.Sum256(hostKey)
+		record := &SSHFP{Algorithm: SSHFPAlgorithmEd25519, FingerprintType: SSHFPFingerprintSHA256, Fingerprint: digest[:]}
+
+		matches, err := record.Matches(hostKey)
+		assert.NoError(t, err)
+		assert.True(t, matches)
+		matches, _ = (&SSHFP{Algorithm: SSHFPAlgorithmRSA, FingerprintType: SSHFPFingerprintSHA256, Fingerprint: digest[:]}).Matches(hostKey)
+		assert.False(t, matches, "the algorithm does not match the type of the key")
+		matches, _ = record.Matches(append(hostKey, 0))
+		assert.False(t, matches)
+		_, err = (&SSHFP{Algorithm: SSHFPAlgorithmEd25519, FingerprintType: 3}).Matches(hostKey)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should read SSHFP records in zone files", func(t *testing.T) {
+		zone := "host 300 SSHFP 4 2 ( 1D2D0C4C0BF6A4A3CB44E56B7F4D7D33\n 8D26D0E6A43D4E5B1E7C9C7C5B0C8E5B )\n"
+		records, err := ParseZone(strings.NewReader(zone), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, "host.example.com.\t300\tIN\tSSHFP\t4 2 1D2D0C4C0BF6A4A3CB44E56B7F4D7D338D26D0E6A43D4E5B1E7C9C7C5B0C8E5B", records[0].String())
+		}
+
+		for _, invalid := range []string{"host 300 SSHFP 4 2", "host 300 SSHFP 4 2 XYZ", "host 300 SSHFP 256 2 00"} {
+			_, err := ParseZone(strings.NewReader(invalid), "example.com.", "")
+			assert.Error(t, err, invalid)
+		}
+	})
+}
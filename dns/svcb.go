@@ -0,0 +1,733 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SvcParam keys, as registered by IANA.
+const (
+	SvcParamMandatory     uint16 = 0 // keys that must be understood to use the record
+	SvcParamALPN          uint16 = 1 // application protocols supported by the endpoint
+	SvcParamNoDefaultALPN uint16 = 2 // the default protocol of the scheme is not supported
+	SvcParamPort          uint16 = 3 // port of the endpoint
+	SvcParamIPv4Hint      uint16 = 4 // IPv4 addresses of the target
+	SvcParamECH           uint16 = 5 // Encrypted ClientHello configurations
+	SvcParamIPv6Hint      uint16 = 6 // IPv6 addresses of the target
+)
+
+// svcParamInvalidKey is the reserved key which can not be used by any parameter.
+const svcParamInvalidKey uint16 = 65535
+
+// SvcParam is a typed parameter of an SVCB or HTTPS record, which describes how to reach the service.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-2.2 for more information
+type SvcParam interface {
+	// Key returns the key of the parameter.
+	Key() uint16
+	// String returns the presentation format of the parameter: its key, followed by = and its value if it has one.
+	String() string
+
+	// pack appends the wire format of the value, without the key and length, to b.
+	pack(b []byte) ([]byte, error)
+	// unpack decodes the wire format of the value, without the key and length.
+	unpack(data []byte) error
+	// parse decodes the presentation format of the value, once its character-string escapes are decoded.
+	// A parameter written without value has an empty value.
+	parse(value string) error
+}
+
+// newSvcParam returns an empty SvcParam of the given key, or an UnknownSvcParam if the key is not supported.
+func newSvcParam(key uint16) SvcParam {
+	switch key {
+	case SvcParamMandatory:
+		return &MandatoryParam{}
+	case SvcParamALPN:
+		return &ALPNParam{}
+	case SvcParamNoDefaultALPN:
+		return &NoDefaultALPNParam{}
+	case SvcParamPort:
+		return &PortParam{}
+	case SvcParamIPv4Hint:
+		return &IPv4HintParam{}
+	case SvcParamECH:
+		return &ECHParam{}
+	case SvcParamIPv6Hint:
+		return &IPv6HintParam{}
+	default:
+		return &UnknownSvcParam{ParamKey: key}
+	}
+}
+
+// SvcParamKeyToString returns the presentation name of the given SvcParam key.
+// Keys without name are written key followed by their value, e.g. key667.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-2.1 for more information
+func SvcParamKeyToString(key uint16) string {
+	switch key {
+	case SvcParamMandatory:
+		return "mandatory"
+	case SvcParamALPN:
+		return "alpn"
+	case SvcParamNoDefaultALPN:
+		return "no-default-alpn"
+	case SvcParamPort:
+		return "port"
+	case SvcParamIPv4Hint:
+		return "ipv4hint"
+	case SvcParamECH:
+		return "ech"
+	case SvcParamIPv6Hint:
+		return "ipv6hint"
+	default:
+		return fmt.Sprintf("key%d", key)
+	}
+}
+
+// svcParamKeyFromString returns the SvcParam key of the given presentation name.
+func svcParamKeyFromString(text string) (uint16, error) {
+	for key := SvcParamMandatory; key <= SvcParamIPv6Hint; key++ {
+		if text == SvcParamKeyToString(key) {
+			return key, nil
+		}
+	}
+	value, ok := strings.CutPrefix(text, "key")
+	if !ok || value == "" || (value[0] == '0' && len(value) > 1) {
+		return 0, fmt.Errorf("unknown SvcParam key: %s", text)
+	}
+	key, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || uint16(key) == svcParamInvalidKey {
+		return 0, fmt.Errorf("invalid SvcParam key: %s", text)
+	}
+	return uint16(key), nil
+}
+
+// MandatoryParam lists the keys of the parameters that a client must understand to use the record.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-8 for more information
+type MandatoryParam struct {
+	Keys []uint16
+}
+
+// Key returns SvcParamMandatory.
+func (p *MandatoryParam) Key() uint16 { return SvcParamMandatory }
+
+// String returns the names of the keys separated by commas.
+func (p *MandatoryParam) String() string {
+	names := make([]string, len(p.Keys))
+	for i, key := range p.Keys {
+		names[i] = SvcParamKeyToString(key)
+	}
+	return "mandatory=" + strings.Join(names, ",")
+}
+
+// pack writes the keys in increasing order, as required on the wire.
+func (p *MandatoryParam) pack(b []byte) ([]byte, error) {
+	keys := slices.Clone(p.Keys)
+	slices.Sort(keys)
+	if err := checkMandatoryKeys(keys); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		b = binary.BigEndian.AppendUint16(b, key)
+	}
+	return b, nil
+}
+
+func (p *MandatoryParam) unpack(data []byte) error {
+	if len(data)%2 != 0 {
+		return fmt.Errorf("invalid mandatory length: %d", len(data))
+	}
+	p.Keys = make([]uint16, len(data)/2)
+	for i := range p.Keys {
+		p.Keys[i] = binary.BigEndian.Uint16(data[2*i:])
+	}
+	return checkMandatoryKeys(p.Keys)
+}
+
+func (p *MandatoryParam) parse(value string) error {
+	p.Keys = nil
+	for _, name := range strings.Split(value, ",") {
+		key, err := svcParamKeyFromString(name)
+		if err != nil {
+			return err
+		}
+		p.Keys = append(p.Keys, key)
+	}
+	slices.Sort(p.Keys)
+	return checkMandatoryKeys(p.Keys)
+}
+
+// checkMandatoryKeys checks that the sorted keys of a MandatoryParam are not empty, unique, and do not hold mandatory itself.
+func checkMandatoryKeys(keys []uint16) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("mandatory must list at least one key")
+	}
+	for i, key := range keys {
+		if key == SvcParamMandatory {
+			return fmt.Errorf("mandatory can not list itself")
+		}
+		if i > 0 && keys[i-1] >= key {
+			return fmt.Errorf("mandatory lists the key %s twice or out of order", SvcParamKeyToString(key))
+		}
+	}
+	return nil
+}
+
+// ALPNParam lists the identifiers of the application protocols supported by the endpoint, such as h2 or h3,
+// in addition to the default protocol of the scheme unless NoDefaultALPNParam is set.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-7.1 for more information
+type ALPNParam struct {
+	Protocols []string
+}
+
+// Key returns SvcParamALPN.
+func (p *ALPNParam) Key() uint16 { return SvcParamALPN }
+
+// String returns the quoted protocols separated by commas.
+// The commas and backslashes inside the protocols are escaped with a backslash.
+func (p *ALPNParam) String() string {
+	escaped := make([]string, len(p.Protocols))
+	for i, protocol := range p.Protocols {
+		escaped[i] = escapeValueListItem(protocol)
+	}
+	return "alpn=" + quoteCharacterString(strings.Join(escaped, ","))
+}
+
+func (p *ALPNParam) pack(b []byte) ([]byte, error) {
+	if err := checkALPNProtocols(p.Protocols); err != nil {
+		return nil, err
+	}
+	for _, protocol := range p.Protocols {
+		b = append(b, byte(len(protocol)))
+		b = append(b, protocol...)
+	}
+	return b, nil
+}
+
+func (p *ALPNParam) unpack(data []byte) error {
+	p.Protocols = nil
+	for off := 0; off < len(data); {
+		length := int(data[off])
+		if off+1+length > len(data) {
+			return fmt.Errorf("alpn protocol exceeds the parameter")
+		}
+		p.Protocols = append(p.Protocols, string(data[off+1:off+1+length]))
+		off += 1 + length
+	}
+	return checkALPNProtocols(p.Protocols)
+}
+
+func (p *ALPNParam) parse(value string) error {
+	protocols, err := splitValueList(value)
+	if err != nil {
+		return err
+	}
+	p.Protocols = protocols
+	return checkALPNProtocols(p.Protocols)
+}
+
+// checkALPNProtocols checks that there is at least one protocol, and that each fits in a character-string.
+func checkALPNProtocols(protocols []string) error {
+	if len(protocols) == 0 {
+		return fmt.Errorf("alpn must list at least one protocol")
+	}
+	for _, protocol := range protocols {
+		if len(protocol) == 0 || len(protocol) > maxCharacterStringLength {
+			return fmt.Errorf("invalid alpn protocol length: %d", len(protocol))
+		}
+	}
+	return nil
+}
+
+// NoDefaultALPNParam indicates that the endpoint does not support the default protocol of the scheme,
+// such as HTTP/1.1 for HTTPS records. It has no value.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-7.1 for more information
+type NoDefaultALPNParam struct{}
+
+// Key returns SvcParamNoDefaultALPN.
+func (p *NoDefaultALPNParam) Key() uint16 { return SvcParamNoDefaultALPN }
+
+// String returns the name of the key, as the parameter has no value.
+func (p *NoDefaultALPNParam) String() string { return "no-default-alpn" }
+
+func (p *NoDefaultALPNParam) pack(b []byte) ([]byte, error) { return b, nil }
+
+func (p *NoDefaultALPNParam) unpack(data []byte) error {
+	if len(data) != 0 {
+		return fmt.Errorf("no-default-alpn must have an empty value")
+	}
+	return nil
+}
+
+func (p *NoDefaultALPNParam) parse(value string) error { return p.unpack([]byte(value)) }
+
+// PortParam is the port of the endpoint, which replaces the default port of the scheme.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-7.2 for more information
+type PortParam struct {
+	Port uint16
+}
+
+// Key returns SvcParamPort.
+func (p *PortParam) Key() uint16 { return SvcParamPort }
+
+// String returns the port number.
+func (p *PortParam) String() string { return fmt.Sprintf("port=%d", p.Port) }
+
+func (p *PortParam) pack(b []byte) ([]byte, error) {
+	return binary.BigEndian.AppendUint16(b, p.Port), nil
+}
+
+func (p *PortParam) unpack(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("invalid port length: %d", len(data))
+	}
+	p.Port = binary.BigEndian.Uint16(data)
+	return nil
+}
+
+func (p *PortParam) parse(value string) error {
+	port, err := strconv.ParseUint(value, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port: %s", value)
+	}
+	p.Port = uint16(port)
+	return nil
+}
+
+// IPv4HintParam lists IPv4 addresses of the target, which clients may use until they resolve its A records.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-7.3 for more information
+type IPv4HintParam struct {
+	Addresses []netip.Addr
+}
+
+// Key returns SvcParamIPv4Hint.
+func (p *IPv4HintParam) Key() uint16 { return SvcParamIPv4Hint }
+
+// String returns the addresses separated by commas.
+func (p *IPv4HintParam) String() string { return "ipv4hint=" + formatAddresses(p.Addresses) }
+
+func (p *IPv4HintParam) pack(b []byte) ([]byte, error) { return packAddresses(b, p.Addresses, 4) }
+
+func (p *IPv4HintParam) unpack(data []byte) (err error) {
+	p.Addresses, err = unpackAddresses(data, 4)
+	return err
+}
+
+func (p *IPv4HintParam) parse(value string) (err error) {
+	p.Addresses, err = parseAddresses(value, 4)
+	return err
+}
+
+// ECHParam is the list of Encrypted ClientHello configurations of the endpoint, which clients use to
+// encrypt the TLS ClientHello. Its value is kept opaque.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-14.3.2 for more information
+type ECHParam struct {
+	ConfigList []byte
+}
+
+// Key returns SvcParamECH.
+func (p *ECHParam) Key() uint16 { return SvcParamECH }
+
+// String returns the configuration list encoded in base64.
+func (p *ECHParam) String() string { return "ech=" + base64.StdEncoding.EncodeToString(p.ConfigList) }
+
+func (p *ECHParam) pack(b []byte) ([]byte, error) { return append(b, p.ConfigList...), nil }
+
+func (p *ECHParam) unpack(data []byte) error {
+	p.ConfigList = bytes.Clone(data)
+	return nil
+}
+
+func (p *ECHParam) parse(value string) error {
+	configList, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return fmt.Errorf("invalid base64 ech: %s", value)
+	}
+	p.ConfigList = configList
+	return nil
+}
+
+// IPv6HintParam lists IPv6 addresses of the target, which clients may use until they resolve its AAAA records.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-7.3 for more information
+type IPv6HintParam struct {
+	Addresses []netip.Addr
+}
+
+// Key returns SvcParamIPv6Hint.
+func (p *IPv6HintParam) Key() uint16 { return SvcParamIPv6Hint }
+
+// String returns the addresses separated by commas.
+func (p *IPv6HintParam) String() string { return "ipv6hint=" + formatAddresses(p.Addresses) }
+
+func (p *IPv6HintParam) pack(b []byte) ([]byte, error) { return packAddresses(b, p.Addresses, 16) }
+
+func (p *IPv6HintParam) unpack(data []byte) (err error) {
+	p.Addresses, err = unpackAddresses(data, 16)
+	return err
+}
+
+func (p *IPv6HintParam) parse(value string) (err error) {
+	p.Addresses, err = parseAddresses(value, 16)
+	return err
+}
+
+// formatAddresses returns the addresses separated by commas.
+func formatAddresses(addrs []netip.Addr) string {
+	texts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		texts[i] = addr.String()
+	}
+	return strings.Join(texts, ",")
+}
+
+// packAddresses appends the addresses, which must all have the given size in bytes, to b.
+func packAddresses(b []byte, addrs []netip.Addr, size int) ([]byte, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("address hints must list at least one address")
+	}
+	for _, addr := range addrs {
+		if addr.BitLen() != size*8 {
+			return nil, fmt.Errorf("invalid address hint: %s", addr)
+		}
+		b = append(b, addr.AsSlice()...)
+	}
+	return b, nil
+}
+
+// unpackAddresses decodes a non-empty list of addresses of the given size in bytes.
+func unpackAddresses(data []byte, size int) ([]netip.Addr, error) {
+	if len(data) == 0 || len(data)%size != 0 {
+		return nil, fmt.Errorf("invalid address hints length: %d", len(data))
+	}
+	addrs := make([]netip.Addr, 0, len(data)/size)
+	for off := 0; off < len(data); off += size {
+		addr, _ := netip.AddrFromSlice(data[off : off+size])
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// parseAddresses parses a non-empty list of addresses of the given size in bytes, separated by commas.
+func parseAddresses(value string, size int) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	for _, text := range strings.Split(value, ",") {
+		addr, err := netip.ParseAddr(text)
+		if err != nil || addr.BitLen() != size*8 || addr.Zone() != "" {
+			return nil, fmt.Errorf("invalid address hint: %s", text)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// UnknownSvcParam is a parameter whose key is not supported. Its value is kept as is.
+type UnknownSvcParam struct {
+	ParamKey uint16
+	Value    []byte
+}
+
+// Key returns the key of the parameter.
+func (p *UnknownSvcParam) Key() uint16 { return p.ParamKey }
+
+// String returns the generic name of the key followed by the quoted value, if not empty.
+func (p *UnknownSvcParam) String() string {
+	if len(p.Value) == 0 {
+		return SvcParamKeyToString(p.ParamKey)
+	}
+	return SvcParamKeyToString(p.ParamKey) + "=" + quoteCharacterString(string(p.Value))
+}
+
+func (p *UnknownSvcParam) pack(b []byte) ([]byte, error) { return append(b, p.Value...), nil }
+
+func (p *UnknownSvcParam) unpack(data []byte) error {
+	p.Value = bytes.Clone(data)
+	return nil
+}
+
+func (p *UnknownSvcParam) parse(value string) error {
+	p.Value = []byte(value)
+	return nil
+}
+
+// splitValueList splits a comma-separated list of values, in which commas and backslashes are escaped with a backslash.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#appendix-A.1 for more information
+func splitValueList(value string) ([]string, error) {
+	var items []string
+	var item strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case ',':
+			items = append(items, item.String())
+			item.Reset()
+		case '\\':
+			if i+1 >= len(value) {
+				return nil, fmt.Errorf("incomplete escape: %s", value)
+			}
+			i++
+			item.WriteByte(value[i])
+		default:
+			item.WriteByte(c)
+		}
+	}
+	return append(items, item.String()), nil
+}
+
+// escapeValueListItem escapes the commas and backslashes of an item of a comma-separated list of values.
+func escapeValueListItem(item string) string {
+	return strings.NewReplacer(`\`, `\\`, `,`, `\,`).Replace(item)
+}
+
+// SVCB is the resource data of an SVCB record, which binds a service to an endpoint providing it.
+// Records with priority 0 are in AliasMode: they alias the owner to the target, which holds the records
+// of the service, as a CNAME restricted to the service would. The parameters of these records are ignored.
+// Other records are in ServiceMode: the target provides the service with the given parameters,
+// and the records with a lower priority are preferred.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-2 for more information
+type SVCB struct {
+	Priority uint16     // Priority is 0 in AliasMode, and the preference of the endpoint in ServiceMode.
+	Target   string     // Target is the domain name of the endpoint. In ServiceMode, the root "" stands for the owner.
+	Params   []SvcParam // Params describe how to reach the endpoint. They are written in increasing order of keys.
+}
+
+// Type returns TypeSVCB.
+func (rd *SVCB) Type() uint16 { return TypeSVCB }
+
+// String returns the priority and the fully qualified target followed by the parameters, separated by spaces.
+func (rd *SVCB) String() string {
+	fields := []string{strconv.Itoa(int(rd.Priority)), fqdn(rd.Target)}
+	for _, param := range rd.sortedParams() {
+		fields = append(fields, param.String())
+	}
+	return strings.Join(fields, " ")
+}
+
+// Equal reports whether other is an SVCB record with the same priority, target and parameters.
+func (rd *SVCB) Equal(other RData) bool {
+	o, ok := other.(*SVCB)
+	return ok && rd.equal(o)
+}
+
+// equal reports whether the two records have the same priority, target and parameters.
+func (rd *SVCB) equal(o *SVCB) bool {
+	if rd.Priority != o.Priority || !equalNames(rd.Target, o.Target) || len(rd.Params) != len(o.Params) {
+		return false
+	}
+	params, otherParams := rd.sortedParams(), o.sortedParams()
+	for i := range params {
+		value, err := params[i].pack(nil)
+		otherValue, otherErr := otherParams[i].pack(nil)
+		if params[i].Key() != otherParams[i].Key() || err != nil || otherErr != nil || !bytes.Equal(value, otherValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAliasMode reports whether the record is in AliasMode, that is whether its priority is 0.
+func (rd *SVCB) IsAliasMode() bool { return rd.Priority == 0 }
+
+// TargetName returns the domain name of the endpoint of a record owned by the given name:
+// its target, or the owner itself if the target of a ServiceMode record is the root.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-2.5 for more information
+func (rd *SVCB) TargetName(owner string) string {
+	if rd.Target == "" && !rd.IsAliasMode() {
+		return owner
+	}
+	return rd.Target
+}
+
+// Param returns the parameter with the given key, or nil if the record has none.
+func (rd *SVCB) Param(key uint16) SvcParam {
+	for _, param := range rd.Params {
+		if param.Key() == key {
+			return param
+		}
+	}
+	return nil
+}
+
+// SetParam adds the parameter to the record, replacing the parameter with the same key if any.
+// The parameters are kept in increasing order of keys.
+func (rd *SVCB) SetParam(param SvcParam) {
+	for i, existing := range rd.Params {
+		if existing.Key() == param.Key() {
+			rd.Params[i] = param
+			return
+		}
+	}
+	rd.Params = append(rd.Params, param)
+	rd.Params = rd.sortedParams()
+}
+
+// sortedParams returns the parameters in increasing order of keys, without changing the record.
+func (rd *SVCB) sortedParams() []SvcParam {
+	params := slices.Clone(rd.Params)
+	slices.SortStableFunc(params, func(a, b SvcParam) int { return int(a.Key()) - int(b.Key()) })
+	return params
+}
+
+// checkMandatory checks that the keys listed by the mandatory parameter are all present.
+func (rd *SVCB) checkMandatory() error {
+	mandatory, ok := rd.Param(SvcParamMandatory).(*MandatoryParam)
+	if !ok {
+		return nil
+	}
+	for _, key := range mandatory.Keys {
+		if rd.Param(key) == nil {
+			return fmt.Errorf("mandatory key %s is missing", SvcParamKeyToString(key))
+		}
+	}
+	return nil
+}
+
+// pack never compresses the target, as required by RFC 9460.
+func (rd *SVCB) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg = binary.BigEndian.AppendUint16(msg, rd.Priority)
+	msg, err := appendName(msg, rd.Target, nil)
+	if err != nil {
+		return nil, err
+	}
+	params := rd.sortedParams()
+	for i, param := range params {
+		if param.Key() == svcParamInvalidKey {
+			return nil, fmt.Errorf("invalid SvcParam key: %d", param.Key())
+		}
+		if i > 0 && params[i-1].Key() == param.Key() {
+			return nil, fmt.Errorf("duplicate SvcParam key: %s", SvcParamKeyToString(param.Key()))
+		}
+		msg = binary.BigEndian.AppendUint16(msg, param.Key())
+		lengthOffset := len(msg)
+		msg = append(msg, 0, 0)
+		var err error
+		if msg, err = param.pack(msg); err != nil {
+			return nil, err
+		}
+		length := len(msg) - lengthOffset - 2
+		if length > 0xFFFF {
+			return nil, fmt.Errorf("SvcParam %s is longer than 65535 bytes", SvcParamKeyToString(param.Key()))
+		}
+		binary.BigEndian.PutUint16(msg[lengthOffset:], uint16(length))
+	}
+	if err := rd.checkMandatory(); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// unpack rejects the parameters which are not in strictly increasing order of keys,
+// or which miss a mandatory key, as such records are malformed.
+func (rd *SVCB) unpack(msg []byte, off, end int) error {
+	if end-off < 3 {
+		return fmt.Errorf("invalid service binding record length: %d", end-off)
+	}
+	rd.Priority = binary.BigEndian.Uint16(msg[off : off+2])
+	target, off, err := unpackRDataName(msg, off+2, end)
+	if err != nil {
+		return err
+	}
+	rd.Target = target
+	rd.Params = nil
+	for off < end {
+		if end-off < 4 {
+			return fmt.Errorf("SvcParam exceeds the resource data")
+		}
+		key := binary.BigEndian.Uint16(msg[off : off+2])
+		length := int(binary.BigEndian.Uint16(msg[off+2 : off+4]))
+		off += 4
+		if off+length > end {
+			return fmt.Errorf("SvcParam %s exceeds the resource data", SvcParamKeyToString(key))
+		}
+		if len(rd.Params) > 0 && rd.Params[len(rd.Params)-1].Key() >= key {
+			return fmt.Errorf("SvcParam %s is duplicate or out of order", SvcParamKeyToString(key))
+		}
+		param := newSvcParam(key)
+		if err := param.unpack(msg[off : off+length]); err != nil {
+			return err
+		}
+		rd.Params = append(rd.Params, param)
+		off += length
+	}
+	return rd.checkMandatory()
+}
+
+// parse accepts the parameters in any order, and the values split from their key by the quotes: key="value".
+// It rejects the records which are not self-consistent, such as no-default-alpn without alpn.
+func (rd *SVCB) parse(tokens []zoneToken, origin string) error {
+	if len(tokens) < 2 {
+		return fmt.Errorf("expected at least 2 fields, got %d", len(tokens))
+	}
+	priority, err := parseUint(tokens[0], 16)
+	if err != nil {
+		return err
+	}
+	rd.Priority = uint16(priority)
+	if rd.Target, err = parseZoneName(tokens[1].text, origin); err != nil {
+		return err
+	}
+
+	rd.Params = nil
+	for i := 2; i < len(tokens); i++ {
+		if tokens[i].quoted {
+			return fmt.Errorf("SvcParam without key: %q", tokens[i].text)
+		}
+		name, value, hasValue := strings.Cut(tokens[i].text, "=")
+		if hasValue && value == "" && i+1 < len(tokens) && tokens[i+1].quoted {
+			value = tokens[i+1].text
+			i++
+		}
+		key, err := svcParamKeyFromString(name)
+		if err != nil {
+			return err
+		}
+		if rd.Param(key) != nil {
+			return fmt.Errorf("duplicate SvcParam key: %s", name)
+		}
+		if value, err = unescapeCharacterString(value); err != nil {
+			return err
+		}
+		param := newSvcParam(key)
+		if err := param.parse(value); err != nil {
+			return err
+		}
+		rd.Params = append(rd.Params, param)
+	}
+	rd.Params = rd.sortedParams()
+
+	if rd.Param(SvcParamNoDefaultALPN) != nil && rd.Param(SvcParamALPN) == nil {
+		return fmt.Errorf("no-default-alpn requires alpn")
+	}
+	return rd.checkMandatory()
+}
+
+// HTTPS is the resource data of an HTTPS record, the SVCB record of the https and http schemes,
+// which is looked up at the domain name of the URL rather than at a prefixed name.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-9 for more information
+type HTTPS struct {
+	SVCB
+}
+
+// Type returns TypeHTTPS.
+func (rd *HTTPS) Type() uint16 { return TypeHTTPS }
+
+// Equal reports whether other is an HTTPS record with the same priority, target and parameters.
+func (rd *HTTPS) Equal(other RData) bool {
+	o, ok := other.(*HTTPS)
+	return ok && rd.equal(&o.SVCB)
+}
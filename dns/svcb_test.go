@@ -0,0 +1,160 @@
+package dns
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// svcbVectors are the test vectors of RFC 9460 appendix D: the presentation format of the resource data,
+// the wire format and the canonical presentation format.
+var svcbVectors = []struct {
+	rType        uint16
+	presentation string
+	wire         string
+	canonical    string
+}{
+	{TypeHTTPS, `0 foo.example.com.`, "\x00\x00\x03foo\x07example\x03com\x00", `0 foo.example.com.`},
+	{TypeSVCB, `1 .`, "\x00\x01\x00", `1 .`},
+	{TypeSVCB, `16 foo.example.com. port=53`, "\x00\x10\x03foo\x07example\x03com\x00\x00\x03\x00\x02\x00\x35", `16 foo.example.com. port=53`},
+	{TypeSVCB, `1 foo.example.com. key667=hello`, "\x00\x01\x03foo\x07example\x03com\x00\x02\x9b\x00\x05hello", `1 foo.example.com. key667="hello"`},
+	{TypeSVCB, `1 foo.example.com. key667="hello\210qoo"`, "\x00\x01\x03foo\x07example\x03com\x00\x02\x9b\x00\x09hello\xd2qoo", `1 foo.example.com. key667="hello\210qoo"`},
+	{TypeSVCB, `1 foo.example.com. ipv6hint="2001:db8::1,2001:db8::53:1"`,
+		"\x00\x01\x03foo\x07example\x03com\x00\x00\x06\x00\x20" +
+			"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01" +
+			"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x53\x00\x01",
+		`1 foo.example.com. ipv6hint=2001:db8::1,2001:db8::53:1`},
+	{TypeSVCB, `1 example.com. ipv6hint="2001:db8:122:344::192.0.2.33"`,
+		"\x00\x01\x07example\x03com\x00\x00\x06\x00\x10\x20\x01\x0d\xb8\x01\x22\x03\x44\x00\x00\x00\x00\xc0\x00\x02\x21",
+		`1 example.com. ipv6hint=2001:db8:122:344::c000:221`},
+	{TypeSVCB, `16 foo.example.org. (alpn=h2,h3-19 mandatory=ipv4hint,alpn ipv4hint=192.0.2.1)`,
+		"\x00\x10\x03foo\x07example\x03org\x00" +
+			"\x00\x00\x00\x04\x00\x01\x00\x04" +
+			"\x00\x01\x00\x09\x02h2\x05h3-19" +
+			"\x00\x04\x00\x04\xc0\x00\x02\x01",
+		`16 foo.example.org. mandatory=alpn,ipv4hint alpn="h2,h3-19" ipv4hint=192.0.2.1`},
+	{TypeSVCB, `16 foo.example.org. alpn="f\\\\oo\\,bar,h2"`,
+		"\x00\x10\x03foo\x07example\x03org\x00\x00\x01\x00\x0c\x08f\\oo,bar\x02h2",
+		`16 foo.example.org. alpn="f\\\\oo\\,bar,h2"`},
+	{TypeSVCB, `16 foo.example.org. alpn=f\\\092oo\092,bar,h2`,
+		"\x00\x10\x03foo\x07example\x03org\x00\x00\x01\x00\x0c\x08f\\oo,bar\x02h2",
+		`16 foo.example.org. alpn="f\\\\oo\\,bar,h2"`},
+}
+
+func TestSVCB(t *testing.T) {
+	t.Run("Should parse, pack and print the test vectors of RFC 9460", func(t *testing.T) {
+		for _, vector := range svcbVectors {
+			tokens := lexRData(t, vector.presentation)
+			data, err := parseRData(vector.rType, tokens, "")
+			if !assert.NoError(t, err, vector.presentation) {
+				continue
+			}
+			rData, err := PackRData(data)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte(vector.wire), rData, vector.presentation)
+			assert.Equal(t, vector.canonical, data.String())
+
+			unpacked, err := UnpackRData(vector.rType, []byte(vector.wire))
+			assert.NoError(t, err)
+			assert.True(t, data.Equal(unpacked), vector.presentation)
+			reparsed, err := parseRData(vector.rType, lexRData(t, unpacked.String()), "")
+			assert.NoError(t, err)
+			assert.True(t, data.Equal(reparsed), unpacked.String())
+		}
+	})
+
+	t.Run("Should reject the failure cases of RFC 9460", func(t *testing.T) {
+		invalid := []string{
+			`1 foo.example.com. key123=abc key123=def`,
+			`1 foo.example.com. mandatory`,
+			`1 foo.example.com. alpn`,
+			`1 foo.example.com. port`,
+			`1 foo.example.com. ipv4hint`,
+			`1 foo.example.com. ipv6hint`,
+			`1 foo.example.com. no-default-alpn=abc`,
+			`1 foo.example.com. mandatory=key123`,
+			`1 foo.example.com. mandatory=mandatory`,
+			`1 foo.example.com. mandatory=key123,key123 key123=abc`,
+			`1 foo.example.com. no-default-alpn`,
+			`1 foo.example.com. key65535=abc`,
+			`1 foo.example.com. key01=abc`,
+			`1 foo.example.com. ipv4hint=2001:db8::1`,
+			`1 foo.example.com. ech=not-base64!`,
+			`1 foo.example.com. "alpn=h2"`,
+			`1`,
+		}
+		for _, presentation := range invalid {
+			_, err := parseRData(TypeSVCB, lexRData(t, presentation), "")
+			assert.Error(t, err, presentation)
+		}
+	})
+
+	t.Run("Should reject malformed resource data", func(t *testing.T) {
+		invalid := []string{
+			"\x00\x01",
+			"\x00\x01\x00\x00\x03\x00",
+			"\x00\x01\x00\x00\x03\x00\x02\x01",
+			"\x00\x01\x00\x00\x03\x00\x02\x01\xbb\x00\x01\x00\x03\x02h2",
+			"\x00\x01\x00\x00\x01\x00\x03\x02h2\x00\x01\x00\x03\x02h3",
+			"\x00\x01\x00\x00\x00\x00\x02\x00\x03",
+			"\x00\x01\x00\x00\x04\x00\x03\x01\x02\x03",
+			"\x00\x01\x00\x00\x01\x00\x00",
+		}
+		for _, wire := range invalid {
+			_, err := UnpackRData(TypeHTTPS, []byte(wire))
+			assert.Error(t, err, "%x", wire)
+		}
+	})
+
+	t.Run("Should build a record with typed parameters", func(t *testing.T) {
+		record := &HTTPS{SVCB{Priority: 1}}
+		record.SetParam(&IPv4HintParam{Addresses: []netip.Addr{netip.MustParseAddr("192.0.2.1")}})
+		record.SetParam(&ALPNParam{Protocols: []string{"h3"}})
+		record.SetParam(&ALPNParam{Protocols: []string{"h2", "h3"}})
+		record.SetParam(&ECHParam{ConfigList: []byte{0, 1, 2}})
+		assert.Equal(t, `1 . alpn="h2,h3" ipv4hint=192.0.2.1 ech=AAEC`, record.String())
+		assert.Equal(t, TypeHTTPS, record.Type())
+		assert.Equal(t, &ALPNParam{Protocols: []string{"h2", "h3"}}, record.Param(SvcParamALPN))
+		assert.Nil(t, record.Param(SvcParamPort))
+		assert.False(t, record.IsAliasMode())
+		assert.Equal(t, "example.com", record.TargetName("example.com"))
+
+		svcb := &SVCB{Priority: 1, Params: record.Params}
+		assert.False(t, record.Equal(svcb))
+		assert.True(t, svcb.Equal(&record.SVCB))
+
+		_, err := PackRData(&SVCB{Priority: 1, Params: []SvcParam{&PortParam{Port: 1}, &PortParam{Port: 2}}})
+		assert.Error(t, err)
+		_, err = PackRData(&SVCB{Priority: 1, Params: []SvcParam{&MandatoryParam{Keys: []uint16{SvcParamPort}}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should follow the target of AliasMode records", func(t *testing.T) {
+		alias := &SVCB{Priority: 0, Target: "svc.example.net"}
+		assert.True(t, alias.IsAliasMode())
+		assert.Equal(t, "svc.example.net", alias.TargetName("example.com"))
+		assert.Equal(t, "", (&SVCB{}).TargetName("example.com"))
+	})
+
+	t.Run("Should read and write the records in zone files", func(t *testing.T) {
+		zone := "$TTL 300\n@ HTTPS 1 . alpn=\"h3,h2\" ipv4hint=192.0.2.1 ( port=8443 )\n_dns SVCB 1 dns key65000\n"
+		records, err := ParseZone(strings.NewReader(zone), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, "example.com.\t300\tIN\tHTTPS\t1 . alpn=\"h3,h2\" port=8443 ipv4hint=192.0.2.1", records[0].String())
+			assert.Equal(t, "_dns.example.com.\t300\tIN\tSVCB\t1 dns.example.com. key65000", records[1].String())
+		}
+	})
+}
+
+// lexRData splits the presentation format of resource data in fields.
+func lexRData(t *testing.T, presentation string) []zoneToken {
+	entries, err := lexZone([]byte(presentation), "")
+	assert.NoError(t, err)
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries[0].tokens
+}
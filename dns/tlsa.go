@@ -0,0 +1,133 @@
+package dns
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TLSA certificate usages, which tell how the certificate of the server is checked against the record.
+const (
+	TLSAUsagePKIXTA uint8 = 0 // a CA of the chain, which must also be trusted by the client
+	TLSAUsagePKIXEE uint8 = 1 // the certificate of the server, which must also be trusted by the client
+	TLSAUsageDANETA uint8 = 2 // a trust anchor of the chain, trusted because of the record
+	TLSAUsageDANEEE uint8 = 3 // the certificate of the server, trusted because of the record
+)
+
+// TLSA selectors, the parts of the certificate that are matched.
+const (
+	TLSASelectorCertificate    uint8 = 0 // the full certificate
+	TLSASelectorSubjectKeyInfo uint8 = 1 // the SubjectPublicKeyInfo of the certificate
+)
+
+// TLSA matching types, the forms of the selected data held by the record.
+const (
+	TLSAMatchingFull   uint8 = 0 // the selected data itself
+	TLSAMatchingSHA256 uint8 = 1 // the SHA-256 digest of the selected data
+	TLSAMatchingSHA512 uint8 = 2 // the SHA-512 digest of the selected data
+)
+
+// TLSA is the resource data of a TLSA record, which associates a certificate or a public key with the TLS server
+// of the owner, a name such as _443._tcp.www.example.com: DNS-Based Authentication of Named Entities (DANE).
+// The records must be validated with DNSSEC to be trusted.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6698#section-2.1 for more information
+type TLSA struct {
+	Usage           uint8  // Usage tells how the certificate of the server is checked, such as TLSAUsageDANEEE.
+	Selector        uint8  // Selector is the part of the certificate that is matched, such as TLSASelectorSubjectKeyInfo.
+	MatchingType    uint8  // MatchingType is the form of the data, such as TLSAMatchingSHA256.
+	CertificateData []byte // CertificateData is the selected data, or its digest.
+}
+
+// Type returns TypeTLSA.
+func (rd *TLSA) Type() uint16 { return TypeTLSA }
+
+// String returns the usage, selector and matching type followed by the certificate data in uppercase hex.
+func (rd *TLSA) String() string {
+	return fmt.Sprintf("%d %d %d %s", rd.Usage, rd.Selector, rd.MatchingType, strings.ToUpper(hex.EncodeToString(rd.CertificateData)))
+}
+
+// Equal reports whether other is a TLSA record with the same fields.
+func (rd *TLSA) Equal(other RData) bool {
+	o, ok := other.(*TLSA)
+	return ok && rd.Usage == o.Usage && rd.Selector == o.Selector && rd.MatchingType == o.MatchingType &&
+		bytes.Equal(rd.CertificateData, o.CertificateData)
+}
+
+// Matches reports whether the certificate data is the one of the certificate, according to the selector and
+// the matching type. The usage is left to the caller, which decides which certificate of the chain to match
+// and whether it must also be trusted. It fails if the selector or the matching type is not supported.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6698#section-2.1.2 for more information
+func (rd *TLSA) Matches(cert *x509.Certificate) (bool, error) {
+	var selected []byte
+	switch rd.Selector {
+	case TLSASelectorCertificate:
+		selected = cert.Raw
+	case TLSASelectorSubjectKeyInfo:
+		selected = cert.RawSubjectPublicKeyInfo
+	default:
+		return false, fmt.Errorf("unsupported TLSA selector: %d", rd.Selector)
+	}
+	switch rd.MatchingType {
+	case TLSAMatchingFull:
+	case TLSAMatchingSHA256:
+		sum := sha256.Sum256(selected)
+		selected = sum[:]
+	case TLSAMatchingSHA512:
+		sum := sha512.Sum512(selected)
+		selected = sum[:]
+	default:
+		return false, fmt.Errorf("unsupported TLSA matching type: %d", rd.MatchingType)
+	}
+	return bytes.Equal(rd.CertificateData, selected), nil
+}
+
+// TLSAName returns the owner name of the TLSA records of the TLS server at the given port and transport
+// protocol of the host, such as _443._tcp.www.example.com for tcp.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6698#section-3 for more information
+func TLSAName(port uint16, protocol, host string) string {
+	return fmt.Sprintf("_%d._%s.%s", port, protocol, trimFQDN(host))
+}
+
+func (rd *TLSA) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	msg = append(msg, rd.Usage, rd.Selector, rd.MatchingType)
+	return append(msg, rd.CertificateData...), nil
+}
+
+func (rd *TLSA) unpack(msg []byte, off, end int) error {
+	if end-off < 3 {
+		return rdataLengthError(TypeTLSA, end-off)
+	}
+	rd.Usage = msg[off]
+	rd.Selector = msg[off+1]
+	rd.MatchingType = msg[off+2]
+	rd.CertificateData = bytes.Clone(msg[off+3 : end])
+	return nil
+}
+
+// parse accepts certificate data split over several fields.
+func (rd *TLSA) parse(tokens []zoneToken, _ string) error {
+	if len(tokens) < 4 {
+		return fmt.Errorf("expected at least 4 fields, got %d", len(tokens))
+	}
+	var fields [3]uint8
+	for i := range fields {
+		value, err := parseUint(tokens[i], 8)
+		if err != nil {
+			return err
+		}
+		fields[i] = uint8(value)
+	}
+	data, err := parseHex(tokens[3:])
+	if err != nil {
+		return err
+	}
+	rd.Usage, rd.Selector, rd.MatchingType, rd.CertificateData = fields[0], fields[1], fields[2], data
+	return nil
+}
@@ -0,0 +1,85 @@
+package dns
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSA(t *testing.T) {
+	newCertificate := func() *x509.Certificate {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "www.example.com"},
+			NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		assert.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		assert.NoError(t, err)
+		return cert
+	}
+
+	t.Run("Should pack, unpack and print a TLSA record", func(t *testing.T) {
+		record := &TLSA{Usage: TLSAUsageDANEEE, Selector: TLSASelectorSubjectKeyInfo, MatchingType: TLSAMatchingSHA256, CertificateData: []byte{0xAB, 0xCD}}
+		rData, err := PackRData(record)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{3, 1, 1, 0xAB, 0xCD}, rData)
+
+		unpacked, err := UnpackRData(TypeTLSA, rData)
+		assert.NoError(t, err)
+		assert.True(t, record.Equal(unpacked))
+		assert.Equal(t, "3 1 1 ABCD", unpacked.String())
+
+		_, err = UnpackRData(TypeTLSA, []byte{3, 1})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should match the certificate data with a certificate", func(t *testing.T) {
+		cert, other := newCertificate(), newCertificate()
+		spkiDigest := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		certDigest := sha512.Sum512(cert.Raw)
+		for _, record := range []*TLSA{
+			{Usage: TLSAUsageDANEEE, Selector: TLSASelectorSubjectKeyInfo, MatchingType: TLSAMatchingSHA256, CertificateData: spkiDigest[:]},
+			{Usage: TLSAUsageDANEEE, Selector: TLSASelectorCertificate, MatchingType: TLSAMatchingSHA512, CertificateData: certDigest[:]},
+			{Usage: TLSAUsagePKIXEE, Selector: TLSASelectorCertificate, MatchingType: TLSAMatchingFull, CertificateData: cert.Raw},
+		} {
+			matches, err := record.Matches(cert)
+			assert.NoError(t, err)
+			assert.True(t, matches, record.String())
+			matches, err = record.Matches(other)
+			assert.NoError(t, err)
+			assert.False(t, matches, record.String())
+		}
+
+		_, err := (&TLSA{Selector: 2}).Matches(cert)
+		assert.Error(t, err)
+		_, err = (&TLSA{MatchingType: 3}).Matches(cert)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should read TLSA records in zone files", func(t *testing.T) {
+		assert.Equal(t, "_443._tcp.www.example.com", TLSAName(443, "tcp", "www.example.com."))
+
+		zone := "_443._tcp.www 300 TLSA 3 1 1 ( d2abde240d7cd3ee6b4b28c54df034b9\n 7983a1d16e8a410e4561cb106618e971 )\n"
+		records, err := ParseZone(strings.NewReader(zone), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, "_443._tcp.www.example.com", records[0].Name)
+			assert.Equal(t, "3 1 1 D2ABDE240D7CD3EE6B4B28C54DF034B97983A1D16E8A410E4561CB106618E971", records[0].RDataParsed)
+		}
+
+		for _, invalid := range []string{"@ 300 TLSA 3 1 1", "@ 300 TLSA 3 1 256 00", "@ 300 TLSA 3 1 1 0"} {
+			_, err := ParseZone(strings.NewReader(invalid), "example.com.", "")
+			assert.Error(t, err, invalid)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package dns
+
+import "sync"
+
+// packBuffers holds the buffers in which Len packs the messages, which are reused across calls.
+var packBuffers = sync.Pool{New: func() any { b := make([]byte, 0, DefaultEDNSUDPSize); return &b }}
+
+// Len returns the length of the message in its wire format, with its domain names compressed as Pack does.
+// It returns 0 if the message can not be packed.
+func (m *DNSMessage) Len() int {
+	buf := packBuffers.Get().(*[]byte)
+	defer packBuffers.Put(buf)
+	msg, err := m.AppendPack((*buf)[:0])
+	if err != nil {
+		return 0
+	}
+	*buf = msg
+	return len(msg)
+}
+
+// Truncate drops whole RRsets from the end of the message until its wire format fits in maxSize octets:
+// those of the additional section first, then those of the authority section, then those of the answer section.
+// The TC bit is set if RRsets of the answer section were dropped, which tells the client to retry over TCP.
+// The OPT pseudo-record is always kept, and the header counts are kept up to date.
+// Sizes lower than 512 octets, which every client can receive over UDP, are treated as 512.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2181#section-9 for more information
+func (m *DNSMessage) Truncate(maxSize int) {
+	if maxSize < MaxUDPSize {
+		maxSize = MaxUDPSize
+	}
+	if m.Len() <= maxSize {
+		return
+	}
+
+	// The OPT pseudo-record is set aside, so that it is never dropped with the additional RRsets
+	var additionalRRs, opt []ResourceRecord
+	for _, rr := range m.AdditionalRRs {
+		if rr.Type == TypeOPT {
+			opt = append(opt, rr)
+		} else {
+			additionalRRs = append(additionalRRs, rr)
+		}
+	}
+	setAdditional := func(records []ResourceRecord) {
+		additionalRRs = records
+		m.AdditionalRRs = append(append(make([]ResourceRecord, 0, len(records)+len(opt)), records...), opt...)
+		m.Header.ARCount = uint16(len(m.AdditionalRRs))
+	}
+	setAdditional(additionalRRs)
+
+	for len(additionalRRs) > 0 && m.Len() > maxSize {
+		setAdditional(dropLastRRset(additionalRRs))
+	}
+	for len(m.AuthorityRRs) > 0 && m.Len() > maxSize {
+		m.AuthorityRRs = dropLastRRset(m.AuthorityRRs)
+		m.Header.NSCount = uint16(len(m.AuthorityRRs))
+	}
+	truncated := false
+	for len(m.Answers) > 0 && m.Len() > maxSize {
+		m.Answers = dropLastRRset(m.Answers)
+		m.Header.ANCount = uint16(len(m.Answers))
+		truncated = true
+	}
+	if truncated {
+		m.updateFlags(func(flags *HeaderFlag) { flags.TC = true })
+	}
+}
+
+// rrsetKey identifies the RRset of a record. The RRSIG records belong to the RRset they cover.
+type rrsetKey struct {
+	name  string
+	class uint16
+	rType uint16
+}
+
+// newRRsetKey returns the key of the RRset of the record.
+func newRRsetKey(rr *ResourceRecord) rrsetKey {
+	rType := rr.Type
+	if sig, ok := rr.Data.(*RRSIG); ok {
+		rType = sig.TypeCovered
+	}
+	return rrsetKey{name: canonicalName(rr.Name), class: rr.Class, rType: rType}
+}
+
+// dropLastRRset returns the records without those of the RRset of the last record, keeping their order.
+func dropLastRRset(records []ResourceRecord) []ResourceRecord {
+	last := newRRsetKey(&records[len(records)-1])
+	kept := make([]ResourceRecord, 0, len(records)-1)
+	for i := range records {
+		if newRRsetKey(&records[i]) != last {
+			kept = append(kept, records[i])
+		}
+	}
+	return kept
+}
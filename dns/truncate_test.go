@@ -0,0 +1,97 @@
+package dns
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// addresses returns count A records owned by the name.
+func addresses(name string, count int) []ResourceRecord {
+	records := make([]ResourceRecord, count)
+	for i := range records {
+		records[i] = *NewResourceRecord(name, TypeA, ClassIN, 300, 4, []byte{192, 0, 2, byte(i)})
+	}
+	return records
+}
+
+func TestTruncate(t *testing.T) {
+	t.Run("Should return the length of the message with its names compressed", func(t *testing.T) {
+		message, err := ParseMessage(parserTestMessage())
+		assert.NoError(t, err)
+		assert.Equal(t, len(message.ToBytes()), message.Len())
+		assert.Less(t, message.Len(), len(message.ToBytesUncompressed()))
+
+		query := NewQuery("www..example", TypeA)
+		assert.Equal(t, 0, query.Len(), "a message that can not be packed has no length")
+	})
+
+	t.Run("Should keep the messages that fit", func(t *testing.T) {
+		message, err := ParseMessage(parserTestMessage())
+		assert.NoError(t, err)
+		expected := message.ToBytes()
+		message.Truncate(100)
+		assert.Equal(t, expected, message.ToBytes(), "the sizes lower than 512 octets are treated as 512")
+	})
+
+	t.Run("Should drop the additional RRsets before the authority ones and keep the OPT record", func(t *testing.T) {
+		query := NewQuery("www.example.com", TypeA).WithEDNS(1232)
+		ns, err := NewResourceRecordWithData("example.com", ClassIN, 3600, &NS{Host: "ns1.example.com"})
+		assert.NoError(t, err)
+		response := query.Reply().
+			AddAnswer(addresses("www.example.com", 4)...).
+			AddAuthority(*ns).
+			AddAdditional(addresses("ns1.example.com", 20)...).
+			AddAdditional(addresses("ns2.example.com", 20)...)
+		response.SetEDNS(query.EDNS())
+
+		response.Truncate(512)
+		assert.LessOrEqual(t, response.Len(), 512)
+		assert.Len(t, response.Answers, 4)
+		assert.Len(t, response.AuthorityRRs, 1)
+		assert.Equal(t, addresses("ns1.example.com", 20), response.AdditionalRRs[:20], "the last RRset is dropped as a whole")
+		assert.Len(t, response.AdditionalRRs, 21)
+		assert.Equal(t, uint16(TypeOPT), response.AdditionalRRs[20].Type)
+		assert.Equal(t, uint16(21), response.Header.ARCount)
+		assert.False(t, HeaderFlagFromUint16(response.Header.Flags).TC, "the answer was not truncated")
+	})
+
+	t.Run("Should drop the answer RRsets with their signatures and set the TC bit", func(t *testing.T) {
+		query := NewQuery("www.example.com", TypeA).WithEDNS(1232).WithDO()
+		cname, err := NewResourceRecordWithData("www.example.com", ClassIN, 300, &CNAME{Target: "web.example.com"})
+		assert.NoError(t, err)
+		sig, err := NewResourceRecordWithData("web.example.com", ClassIN, 300, &RRSIG{
+			TypeCovered: TypeA, Algorithm: 13, Labels: 3, OriginalTTL: 300, SignerName: "example.com", Signature: make([]byte, 64),
+		})
+		assert.NoError(t, err)
+		response := query.Reply().AddAnswer(*cname).AddAnswer(addresses("web.example.com", 40)...).AddAnswer(*sig)
+		response.SetEDNS(query.EDNS())
+
+		response.Truncate(512)
+		assert.Equal(t, []ResourceRecord{*cname}, response.Answers)
+		assert.Equal(t, uint16(1), response.Header.ANCount)
+		assert.True(t, HeaderFlagFromUint16(response.Header.Flags).TC)
+		assert.NotNil(t, response.EDNS())
+		assert.True(t, response.EDNS().DO)
+
+		parsed, err := ParseMessage(response.ToBytes())
+		assert.NoError(t, err)
+		assert.Equal(t, response.Header, parsed.Header)
+	})
+
+	t.Run("Should fit the large responses in the EDNS payload size", func(t *testing.T) {
+		for _, size := range []int{512, 1232, 4096} {
+			response := NewQuery("example.com", TypeTXT).WithEDNS(uint16(size)).Reply()
+			for i := 0; i < 200; i++ {
+				txt, err := NewResourceRecordWithData(fmt.Sprintf("host%d.example.com", i), ClassIN, 300, &TXT{Strings: []string{"v=spf1 -all"}})
+				assert.NoError(t, err)
+				response.AddAnswer(*txt)
+			}
+			response.Truncate(int(response.EDNS().UDPSize))
+			assert.LessOrEqual(t, response.Len(), size)
+			assert.Greater(t, response.Len(), size-64, "only the RRsets that do not fit are dropped")
+			assert.Equal(t, int(response.Header.ANCount), len(response.Answers))
+		}
+	})
+}
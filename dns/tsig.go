@@ -0,0 +1,309 @@
+package dns
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// TSIG error codes, stored in the Error field of the TSIG record. BADSIG shares its value with BADVERS,
+// which is only used in the OPT pseudo-record.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-5.3 for more information
+const (
+	RCodeBadSig   uint16 = 16 // Bad signature - The MAC of the message does not verify
+	RCodeBadKey   uint16 = 17 // Bad key - The key of the message is not known, or its algorithm does not match
+	RCodeBadTime  uint16 = 18 // Bad time - The time signed of the message is outside of the fudge
+	RCodeBadTrunc uint16 = 22 // Bad truncation - The MAC of the message is truncated too much
+)
+
+// HMAC algorithms of TSIG keys, as named in the TSIG records and the key files.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-6 for more information
+const (
+	HmacSHA1   = "hmac-sha1"
+	HmacSHA256 = "hmac-sha256"
+	HmacSHA512 = "hmac-sha512"
+)
+
+// tsigAlgorithms are the hash functions of the supported HMAC algorithms.
+var tsigAlgorithms = map[string]func() hash.Hash{
+	HmacSHA1:   sha1.New,
+	HmacSHA256: sha256.New,
+	HmacSHA512: sha512.New,
+}
+
+// DefaultTSIGFudge is the number of seconds by which the time signed of a message may differ from the time
+// it is verified, as recommended by RFC 8945.
+const DefaultTSIGFudge = 300
+
+// TSIG is the resource data of a TSIG record, the signature of a message with a shared secret key.
+// The record is owned by the name of the key, in the ANY class with a TTL of 0, and ends the additional section.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-4.2 for more information
+type TSIG struct {
+	Algorithm  string // Algorithm is the name of the HMAC algorithm, such as hmac-sha256.
+	TimeSigned uint64 // TimeSigned is the time at which the message was signed, in seconds since the epoch, on 48 bits.
+	Fudge      uint16 // Fudge is the number of seconds by which TimeSigned may differ from the time of the verification.
+	MAC        []byte // MAC is the message authentication code, which may be truncated.
+	OriginalID uint16 // OriginalID is the ID of the message when it was signed.
+	Error      uint16 // Error is the TSIG error code, such as RCodeBadTime, of a response to a request that did not verify.
+	OtherData  []byte // OtherData holds the time of the server in the BADTIME responses, and is empty otherwise.
+}
+
+// Type returns TypeTSIG.
+func (rd *TSIG) Type() uint16 { return TypeTSIG }
+
+// String returns the fields of the signature as printed by dig, with the MAC and the other data in base64.
+func (rd *TSIG) String() string {
+	s := fmt.Sprintf("%s %d %d %d %s %d %s %d", fqdn(rd.Algorithm), rd.TimeSigned, rd.Fudge, len(rd.MAC),
+		base64.StdEncoding.EncodeToString(rd.MAC), rd.OriginalID, TSIGErrorToString(rd.Error), len(rd.OtherData))
+	if len(rd.OtherData) > 0 {
+		s += " " + base64.StdEncoding.EncodeToString(rd.OtherData)
+	}
+	return s
+}
+
+// Equal reports whether other is a TSIG record with the same fields.
+func (rd *TSIG) Equal(other RData) bool {
+	o, ok := other.(*TSIG)
+	return ok && equalNames(rd.Algorithm, o.Algorithm) && rd.TimeSigned == o.TimeSigned && rd.Fudge == o.Fudge &&
+		bytes.Equal(rd.MAC, o.MAC) && rd.OriginalID == o.OriginalID && rd.Error == o.Error && bytes.Equal(rd.OtherData, o.OtherData)
+}
+
+// pack never compresses the algorithm name, as required by RFC 8945.
+func (rd *TSIG) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	if rd.TimeSigned >= 1<<48 {
+		return nil, fmt.Errorf("TSIG time signed does not fit in 48 bits: %d", rd.TimeSigned)
+	}
+	if len(rd.MAC) > 0xFFFF || len(rd.OtherData) > 0xFFFF {
+		return nil, fmt.Errorf("TSIG MAC or other data too long")
+	}
+	msg, err := appendName(msg, rd.Algorithm, nil)
+	if err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, uint16(rd.TimeSigned>>32))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(rd.TimeSigned))
+	msg = binary.BigEndian.AppendUint16(msg, rd.Fudge)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(rd.MAC)))
+	msg = append(msg, rd.MAC...)
+	msg = binary.BigEndian.AppendUint16(msg, rd.OriginalID)
+	msg = binary.BigEndian.AppendUint16(msg, rd.Error)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(rd.OtherData)))
+	return append(msg, rd.OtherData...), nil
+}
+
+func (rd *TSIG) unpack(msg []byte, off, end int) error {
+	algorithm, off, err := unpackRDataName(msg, off, end)
+	if err != nil {
+		return err
+	}
+	if end-off < 10 {
+		return rdataLengthError(TypeTSIG, end-off)
+	}
+	rd.Algorithm = algorithm
+	rd.TimeSigned = uint64(binary.BigEndian.Uint16(msg[off:]))<<32 | uint64(binary.BigEndian.Uint32(msg[off+2:]))
+	rd.Fudge = binary.BigEndian.Uint16(msg[off+6:])
+	macSize := int(binary.BigEndian.Uint16(msg[off+8:]))
+	off += 10
+	if end-off < macSize+6 {
+		return rdataLengthError(TypeTSIG, end-off)
+	}
+	rd.MAC = bytes.Clone(msg[off : off+macSize])
+	off += macSize
+	rd.OriginalID = binary.BigEndian.Uint16(msg[off:])
+	rd.Error = binary.BigEndian.Uint16(msg[off+2:])
+	otherLen := int(binary.BigEndian.Uint16(msg[off+4:]))
+	off += 6
+	if end-off != otherLen {
+		return rdataLengthError(TypeTSIG, end-off)
+	}
+	rd.OtherData = nil
+	if otherLen > 0 {
+		rd.OtherData = bytes.Clone(msg[off:end])
+	}
+	return nil
+}
+
+// parse always fails: TSIG records only exist in messages, not in zone files.
+func (rd *TSIG) parse(_ []zoneToken, _ string) error {
+	return fmt.Errorf("TSIG records can not appear in zone files")
+}
+
+// TSIGErrorToString returns the mnemonic of the TSIG error code, such as BADSIG, or the one of the response code
+// it shares its value with.
+func TSIGErrorToString(code uint16) string {
+	switch code {
+	case RCodeBadSig:
+		return "BADSIG"
+	case RCodeBadKey:
+		return "BADKEY"
+	case RCodeBadTime:
+		return "BADTIME"
+	case RCodeBadTrunc:
+		return "BADTRUNC"
+	default:
+		return RCodeToString(code)
+	}
+}
+
+// TSIGKey is a secret key shared by two parties to sign the messages they exchange with TSIG.
+type TSIGKey struct {
+	Name      string // Name is the name of the key, which owns the TSIG records, such as transfer.example.com.
+	Algorithm string // Algorithm is the HMAC algorithm of the key: HmacSHA1, HmacSHA256 or HmacSHA512.
+	Secret    []byte // Secret is the shared secret.
+}
+
+// newHMAC returns the HMAC of the algorithm of the key, keyed with its secret.
+func (k *TSIGKey) newHMAC() (hash.Hash, error) {
+	newHash, ok := tsigAlgorithms[strings.ToLower(trimFQDN(k.Algorithm))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedTSIGAlgorithm, k.Algorithm)
+	}
+	return hmac.New(newHash, k.Secret), nil
+}
+
+// ParseTSIGKeys parses the key statements of a BIND configuration or key file, as written by tsig-keygen,
+// and returns their keys. The other statements are ignored.
+//
+//	key "transfer.example.com" {
+//		algorithm hmac-sha256;
+//		secret "Zm9vYmFyYmF6cXV4cXV1eGZvb2Jhcg==";
+//	};
+//
+// The comments may be written as /* */, // or #.
+func ParseTSIGKeys(r io.Reader) ([]TSIGKey, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := lexKeyFile(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []TSIGKey
+	for i := 0; i < len(tokens); {
+		// Find the next statement: a keyword followed by arguments and a block or a semicolon
+		if tokens[i] != "key" || i+2 >= len(tokens) || tokens[i+2] != "{" {
+			i = skipKeyFileStatement(tokens, i)
+			continue
+		}
+		key := TSIGKey{Name: trimFQDN(tokens[i+1])}
+		if _, err := NewName(key.Name); err != nil {
+			return nil, fmt.Errorf("invalid key name %q: %w", key.Name, err)
+		}
+		i += 3
+		var secret string
+		for i < len(tokens) && tokens[i] != "}" {
+			if i+2 >= len(tokens) || tokens[i+2] != ";" {
+				return nil, fmt.Errorf("key %s: expected a semicolon after %s", key.Name, tokens[i])
+			}
+			switch tokens[i] {
+			case "algorithm":
+				key.Algorithm = strings.ToLower(trimFQDN(tokens[i+1]))
+			case "secret":
+				secret = tokens[i+1]
+			default:
+				return nil, fmt.Errorf("key %s: unknown clause %s", key.Name, tokens[i])
+			}
+			i += 3
+		}
+		if i+1 >= len(tokens) || tokens[i+1] != ";" {
+			return nil, fmt.Errorf("key %s: expected }; at the end of the statement", key.Name)
+		}
+		i += 2
+
+		if _, ok := tsigAlgorithms[key.Algorithm]; !ok {
+			return nil, fmt.Errorf("key %s: %w: %q", key.Name, ErrUnsupportedTSIGAlgorithm, key.Algorithm)
+		}
+		if key.Secret, err = base64.StdEncoding.DecodeString(secret); err != nil || len(key.Secret) == 0 {
+			return nil, fmt.Errorf("key %s: invalid base64 secret", key.Name)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// LoadTSIGKeyFile parses the key file at the given path and returns its keys, see ParseTSIGKeys.
+func LoadTSIGKeyFile(path string) ([]TSIGKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	keys, err := ParseTSIGKeys(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// lexKeyFile splits a BIND configuration into tokens: the words and quoted strings, without their quotes,
+// and the { } ; punctuation. The comments are removed.
+func lexKeyFile(text string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '#' || strings.HasPrefix(text[i:], "//"):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+		case c == '{' || c == '}' || c == ';':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			end := strings.IndexByte(text[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted string")
+			}
+			tokens = append(tokens, text[i+1:i+1+end])
+			i += end + 2
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\r\n{};\"#", rune(text[i])) {
+				i++
+			}
+			tokens = append(tokens, text[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+// skipKeyFileStatement returns the index of the token following the statement starting at i,
+// skipping its nested blocks.
+func skipKeyFileStatement(tokens []string, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		case ";":
+			if depth <= 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
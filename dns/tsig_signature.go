@@ -0,0 +1,374 @@
+package dns
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupportedTSIGAlgorithm is returned for the TSIG keys whose HMAC algorithm is not supported.
+var ErrUnsupportedTSIGAlgorithm = errors.New("unsupported TSIG algorithm")
+
+// ErrNotSigned is returned when verifying a message that has no TSIG record.
+var ErrNotSigned = errors.New("the message is not signed with TSIG")
+
+// Errors of the TSIG verification, wrapped in a *TSIGError.
+var (
+	ErrBadSig   = errors.New("BADSIG: the MAC of the message does not verify")
+	ErrBadKey   = errors.New("BADKEY: the key of the message is not known")
+	ErrBadTime  = errors.New("BADTIME: the message was not signed within the fudge")
+	ErrBadTrunc = errors.New("BADTRUNC: the MAC of the message is truncated too much")
+)
+
+// maxUnsignedMessages is the number of messages of a multi-message response that may follow each other unsigned.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-5.3.1 for more information
+const maxUnsignedMessages = 99
+
+// TSIGError describes why the TSIG record of a message did not verify, or the error reported by the other party
+// in the TSIG record of its response.
+type TSIGError struct {
+	Code    uint16 // Code is the TSIG error code: RCodeBadSig, RCodeBadKey, RCodeBadTime or RCodeBadTrunc.
+	Remote  bool   // Remote indicates that the error was reported by the other party, which rejected the request.
+	KeyName string // KeyName is the name of the key of the message.
+	Record  *TSIG  // Record is the TSIG record of the message.
+}
+
+// Error returns the description of the TSIG error, mentioning whether it was reported by the other party.
+func (e *TSIGError) Error() string {
+	if e.Remote {
+		return fmt.Sprintf("the request signed with the key %s was rejected: %v", fqdn(e.KeyName), e.Unwrap())
+	}
+	return fmt.Sprintf("key %s: %v", fqdn(e.KeyName), e.Unwrap())
+}
+
+// Unwrap returns the error of the code, such as ErrBadSig, so that errors.Is can be used on a TSIGError.
+func (e *TSIGError) Unwrap() error {
+	switch e.Code {
+	case RCodeBadSig:
+		return ErrBadSig
+	case RCodeBadKey:
+		return ErrBadKey
+	case RCodeBadTime:
+		return ErrBadTime
+	case RCodeBadTrunc:
+		return ErrBadTrunc
+	default:
+		return fmt.Errorf("TSIG error %s", TSIGErrorToString(e.Code))
+	}
+}
+
+// Sign appends to the message in its wire format the TSIG record signing it with the key, at the time now,
+// and returns the signed message and its MAC. requestMAC is the MAC of the signed request when signing
+// its response, and nil when signing a request. The message must not already have a TSIG record.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-5.1 for more information
+func (k *TSIGKey) Sign(msg []byte, requestMAC []byte, now time.Time) ([]byte, []byte, error) {
+	record := &TSIG{TimeSigned: uint64(now.Unix()), Fudge: DefaultTSIGFudge}
+	signed, err := k.sign(msg, requestMAC, nil, record, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signed, record.MAC, nil
+}
+
+// Verify verifies the TSIG record ending the message in its wire format with the key, at the time now,
+// and returns its MAC, see VerifyTSIG.
+func (k *TSIGKey) Verify(msg []byte, requestMAC []byte, now time.Time) ([]byte, error) {
+	_, record, err := VerifyTSIG(msg, []TSIGKey{*k}, requestMAC, now)
+	if err != nil {
+		return nil, err
+	}
+	return record.MAC, nil
+}
+
+// VerifyTSIG verifies the TSIG record ending the message in its wire format with the key of the same name
+// and algorithm, at the time now, and returns the key and the record. requestMAC is the MAC of the request
+// when verifying its response, and nil when verifying a request.
+//
+// It fails with ErrNotSigned if the message has no TSIG record, and with a *TSIGError if the key is not
+// known (BADKEY), the MAC does not verify (BADSIG) or is truncated too much (BADTRUNC), or the message was
+// not signed within the fudge (BADTIME). A server answers the requests that fail with a *TSIGError with
+// SignTSIGError. The errors reported by the server in the TSIG record of a response are returned as
+// *TSIGError values too, whose Remote field is set.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-5.2 for more information
+func VerifyTSIG(msg []byte, keys []TSIGKey, requestMAC []byte, now time.Time) (*TSIGKey, *TSIG, error) {
+	return verifyTSIG(msg, keys, requestMAC, nil, false, now)
+}
+
+// SignTSIGError appends to the response in its wire format the TSIG record answering a request that failed
+// the verification with the error, and sets its response code to NOTAUTH. The response to a request signed
+// out of the fudge is signed with the key, with the time signed of the request, and holds the time of the server
+// in the other data of its record.
+// The other responses are not signed, as the key or the MAC of the request can not be trusted.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-5.3.2 for more information
+func SignTSIGError(response []byte, verifyErr *TSIGError, keys []TSIGKey, now time.Time) ([]byte, error) {
+	if len(response) < headerLength {
+		return nil, ErrShortHeader
+	}
+	response = append([]byte(nil), response...)
+	flags := binary.BigEndian.Uint16(response[2:])
+	binary.BigEndian.PutUint16(response[2:], flags&^0xF|uint16(RCodeNotAuth))
+
+	record := &TSIG{TimeSigned: uint64(now.Unix()), Fudge: DefaultTSIGFudge, Error: verifyErr.Code}
+	key := TSIGKey{Name: verifyErr.KeyName}
+	if verifyErr.Record != nil {
+		key.Algorithm = verifyErr.Record.Algorithm
+	}
+	if verifyErr.Code != RCodeBadTime || verifyErr.Record == nil {
+		record.Algorithm = key.Algorithm
+		record.OriginalID = binary.BigEndian.Uint16(response)
+		return appendTSIG(response, key.Name, record)
+	}
+	signingKey := findTSIGKey(keys, key.Name, key.Algorithm)
+	if signingKey == nil {
+		return nil, fmt.Errorf("no key %s to sign the BADTIME response", fqdn(key.Name))
+	}
+	// The time signed is the one of the request, so that the client can verify the response despite its clock
+	record.OtherData = binary.BigEndian.AppendUint16(nil, uint16(record.TimeSigned>>32))
+	record.OtherData = binary.BigEndian.AppendUint32(record.OtherData, uint32(record.TimeSigned))
+	record.TimeSigned = verifyErr.Record.TimeSigned
+	return signingKey.sign(response, verifyErr.Record.MAC, nil, record, false)
+}
+
+// TSIGStream signs or verifies the messages of a multi-message response, such as a zone transfer.
+// The first message is signed as a single response, with the MAC of the request, and each following message
+// with the MAC of the previous signed message and the timers only.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-5.3.1 for more information
+type TSIGStream struct {
+	key      TSIGKey
+	prior    []byte // prior is the MAC of the request, then the MAC of the last signed message.
+	started  bool   // started indicates that the first message was signed or verified.
+	unsigned []byte // unsigned holds the messages received since the last signed one, which its MAC covers.
+	count    int    // count is the number of messages received since the last signed one.
+}
+
+// NewTSIGStream creates a TSIGStream for the response to the request of the given MAC, with the key of the request.
+func NewTSIGStream(key TSIGKey, requestMAC []byte) *TSIGStream {
+	return &TSIGStream{key: key, prior: requestMAC}
+}
+
+// Sign signs the next message of the response at the time now, and returns the signed message.
+// Every message is signed.
+func (s *TSIGStream) Sign(msg []byte, now time.Time) ([]byte, error) {
+	record := &TSIG{TimeSigned: uint64(now.Unix()), Fudge: DefaultTSIGFudge}
+	signed, err := s.key.sign(msg, s.prior, nil, record, s.started)
+	if err != nil {
+		return nil, err
+	}
+	s.prior, s.started = record.MAC, true
+	return signed, nil
+}
+
+// Verify verifies the next message of the response at the time now. The first message must be signed,
+// and the following ones may be unsigned, up to 99 in a row, in which case they are verified along with
+// the next signed message. Done tells whether the last message was signed.
+func (s *TSIGStream) Verify(msg []byte, now time.Time) error {
+	_, record, err := verifyTSIG(msg, []TSIGKey{s.key}, s.prior, s.unsigned, s.started, now)
+	if errors.Is(err, ErrNotSigned) && s.started {
+		if s.count >= maxUnsignedMessages {
+			return fmt.Errorf("%w: %d messages in a row", ErrNotSigned, s.count+1)
+		}
+		s.unsigned = append(s.unsigned, msg...)
+		s.count++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.prior, s.started, s.unsigned, s.count = record.MAC, true, nil, 0
+	return nil
+}
+
+// Done returns ErrNotSigned if messages were verified since the last signed one, in which case the response
+// is not authenticated as a whole.
+func (s *TSIGStream) Done() error {
+	if !s.started || s.count > 0 {
+		return ErrNotSigned
+	}
+	return nil
+}
+
+// sign appends the TSIG record signing the message, and the unsigned messages preceding it, with the key.
+// The fields of the record other than the algorithm, the original ID and the MAC are set by the caller.
+func (k *TSIGKey) sign(msg, prior, preceding []byte, record *TSIG, timersOnly bool) ([]byte, error) {
+	if len(msg) < headerLength {
+		return nil, ErrShortHeader
+	}
+	record.Algorithm = k.Algorithm
+	record.OriginalID = binary.BigEndian.Uint16(msg)
+	mac, err := k.mac(prior, preceding, msg, record, timersOnly)
+	if err != nil {
+		return nil, err
+	}
+	record.MAC = mac
+	return appendTSIG(msg, k.Name, record)
+}
+
+// appendTSIG returns a copy of the message with the TSIG record owned by the key name appended to its
+// additional section.
+func appendTSIG(msg []byte, keyName string, record *TSIG) ([]byte, error) {
+	signed := append(make([]byte, 0, len(msg)+128), msg...)
+	signed, err := appendName(signed, keyName, nil)
+	if err != nil {
+		return nil, err
+	}
+	signed = binary.BigEndian.AppendUint16(signed, TypeTSIG)
+	signed = binary.BigEndian.AppendUint16(signed, ClassAll)
+	signed = binary.BigEndian.AppendUint32(signed, 0)
+	rdLengthOffset := len(signed)
+	signed = append(signed, 0, 0)
+	if signed, err = record.pack(signed, nil); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(signed[rdLengthOffset:], uint16(len(signed)-rdLengthOffset-2))
+	arCount := binary.BigEndian.Uint16(signed[10:])
+	binary.BigEndian.PutUint16(signed[10:], arCount+1)
+	return signed, nil
+}
+
+// mac computes the MAC of the message, preceded by the prior MAC, if any, and by the unsigned messages,
+// and followed by the TSIG variables of the record, or only its timers if timersOnly is set.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8945#section-4.3 for more information
+func (k *TSIGKey) mac(prior, preceding, msg []byte, record *TSIG, timersOnly bool) ([]byte, error) {
+	h, err := k.newHMAC()
+	if err != nil {
+		return nil, err
+	}
+	if prior != nil {
+		h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(prior))))
+		h.Write(prior)
+	}
+	h.Write(preceding)
+	h.Write(msg)
+
+	var variables []byte
+	if !timersOnly {
+		// The names are in their canonical form: uncompressed and lowered
+		if variables, err = appendName(variables, canonicalName(k.Name), nil); err != nil {
+			return nil, err
+		}
+		variables = binary.BigEndian.AppendUint16(variables, ClassAll)
+		variables = binary.BigEndian.AppendUint32(variables, 0)
+		if variables, err = appendName(variables, canonicalName(record.Algorithm), nil); err != nil {
+			return nil, err
+		}
+	}
+	variables = binary.BigEndian.AppendUint16(variables, uint16(record.TimeSigned>>32))
+	variables = binary.BigEndian.AppendUint32(variables, uint32(record.TimeSigned))
+	variables = binary.BigEndian.AppendUint16(variables, record.Fudge)
+	if !timersOnly {
+		variables = binary.BigEndian.AppendUint16(variables, record.Error)
+		variables = binary.BigEndian.AppendUint16(variables, uint16(len(record.OtherData)))
+		variables = append(variables, record.OtherData...)
+	}
+	h.Write(variables)
+	return h.Sum(nil), nil
+}
+
+// verifyTSIG verifies the TSIG record ending the message, whose MAC covers the prior MAC and the unsigned
+// messages preceding it, see VerifyTSIG.
+func verifyTSIG(msg []byte, keys []TSIGKey, prior, preceding []byte, timersOnly bool, now time.Time) (*TSIGKey, *TSIG, error) {
+	keyName, record, offset, err := findTSIG(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	verifyErr := func(code uint16) error {
+		return &TSIGError{Code: code, KeyName: keyName, Record: record}
+	}
+	if record.Error != 0 && len(record.MAC) == 0 {
+		// The other party rejected the request without signing its response
+		return nil, nil, &TSIGError{Code: record.Error, Remote: true, KeyName: keyName, Record: record}
+	}
+	key := findTSIGKey(keys, keyName, record.Algorithm)
+	if key == nil {
+		return nil, nil, verifyErr(RCodeBadKey)
+	}
+
+	// The MAC covers the message as it was before the TSIG record was added, with its original ID
+	unsigned := append([]byte(nil), msg[:offset]...)
+	binary.BigEndian.PutUint16(unsigned, record.OriginalID)
+	binary.BigEndian.PutUint16(unsigned[10:], binary.BigEndian.Uint16(unsigned[10:])-1)
+	expected, err := key.mac(prior, preceding, unsigned, record, timersOnly)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(record.MAC) > len(expected) {
+		return nil, nil, fmt.Errorf("TSIG MAC longer than the output of %s: %d octets", key.Algorithm, len(record.MAC))
+	}
+	if len(record.MAC) < max(10, len(expected)/2) {
+		return nil, nil, verifyErr(RCodeBadTrunc)
+	}
+	if !hmac.Equal(record.MAC, expected[:len(record.MAC)]) {
+		return nil, nil, verifyErr(RCodeBadSig)
+	}
+
+	// The time is checked once the MAC verified, so that it can be trusted
+	signed := time.Unix(int64(record.TimeSigned), 0)
+	if delta := now.Sub(signed).Abs(); delta > time.Duration(record.Fudge)*time.Second {
+		return nil, nil, verifyErr(RCodeBadTime)
+	}
+	if record.Error != 0 {
+		return nil, nil, &TSIGError{Code: record.Error, Remote: true, KeyName: keyName, Record: record}
+	}
+	return key, record, nil
+}
+
+// findTSIG returns the key name and the TSIG record ending the message, and the offset of the record.
+// It fails with ErrNotSigned if the message has no TSIG record, and if the TSIG record is not the last record.
+func findTSIG(msg []byte) (string, *TSIG, int, error) {
+	var p Parser
+	if _, err := p.Start(msg); err != nil {
+		return "", nil, 0, err
+	}
+	var last RawRecord
+	found := false
+	for section := SectionAnswer; section <= SectionAdditional; section++ {
+		for {
+			raw, err := p.Record(section)
+			if errors.Is(err, ErrSectionDone) {
+				break
+			}
+			if err != nil {
+				return "", nil, 0, err
+			}
+			if found {
+				return "", nil, 0, fmt.Errorf("the TSIG record is not the last record of the message")
+			}
+			if raw.Type == TypeTSIG {
+				if section != SectionAdditional {
+					return "", nil, 0, fmt.Errorf("TSIG record in the %s section", sectionNames[section])
+				}
+				last, found = raw, true
+			}
+		}
+	}
+	if !found {
+		return "", nil, 0, ErrNotSigned
+	}
+	rr, err := last.Record()
+	if err != nil {
+		return "", nil, 0, err
+	}
+	record, ok := rr.Data.(*TSIG)
+	if !ok || rr.Class != ClassAll || rr.TTL != 0 {
+		return "", nil, 0, fmt.Errorf("invalid TSIG record: %s", rr)
+	}
+	return rr.Name, record, last.Name.Offset, nil
+}
+
+// findTSIGKey returns the key of the given name and algorithm, or nil if there is none.
+func findTSIGKey(keys []TSIGKey, name, algorithm string) *TSIGKey {
+	for i := range keys {
+		if equalNames(keys[i].Name, name) && equalNames(keys[i].Algorithm, algorithm) {
+			return &keys[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package dns
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSIGSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	key := TSIGKey{Name: "transfer.example.com", Algorithm: HmacSHA256, Secret: []byte("foobarbazquxquuxfoobar")}
+	query := func() []byte {
+		return NewQuery("example.com", TypeSOA).ToBytes()
+	}
+
+	t.Run("Should compute the MAC over the message and the TSIG variables", func(t *testing.T) {
+		msg := query()
+		signed, mac, err := key.Sign(msg, nil, now)
+		assert.NoError(t, err)
+		assert.Equal(t, msg[:10], signed[:10])
+		assert.Equal(t, uint16(1), binary.BigEndian.Uint16(signed[10:]))
+
+		h := hmac.New(sha256.New, key.Secret)
+		h.Write(msg)
+		h.Write(append([]byte{8}, "transfer"...))
+		h.Write(append([]byte{7}, "example"...))
+		h.Write(append([]byte{3}, "com"...))
+		h.Write([]byte{0, 0, 255, 0, 0, 0, 0})
+		h.Write(append([]byte{11}, "hmac-sha256"...))
+		h.Write([]byte{0, 0, 0, 0x65, 0x53, 0xF1, 0, 1, 0x2C, 0, 0, 0, 0})
+		assert.Equal(t, h.Sum(nil), mac)
+
+		parsed, err := ParseMessage(signed)
+		assert.NoError(t, err)
+		rr := parsed.AdditionalRRs[0]
+		assert.Equal(t, "transfer.example.com", rr.Name)
+		assert.Equal(t, uint16(ClassAll), rr.Class)
+		assert.Equal(t, &TSIG{Algorithm: HmacSHA256, TimeSigned: 1700000000, Fudge: DefaultTSIGFudge, MAC: mac,
+			OriginalID: parsed.Header.ID}, rr.Data)
+	})
+
+	t.Run("Should sign and verify the requests and their responses with every supported algorithm", func(t *testing.T) {
+		for _, algorithm := range []string{HmacSHA1, HmacSHA256, HmacSHA512} {
+			key := TSIGKey{Name: "transfer.example.com", Algorithm: algorithm, Secret: []byte("secret")}
+			msg := query()
+			request, requestMAC, err := key.Sign(msg, nil, now)
+			assert.NoError(t, err)
+			verifiedKey, record, err := VerifyTSIG(request, []TSIGKey{{Name: "other", Algorithm: algorithm}, key}, nil, now.Add(time.Minute))
+			assert.NoError(t, err, algorithm)
+			assert.Equal(t, key, *verifiedKey)
+			assert.Equal(t, requestMAC, record.MAC)
+
+			parsed, err := ParseMessage(msg)
+			assert.NoError(t, err)
+			response, responseMAC, err := key.Sign(parsed.Reply().ToBytes(), requestMAC, now)
+			assert.NoError(t, err)
+			mac, err := key.Verify(response, requestMAC, now)
+			assert.NoError(t, err, algorithm)
+			assert.Equal(t, responseMAC, mac)
+
+			_, err = key.Verify(response, nil, now)
+			assert.True(t, errors.Is(err, ErrBadSig), "the MAC of a response covers the MAC of its request")
+		}
+	})
+
+	t.Run("Should verify the messages whose ID was changed by a forwarder", func(t *testing.T) {
+		signed, _, err := key.Sign(query(), nil, now)
+		assert.NoError(t, err)
+		binary.BigEndian.PutUint16(signed, binary.BigEndian.Uint16(signed)+1)
+		_, err = key.Verify(signed, nil, now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should report the TSIG errors of the messages that do not verify", func(t *testing.T) {
+		signed, _, err := key.Sign(query(), nil, now)
+		assert.NoError(t, err)
+
+		tampered := append([]byte(nil), signed...)
+		tampered[headerLength+1] ^= 0x20
+		_, err = key.Verify(tampered, nil, now)
+		var tsigErr *TSIGError
+		assert.True(t, errors.As(err, &tsigErr))
+		assert.Equal(t, RCodeBadSig, tsigErr.Code)
+		assert.False(t, tsigErr.Remote)
+		assert.Equal(t, "transfer.example.com", tsigErr.KeyName)
+		assert.True(t, errors.Is(err, ErrBadSig))
+
+		other := TSIGKey{Name: "other.example.com", Algorithm: HmacSHA256, Secret: key.Secret}
+		_, _, err = VerifyTSIG(signed, []TSIGKey{other}, nil, now)
+		assert.True(t, errors.Is(err, ErrBadKey))
+		sha512Key := key
+		sha512Key.Algorithm = HmacSHA512
+		_, err = sha512Key.Verify(signed, nil, now)
+		assert.True(t, errors.Is(err, ErrBadKey), "the algorithm of the key must match")
+		wrongSecret := key
+		wrongSecret.Secret = []byte("other")
+		_, err = wrongSecret.Verify(signed, nil, now)
+		assert.True(t, errors.Is(err, ErrBadSig))
+
+		_, err = key.Verify(signed, nil, now.Add((DefaultTSIGFudge+1)*time.Second))
+		assert.True(t, errors.Is(err, ErrBadTime))
+		_, err = key.Verify(signed, nil, now.Add(-(DefaultTSIGFudge+1)*time.Second))
+		assert.True(t, errors.Is(err, ErrBadTime))
+
+		_, err = key.Verify(query(), nil, now)
+		assert.True(t, errors.Is(err, ErrNotSigned))
+	})
+
+	t.Run("Should verify the truncated MACs that are long enough", func(t *testing.T) {
+		msg := query()
+		_, mac, err := key.Sign(msg, nil, now)
+		assert.NoError(t, err)
+		truncated := func(size int) []byte {
+			record := &TSIG{Algorithm: HmacSHA256, TimeSigned: 1700000000, Fudge: DefaultTSIGFudge, MAC: mac[:size],
+				OriginalID: binary.BigEndian.Uint16(msg)}
+			signed, err := appendTSIG(msg, key.Name, record)
+			assert.NoError(t, err)
+			return signed
+		}
+		_, err = key.Verify(truncated(16), nil, now)
+		assert.NoError(t, err)
+		_, err = key.Verify(truncated(15), nil, now)
+		assert.True(t, errors.Is(err, ErrBadTrunc))
+	})
+
+	t.Run("Should reject the TSIG records that are not the last record", func(t *testing.T) {
+		signed, _, err := key.Sign(query(), nil, now)
+		assert.NoError(t, err)
+		message, err := ParseMessage(signed)
+		assert.NoError(t, err)
+		message.AddAdditional(*NewResourceRecord("example.com", TypeA, ClassIN, 300, 4, []byte{192, 0, 2, 1}))
+		_, err = key.Verify(message.ToBytes(), nil, now)
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrNotSigned))
+	})
+
+	t.Run("Should answer the requests that do not verify with the TSIG error", func(t *testing.T) {
+		request, requestMAC, err := key.Sign(query(), nil, now.Add(-time.Hour))
+		assert.NoError(t, err)
+		_, _, verifyErr := VerifyTSIG(request, []TSIGKey{key}, nil, now)
+		var tsigErr *TSIGError
+		assert.True(t, errors.As(verifyErr, &tsigErr))
+		parsed, err := ParseMessage(request)
+		assert.NoError(t, err)
+		reply := parsed.Reply()
+		reply.AdditionalRRs, reply.Header.ARCount = nil, 0
+
+		// The BADTIME response is signed, and verified by the client whose clock is late
+		response, err := SignTSIGError(reply.ToBytes(), tsigErr, []TSIGKey{key}, now)
+		assert.NoError(t, err)
+		_, err = key.Verify(response, requestMAC, now.Add(-time.Hour))
+		assert.True(t, errors.As(err, &tsigErr))
+		assert.True(t, tsigErr.Remote)
+		assert.True(t, errors.Is(err, ErrBadTime))
+		assert.Equal(t, []byte{0, 0, 0x65, 0x53, 0xF1, 0}, tsigErr.Record.OtherData, "the time of the server")
+		parsedResponse, err := ParseMessage(response)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(RCodeNotAuth), parsedResponse.RCode())
+
+		// The BADKEY response is not signed
+		unknown := TSIGKey{Name: "unknown.example.com", Algorithm: HmacSHA256, Secret: []byte("secret")}
+		request, requestMAC, err = unknown.Sign(query(), nil, now)
+		assert.NoError(t, err)
+		_, _, verifyErr = VerifyTSIG(request, []TSIGKey{key}, nil, now)
+		assert.True(t, errors.As(verifyErr, &tsigErr))
+		response, err = SignTSIGError(reply.ToBytes(), tsigErr, []TSIGKey{key}, now)
+		assert.NoError(t, err)
+		_, err = unknown.Verify(response, requestMAC, now)
+		assert.True(t, errors.As(err, &tsigErr))
+		assert.True(t, tsigErr.Remote)
+		assert.True(t, errors.Is(err, ErrBadKey))
+		assert.Empty(t, tsigErr.Record.MAC)
+		assert.Contains(t, err.Error(), "the request signed with the key unknown.example.com. was rejected")
+	})
+
+	t.Run("Should sign and verify the messages of a multi-message response", func(t *testing.T) {
+		_, requestMAC, err := key.Sign(query(), nil, now)
+		assert.NoError(t, err)
+		messages := make([][]byte, 4)
+		for i := range messages {
+			messages[i] = NewQuery("example.com", TypeAXFR).Reply().AddAnswer(addresses("example.com", i+1)...).ToBytes()
+		}
+
+		signer := NewTSIGStream(key, requestMAC)
+		verifier := NewTSIGStream(key, requestMAC)
+		for _, msg := range messages {
+			signed, err := signer.Sign(msg, now)
+			assert.NoError(t, err)
+			assert.NoError(t, verifier.Verify(signed, now))
+		}
+		assert.NoError(t, verifier.Done())
+
+		// Only the first and last messages are signed: the MAC of the last one covers the others
+		signer = NewTSIGStream(key, requestMAC)
+		first, err := signer.Sign(messages[0], now)
+		assert.NoError(t, err)
+		last, err := key.sign(messages[3], signer.prior, append(append([]byte(nil), messages[1]...), messages[2]...),
+			&TSIG{TimeSigned: uint64(now.Unix()), Fudge: DefaultTSIGFudge}, true)
+		assert.NoError(t, err)
+
+		verifier = NewTSIGStream(key, requestMAC)
+		assert.NoError(t, verifier.Verify(first, now))
+		assert.NoError(t, verifier.Verify(messages[1], now))
+		assert.NoError(t, verifier.Verify(messages[2], now))
+		assert.True(t, errors.Is(verifier.Done(), ErrNotSigned))
+		assert.NoError(t, verifier.Verify(last, now))
+		assert.NoError(t, verifier.Done())
+
+		verifier = NewTSIGStream(key, requestMAC)
+		assert.NoError(t, verifier.Verify(first, now))
+		assert.NoError(t, verifier.Verify(messages[2], now))
+		assert.True(t, errors.Is(verifier.Verify(last, now), ErrBadSig), "the unsigned messages were not received")
+
+		verifier = NewTSIGStream(key, requestMAC)
+		assert.True(t, errors.Is(verifier.Verify(messages[0], now), ErrNotSigned), "the first message must be signed")
+		assert.NoError(t, verifier.Verify(first, now))
+		for i := 0; i < maxUnsignedMessages; i++ {
+			assert.NoError(t, verifier.Verify(messages[1], now))
+		}
+		assert.True(t, errors.Is(verifier.Verify(messages[1], now), ErrNotSigned))
+	})
+}
@@ -0,0 +1,113 @@
+package dns
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSIG(t *testing.T) {
+	t.Run("Should pack, unpack and print a TSIG record", func(t *testing.T) {
+		record := &TSIG{Algorithm: "hmac-sha256", TimeSigned: 1700000000, Fudge: 300, MAC: []byte{1, 2, 3, 4}, OriginalID: 0xABCD}
+		rData, err := PackRData(record)
+		assert.NoError(t, err)
+		expected := append([]byte{11}, "hmac-sha256"...)
+		expected = append(expected, 0, 0, 0, 0x65, 0x53, 0xF1, 0, 1, 0x2C, 0, 4, 1, 2, 3, 4, 0xAB, 0xCD, 0, 0, 0, 0)
+		assert.Equal(t, expected, rData)
+
+		unpacked, err := UnpackRData(TypeTSIG, rData)
+		assert.NoError(t, err)
+		assert.True(t, record.Equal(unpacked))
+		assert.Equal(t, "hmac-sha256. 1700000000 300 4 AQIDBA== 43981 NOERROR 0", unpacked.String())
+
+		record.Error, record.OtherData = RCodeBadTime, []byte{0, 0, 0x65, 0x53, 0xF1, 0}
+		assert.Equal(t, "hmac-sha256. 1700000000 300 4 AQIDBA== 43981 BADTIME 6 AABlU/EA", record.String())
+	})
+
+	t.Run("Should reject the TSIG records that are not valid", func(t *testing.T) {
+		rData, err := PackRData(&TSIG{Algorithm: "hmac-sha256", MAC: []byte{1, 2, 3, 4}})
+		assert.NoError(t, err)
+		_, err = UnpackRData(TypeTSIG, rData[:len(rData)-1])
+		assert.Error(t, err)
+		_, err = UnpackRData(TypeTSIG, append(rData, 0))
+		assert.Error(t, err)
+		_, err = PackRData(&TSIG{Algorithm: "hmac-sha256", TimeSigned: 1 << 48})
+		assert.Error(t, err)
+
+		_, err = ParseZone(strings.NewReader("key 0 ANY TSIG hmac-sha256. 1700000000 300 0 0 NOERROR 0"), "example.com.", "")
+		assert.Error(t, err, "TSIG records do not appear in zone files")
+	})
+
+	t.Run("Should name the TSIG errors", func(t *testing.T) {
+		for code, name := range map[uint16]string{
+			RCodeBadSig:   "BADSIG",
+			RCodeBadKey:   "BADKEY",
+			RCodeBadTime:  "BADTIME",
+			RCodeBadTrunc: "BADTRUNC",
+			0:             "NOERROR",
+		} {
+			assert.Equal(t, name, TSIGErrorToString(code))
+		}
+		assert.Equal(t, "TSIG", RTypeToString(TypeTSIG))
+	})
+
+	t.Run("Should parse the keys of a BIND configuration", func(t *testing.T) {
+		config := `
+# Generated by tsig-keygen
+key "transfer.example.com." {
+	algorithm hmac-sha256;
+	secret "Zm9vYmFyYmF6cXV4cXV1eGZvb2Jhcg==";
+};
+
+options {
+	directory "/var/named"; // The other statements are ignored
+	allow-transfer { key transfer.example.com; };
+};
+
+/* The key of the updates */
+key update.example.com {
+	algorithm HMAC-SHA512; secret "c2VjcmV0";
+};
+`
+		keys, err := ParseTSIGKeys(strings.NewReader(config))
+		assert.NoError(t, err)
+		assert.Equal(t, []TSIGKey{
+			{Name: "transfer.example.com", Algorithm: HmacSHA256, Secret: []byte("foobarbazquxquuxfoobar")},
+			{Name: "update.example.com", Algorithm: HmacSHA512, Secret: []byte("secret")},
+		}, keys)
+	})
+
+	t.Run("Should reject the key statements that are not valid", func(t *testing.T) {
+		for _, config := range []string{
+			`key k { algorithm hmac-md5; secret "c2VjcmV0"; };`,
+			`key k { algorithm hmac-sha256; secret "not base64"; };`,
+			`key k { algorithm hmac-sha256; };`,
+			`key k { algorithm hmac-sha256; secret "c2VjcmV0" };`,
+			`key k { algorithm hmac-sha256; secret "c2VjcmV0"; owner admin; };`,
+			`key k { algorithm hmac-sha256; secret "c2VjcmV0"; }`,
+			`key "a..b" { algorithm hmac-sha256; secret "c2VjcmV0"; };`,
+			`key k { algorithm hmac-sha256; secret "c2VjcmV0`,
+			`/* key k { algorithm hmac-sha256; secret "c2VjcmV0"; };`,
+		} {
+			_, err := ParseTSIGKeys(strings.NewReader(config))
+			assert.Error(t, err, config)
+		}
+		_, err := ParseTSIGKeys(strings.NewReader(`key k { algorithm hmac-md5; secret "c2VjcmV0"; };`))
+		assert.True(t, errors.Is(err, ErrUnsupportedTSIGAlgorithm))
+	})
+
+	t.Run("Should load the keys of a key file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "transfer.key")
+		assert.NoError(t, os.WriteFile(path, []byte(`key "transfer" { algorithm hmac-sha1; secret "c2VjcmV0"; };`), 0o600))
+		keys, err := LoadTSIGKeyFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, []TSIGKey{{Name: "transfer", Algorithm: HmacSHA1, Secret: []byte("secret")}}, keys)
+
+		_, err = LoadTSIGKeyFile(filepath.Join(t.TempDir(), "missing.key"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,278 @@
+package dns
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NewUpdate creates an UPDATE message for the zone, in the IN class, with a random ID.
+// The sections of an UPDATE message reuse those of a query, along with their header counts:
+// the zone section is the question section and holds the SOA question for the zone,
+// the prerequisite section is the answer section, the update section is the authority section,
+// and the additional section is left as is. The prerequisites and updates are added with the methods
+// returning the message, which can be chained:
+//
+//	update := dns.NewUpdate("example.com").RequireNameNotInUse("host.example.com").AddRecords(record)
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2 for more information
+func NewUpdate(zone string) *DNSMessage {
+	flags := NewHeaderFlag(false, OpcodeUpdate, false, false, false, false, 0, 0).GenerateFlag()
+	header := NewHeader(RandomID(), flags, 1, 0, 0, 0)
+	return NewDNSMessage(*header, []Question{*NewQuestion(zone, TypeSOA, ClassIN)})
+}
+
+// Prerequisites returns the records of the prerequisite section of an UPDATE message.
+func (m *DNSMessage) Prerequisites() []ResourceRecord {
+	return m.Answers
+}
+
+// Updates returns the records of the update section of an UPDATE message.
+func (m *DNSMessage) Updates() []ResourceRecord {
+	return m.AuthorityRRs
+}
+
+// RequireRRsetExists adds the prerequisite that an RRset of the type exists at the name, whatever its records.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.4.1 for more information
+func (m *DNSMessage) RequireRRsetExists(name string, rType uint16) *DNSMessage {
+	return m.AddAnswer(emptyRecord(name, rType, ClassAll))
+}
+
+// RequireRRset adds the prerequisite that the RRset of the records exists and holds exactly these records.
+// The records are added in the class of the zone with a TTL of 0.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.4.2 for more information
+func (m *DNSMessage) RequireRRset(records ...ResourceRecord) *DNSMessage {
+	for _, rr := range records {
+		rr.Class, rr.TTL = m.zoneClass(), 0
+		m.AddAnswer(rr)
+	}
+	return m
+}
+
+// RequireRRsetNotExists adds the prerequisite that no RRset of the type exists at the name.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.4.3 for more information
+func (m *DNSMessage) RequireRRsetNotExists(name string, rType uint16) *DNSMessage {
+	return m.AddAnswer(emptyRecord(name, rType, ClassNone))
+}
+
+// RequireNameInUse adds the prerequisite that the name owns at least one record.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.4.4 for more information
+func (m *DNSMessage) RequireNameInUse(name string) *DNSMessage {
+	return m.AddAnswer(emptyRecord(name, TypeAll, ClassAll))
+}
+
+// RequireNameNotInUse adds the prerequisite that the name owns no record.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.4.5 for more information
+func (m *DNSMessage) RequireNameNotInUse(name string) *DNSMessage {
+	return m.AddAnswer(emptyRecord(name, TypeAll, ClassNone))
+}
+
+// AddRecords adds the update adding the records to their RRsets. The records are added in the class of the zone.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.5.1 for more information
+func (m *DNSMessage) AddRecords(records ...ResourceRecord) *DNSMessage {
+	for _, rr := range records {
+		rr.Class = m.zoneClass()
+		m.AddAuthority(rr)
+	}
+	return m
+}
+
+// DeleteRRset adds the update deleting the RRset of the type at the name.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.5.2 for more information
+func (m *DNSMessage) DeleteRRset(name string, rType uint16) *DNSMessage {
+	return m.AddAuthority(emptyRecord(name, rType, ClassAll))
+}
+
+// DeleteName adds the update deleting every RRset of the name.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.5.3 for more information
+func (m *DNSMessage) DeleteName(name string) *DNSMessage {
+	return m.AddAuthority(emptyRecord(name, TypeAll, ClassAll))
+}
+
+// DeleteRecords adds the update deleting the records from their RRsets. Their TTL is ignored.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.5.4 for more information
+func (m *DNSMessage) DeleteRecords(records ...ResourceRecord) *DNSMessage {
+	for _, rr := range records {
+		rr.Class, rr.TTL = ClassNone, 0
+		m.AddAuthority(rr)
+	}
+	return m
+}
+
+// zoneClass returns the class of the zone of an UPDATE message, IN if it has no zone section.
+func (m *DNSMessage) zoneClass() uint16 {
+	if len(m.Questions) == 0 {
+		return ClassIN
+	}
+	return m.Questions[0].QClass
+}
+
+// emptyRecord returns the record of the prerequisite or update section without resource data.
+func emptyRecord(name string, rType uint16, class uint16) ResourceRecord {
+	return ResourceRecord{Name: name, Type: rType, Class: class}
+}
+
+// ParseUpdate parses the prerequisites and updates read from r, one per line in the format of nsupdate,
+// and returns the UPDATE message for the zone holding them. Relative names are completed with the zone.
+//
+//	prereq nxdomain <name>
+//	prereq yxdomain <name>
+//	prereq nxrrset <name> [class] <type>
+//	prereq yxrrset <name> [class] <type> [<data>]
+//	update add <name> <ttl> [class] <type> <data>
+//	update delete <name> [ttl] [class] [<type> [<data>]]
+//
+// The update keyword may be omitted, and the resource data is in the format of zone files.
+// The errors are *ZoneError values holding the line of the faulty command.
+func ParseUpdate(r io.Reader, zone string) (*DNSMessage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &ZoneError{Err: err}
+	}
+	entries, err := lexZone(data, "")
+	if err != nil {
+		return nil, err
+	}
+	update := NewUpdate(zone)
+	origin := trimFQDN(update.Questions[0].Name)
+	for _, entry := range entries {
+		if err := parseUpdateCommand(update, entry.tokens, origin); err != nil {
+			return nil, &ZoneError{Line: entry.line, Err: err}
+		}
+	}
+	return update, nil
+}
+
+// parseUpdateCommand parses a prerequisite or an update, see ParseUpdate, and adds it to the message.
+func parseUpdateCommand(update *DNSMessage, tokens []zoneToken, origin string) error {
+	command := strings.ToLower(tokens[0].text)
+	if command == "update" {
+		tokens = tokens[1:]
+		if len(tokens) == 0 {
+			return fmt.Errorf("missing update command")
+		}
+		command = strings.ToLower(tokens[0].text)
+	}
+	if command == "prereq" {
+		if len(tokens) < 3 {
+			return fmt.Errorf("prereq expects a condition and a domain name")
+		}
+		command = "prereq " + strings.ToLower(tokens[1].text)
+		tokens = tokens[1:]
+	}
+	if len(tokens) < 2 {
+		return fmt.Errorf("%s expects a domain name", command)
+	}
+	name, err := parseZoneName(tokens[1].text, origin)
+	if err != nil {
+		return err
+	}
+	fields := tokens[2:]
+
+	switch command {
+	case "prereq nxdomain", "prereq yxdomain":
+		if len(fields) > 0 {
+			return fmt.Errorf("%s expects a domain name only", command)
+		}
+		if command == "prereq nxdomain" {
+			update.RequireNameNotInUse(name)
+		} else {
+			update.RequireNameInUse(name)
+		}
+	case "prereq nxrrset", "prereq yxrrset":
+		rType, data, err := parseUpdateRecord(name, fields, 0, origin)
+		if err != nil {
+			return err
+		}
+		switch {
+		case data != nil && command == "prereq yxrrset":
+			update.RequireRRset(*data)
+		case data != nil:
+			return fmt.Errorf("prereq nxrrset expects no resource data")
+		case command == "prereq yxrrset":
+			update.RequireRRsetExists(name, rType)
+		default:
+			update.RequireRRsetNotExists(name, rType)
+		}
+	case "add":
+		if len(fields) == 0 {
+			return fmt.Errorf("add expects a TTL")
+		}
+		ttl, err := parseTTL(fields[0].text)
+		if err != nil {
+			return err
+		}
+		_, data, err := parseUpdateRecord(name, fields[1:], ttl, origin)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			return fmt.Errorf("add expects resource data")
+		}
+		update.AddRecords(*data)
+	case "delete", "del":
+		if len(fields) > 0 {
+			if _, err := parseTTL(fields[0].text); err == nil {
+				fields = fields[1:] // The TTL of the deleted records is ignored
+			}
+		}
+		if len(fields) == 0 {
+			update.DeleteName(name)
+			return nil
+		}
+		rType, data, err := parseUpdateRecord(name, fields, 0, origin)
+		if err != nil {
+			return err
+		}
+		if data != nil {
+			update.DeleteRecords(*data)
+		} else {
+			update.DeleteRRset(name, rType)
+		}
+	default:
+		return fmt.Errorf("unknown update command: %s", command)
+	}
+	return nil
+}
+
+// parseUpdateRecord parses the fields following the owner of a prerequisite or an update: [class] type [data].
+// It returns the type, and the record holding the resource data if there is one.
+// The class, which must be the class of the zone if present, is ignored.
+func parseUpdateRecord(name string, fields []zoneToken, ttl uint32, origin string) (uint16, *ResourceRecord, error) {
+	if len(fields) > 0 {
+		if class, ok := classFromString(fields[0].text); ok {
+			if class != ClassIN {
+				return 0, nil, fmt.Errorf("the class %s is not the class of the zone", fields[0].text)
+			}
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return 0, nil, fmt.Errorf("missing record type")
+	}
+	rType, ok := typeFromString(fields[0].text)
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown record type: %s", fields[0].text)
+	}
+	if len(fields) == 1 {
+		return rType, nil, nil
+	}
+	data, err := parseRData(rType, fields[1:], origin)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid %s record: %w", RTypeToString(rType), err)
+	}
+	rr, err := NewResourceRecordWithData(name, ClassIN, ttl, data)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid %s record: %w", RTypeToString(rType), err)
+	}
+	return rType, rr, nil
+}
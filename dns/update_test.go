@@ -0,0 +1,134 @@
+package dns
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdate(t *testing.T) {
+	address := *NewResourceRecord("host.example.com", TypeA, ClassIN, 300, 4, []byte{192, 0, 2, 1})
+	txt, err := NewResourceRecordWithData("host.example.com", ClassIN, 300, &TXT{Strings: []string{"v=1"}})
+	assert.NoError(t, err)
+
+	t.Run("Should build the prerequisites and updates in their sections", func(t *testing.T) {
+		update := NewUpdate("example.com").
+			RequireNameInUse("host.example.com").
+			RequireRRsetNotExists("host.example.com", TypeTXT).
+			DeleteRRset("host.example.com", TypeA).
+			AddRecords(address, *txt)
+
+		flags := HeaderFlagFromUint16(update.Header.Flags)
+		assert.Equal(t, OpcodeUpdate, flags.Opcode)
+		assert.False(t, flags.QR)
+		assert.Equal(t, []Question{*NewQuestion("example.com", TypeSOA, ClassIN)}, update.Questions)
+		assert.Equal(t, []ResourceRecord{
+			{Name: "host.example.com", Type: TypeAll, Class: ClassAll},
+			{Name: "host.example.com", Type: TypeTXT, Class: ClassNone},
+		}, update.Prerequisites())
+		assert.Equal(t, []ResourceRecord{{Name: "host.example.com", Type: TypeA, Class: ClassAll}, address, *txt}, update.Updates())
+		assert.Equal(t, *NewHeader(update.Header.ID, update.Header.Flags, 1, 2, 3, 0), update.Header)
+	})
+
+	t.Run("Should write the prerequisites and updates in the classes of RFC 2136", func(t *testing.T) {
+		update := NewUpdate("example.com").
+			RequireRRsetExists("host.example.com", TypeA).
+			RequireRRset(address).
+			RequireNameNotInUse("new.example.com").
+			DeleteName("old.example.com").
+			DeleteRecords(address)
+
+		assert.Equal(t, []ResourceRecord{
+			{Name: "host.example.com", Type: TypeA, Class: ClassAll},
+			{Name: "host.example.com", Type: TypeA, Class: ClassIN, TTL: 0, RDLength: 4, RData: address.RData, RDataParsed: address.RDataParsed, Data: address.Data},
+			{Name: "new.example.com", Type: TypeAll, Class: ClassNone},
+		}, update.Prerequisites())
+		deleted := address
+		deleted.Class, deleted.TTL = ClassNone, 0
+		assert.Equal(t, []ResourceRecord{{Name: "old.example.com", Type: TypeAll, Class: ClassAll}, deleted}, update.Updates())
+
+		// The prerequisite without resource data: host.example.com ANY A with a TTL and a RDLENGTH of 0
+		packed := update.ToBytes()
+		prerequisite := []byte{4, 'h', 'o', 's', 't', 0xC0, 12, 0, 1, 0, 255, 0, 0, 0, 0, 0, 0}
+		assert.Equal(t, prerequisite, packed[headerLength+17:headerLength+17+len(prerequisite)])
+
+		parsed, err := ParseMessage(packed)
+		assert.NoError(t, err)
+		assert.Equal(t, update.Header, parsed.Header)
+		assert.Equal(t, update.Prerequisites()[0], parsed.Prerequisites()[0])
+		assert.Equal(t, update.Prerequisites()[2], parsed.Prerequisites()[2])
+		assert.Equal(t, update.Updates(), parsed.Updates())
+	})
+
+	t.Run("Should print the sections of the UPDATE messages", func(t *testing.T) {
+		update := NewUpdate("example.com").RequireNameNotInUse("host.example.com").AddRecords(address)
+		output := update.String()
+		assert.Contains(t, output, "opcode: UPDATE, status: NOERROR")
+		assert.Contains(t, output, "ZONE: 1, PREREQ: 1, UPDATE: 1, ADDITIONAL: 0")
+		assert.Contains(t, output, ";; ZONE SECTION:\n;example.com.\t\tIN\tSOA")
+		assert.Contains(t, output, ";; PREREQUISITE SECTION:\nhost.example.com.\t0\tNONE\tANY")
+		assert.Contains(t, output, ";; UPDATE SECTION:\nhost.example.com.\t300\tIN\tA\t192.0.2.1")
+	})
+
+	t.Run("Should name the response codes of the updates", func(t *testing.T) {
+		for rcode, name := range map[uint8]string{
+			RCodeYXDomain: "YXDOMAIN",
+			RCodeYXRRSet:  "YXRRSET",
+			RCodeNXRRSet:  "NXRRSET",
+			RCodeNotAuth:  "NOTAUTH",
+			RCodeNotZone:  "NOTZONE",
+		} {
+			assert.Equal(t, name, RCodeToString(uint16(rcode)))
+		}
+	})
+
+	t.Run("Should parse the commands of nsupdate", func(t *testing.T) {
+		commands := `
+; Replace the address of host, which must exist
+prereq yxdomain host
+prereq nxrrset host.example.com. IN AAAA
+prereq yxrrset host TXT "v=1"
+update delete host A
+update add host 300 IN A 192.0.2.1
+add host 1h TXT "v=1"
+delete old
+del host 300 TXT "v=1"
+`
+		update, err := ParseUpdate(strings.NewReader(commands), "example.com.")
+		assert.NoError(t, err)
+		assert.Equal(t, "example.com.", update.Questions[0].Name)
+
+		expectedTXT := *txt
+		expectedTXT.TTL = 3600
+		expected := NewUpdate("example.com.").
+			RequireNameInUse("host.example.com").
+			RequireRRsetNotExists("host.example.com", TypeAAAA).
+			RequireRRset(*txt).
+			DeleteRRset("host.example.com", TypeA).
+			AddRecords(address, expectedTXT).
+			DeleteName("old.example.com").
+			DeleteRecords(*txt)
+		assert.Equal(t, expected.Prerequisites(), update.Prerequisites())
+		assert.Equal(t, expected.Updates(), update.Updates())
+		assert.Equal(t, expected.Header.Flags, update.Header.Flags)
+	})
+
+	t.Run("Should report the line of the commands that are not valid", func(t *testing.T) {
+		for commands, line := range map[string]int{
+			"prereq yxdomain":                    1,
+			"update add host A 192.0.2.1":        1,
+			"\nadd host 300 A":                   2,
+			"add host 300 CH A 192.0.2.1":        1,
+			"prereq nxrrset host A 192.0.2.1":    1,
+			"update insert host 300 A 192.0.2.1": 1,
+			"prereq yxdomain host..example":      1,
+		} {
+			_, err := ParseUpdate(strings.NewReader(commands), "example.com")
+			var zoneErr *ZoneError
+			assert.True(t, errors.As(err, &zoneErr), commands)
+			assert.Equal(t, line, zoneErr.Line, commands)
+		}
+	})
+}
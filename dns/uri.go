@@ -0,0 +1,76 @@
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// URI is the resource data of a URI record, a URI at which the service named by the owner is available,
+// such as _ftp._tcp.example.com for ftp://ftp.example.com/public.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7553#section-4 for more information
+type URI struct {
+	Priority uint16 // Priority orders the targets, lowest first.
+	Weight   uint16 // Weight distributes the load between the targets of the same priority.
+	Target   string // Target is the URI, as defined by RFC 3986. It can not be empty.
+}
+
+// Type returns TypeURI.
+func (rd *URI) Type() uint16 { return TypeURI }
+
+// String returns the priority and weight followed by the quoted target.
+func (rd *URI) String() string {
+	return fmt.Sprintf("%d %d %s", rd.Priority, rd.Weight, quoteCharacterString(rd.Target))
+}
+
+// Equal reports whether other is a URI record with the same fields.
+func (rd *URI) Equal(other RData) bool {
+	o, ok := other.(*URI)
+	return ok && rd.Priority == o.Priority && rd.Weight == o.Weight && rd.Target == o.Target
+}
+
+// pack writes the target as is, up to the end of the resource data, as it is not a character-string.
+func (rd *URI) pack(msg []byte, _ compressionMap) ([]byte, error) {
+	if rd.Target == "" {
+		return nil, fmt.Errorf("empty URI target")
+	}
+	msg = binary.BigEndian.AppendUint16(msg, rd.Priority)
+	msg = binary.BigEndian.AppendUint16(msg, rd.Weight)
+	return append(msg, rd.Target...), nil
+}
+
+func (rd *URI) unpack(msg []byte, off, end int) error {
+	if end-off < 5 {
+		return rdataLengthError(TypeURI, end-off)
+	}
+	rd.Priority = binary.BigEndian.Uint16(msg[off : off+2])
+	rd.Weight = binary.BigEndian.Uint16(msg[off+2 : off+4])
+	rd.Target = string(msg[off+4 : end])
+	return nil
+}
+
+// parse requires the target to be quoted, as RFC 7553 does, but not to fit in 255 bytes.
+func (rd *URI) parse(tokens []zoneToken, _ string) error {
+	if err := expectTokens(tokens, 3); err != nil {
+		return err
+	}
+	for i, field := range []*uint16{&rd.Priority, &rd.Weight} {
+		value, err := parseUint(tokens[i], 16)
+		if err != nil {
+			return err
+		}
+		*field = uint16(value)
+	}
+	if !tokens[2].quoted {
+		return fmt.Errorf("the URI target must be quoted: %s", tokens[2].text)
+	}
+	target, err := unescapeCharacterString(tokens[2].text)
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("empty URI target")
+	}
+	rd.Target = target
+	return nil
+}
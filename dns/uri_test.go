@@ -0,0 +1,43 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURI(t *testing.T) {
+	t.Run("Should pack, unpack and print a URI record", func(t *testing.T) {
+		record := &URI{Priority: 10, Weight: 1, Target: "ftp://ftp1.example.com/public"}
+		rData, err := PackRData(record)
+		assert.NoError(t, err)
+		assert.Equal(t, append([]byte{0, 10, 0, 1}, "ftp://ftp1.example.com/public"...), rData)
+
+		unpacked, err := UnpackRData(TypeURI, rData)
+		assert.NoError(t, err)
+		assert.True(t, record.Equal(unpacked))
+		assert.Equal(t, `10 1 "ftp://ftp1.example.com/public"`, unpacked.String())
+	})
+
+	t.Run("Should reject URI records without target", func(t *testing.T) {
+		_, err := UnpackRData(TypeURI, []byte{0, 10, 0, 1})
+		assert.Error(t, err)
+		_, err = PackRData(&URI{Priority: 10})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should read URI records in zone files", func(t *testing.T) {
+		target := "https://www.example.com/" + strings.Repeat("a", 300)
+		records, err := ParseZone(strings.NewReader(`_http._tcp 300 URI 10 1 "`+target+`"`), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, &URI{Priority: 10, Weight: 1, Target: target}, records[0].Data)
+		}
+
+		for _, invalid := range []string{`@ 300 URI 10 1 ftp://ftp1.example.com/public`, `@ 300 URI 10 1 ""`, `@ 300 URI 10 "ftp://ftp1.example.com/public"`} {
+			_, err := ParseZone(strings.NewReader(invalid), "example.com.", "")
+			assert.Error(t, err, invalid)
+		}
+	})
+}
@@ -0,0 +1,669 @@
+package dns
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth is the maximum nesting of $INCLUDE directives, which stops inclusion loops.
+const maxIncludeDepth = 16
+
+// maxGenerateRecords is the maximum number of records a single $GENERATE directive can produce.
+const maxGenerateRecords = 65536
+
+// ZoneError describes an error found while parsing a zone file.
+type ZoneError struct {
+	File string // File is the name of the zone file, empty if the zone was not read from a file.
+	Line int    // Line is the line of the entry holding the error, starting at 1.
+	Err  error  // Err describes the error.
+}
+
+// Error returns the description of the ZoneError, prefixed with the file and the line.
+func (e *ZoneError) Error() string {
+	file := e.File
+	if file == "" {
+		file = "zone"
+	}
+	return fmt.Sprintf("%s:%d: %v", file, e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error so that errors.Is can be used on a ZoneError.
+func (e *ZoneError) Unwrap() error {
+	return e.Err
+}
+
+// zoneToken is a field of a zone file entry.
+type zoneToken struct {
+	text   string // text is the text of the field, with its escapes and without its quotes.
+	quoted bool   // quoted indicates that the field was enclosed in quotes.
+}
+
+// zoneEntry is a directive or a record of a zone file, which may span several lines in parentheses.
+type zoneEntry struct {
+	tokens     []zoneToken
+	line       int  // line is the line on which the entry starts.
+	blankOwner bool // blankOwner indicates that the entry starts with a blank, so it is owned by the previous owner.
+}
+
+// lexZone splits the content of a zone file into entries, removing the comments and the parentheses.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1035#section-5.1 for more information
+func lexZone(data []byte, file string) ([]zoneEntry, error) {
+	var entries []zoneEntry
+	var entry *zoneEntry
+	var token strings.Builder
+	inToken := false
+	line := 1
+	parens := 0
+	parensLine := 0
+	lineStartsBlank := len(data) > 0 && (data[0] == ' ' || data[0] == '\t')
+
+	addToken := func(text string, quoted bool) {
+		if entry == nil {
+			entry = &zoneEntry{line: line, blankOwner: lineStartsBlank}
+		}
+		entry.tokens = append(entry.tokens, zoneToken{text: text, quoted: quoted})
+	}
+	endToken := func() {
+		if inToken {
+			addToken(token.String(), false)
+			token.Reset()
+			inToken = false
+		}
+	}
+	lexError := func(format string, args ...any) error {
+		return &ZoneError{File: file, Line: line, Err: fmt.Errorf(format, args...)}
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '\n':
+			endToken()
+			if parens == 0 && entry != nil {
+				entries = append(entries, *entry)
+				entry = nil
+			}
+			line++
+			lineStartsBlank = i+1 < len(data) && (data[i+1] == ' ' || data[i+1] == '\t')
+		case ' ', '\t', '\r':
+			endToken()
+		case ';':
+			endToken()
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case '(':
+			endToken()
+			if parens == 0 {
+				parensLine = line
+			}
+			parens++
+		case ')':
+			endToken()
+			if parens == 0 {
+				return nil, lexError("unbalanced closing parenthesis")
+			}
+			parens--
+		case '"':
+			endToken()
+			var quoted strings.Builder
+			for i++; ; i++ {
+				if i >= len(data) || data[i] == '\n' {
+					return nil, lexError("unterminated quoted string")
+				}
+				if data[i] == '"' {
+					break
+				}
+				if data[i] == '\\' && i+1 < len(data) {
+					quoted.WriteByte(data[i])
+					i++
+				}
+				quoted.WriteByte(data[i])
+			}
+			addToken(quoted.String(), true)
+		case '\\':
+			if i+1 >= len(data) {
+				return nil, lexError("escape at the end of the file")
+			}
+			token.WriteByte(c)
+			token.WriteByte(data[i+1])
+			inToken = true
+			i++
+		default:
+			token.WriteByte(c)
+			inToken = true
+		}
+	}
+	endToken()
+	if parens > 0 {
+		line = parensLine
+		return nil, lexError("unbalanced opening parenthesis")
+	}
+	if entry != nil {
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// zoneParser holds the state of the parsing of a zone file.
+type zoneParser struct {
+	file       string
+	origin     string
+	defaultTTL int64 // defaultTTL is the TTL set by $TTL, or -1 if none.
+	lastTTL    int64 // lastTTL is the TTL of the previous record, or -1 if none.
+	lastOwner  string
+	lastClass  uint16
+	depth      int
+	records    []ResourceRecord
+}
+
+// ParseZone parses the zone file read from r and returns its records.
+// Relative names are completed with origin until a $ORIGIN directive changes it, and the
+// files included with $INCLUDE are looked up relative to the directory of file.
+// The errors are *ZoneError values holding the file and the line of the faulty entry.
+//
+// The $ORIGIN, $TTL, $INCLUDE and $GENERATE directives are supported, as well as the record types
+// known by the package and the generic resource data format (\# length hex) of RFC 3597.
+//
+// See https://datatracker.ietf.org/doc/html/rfc1035#section-5 for more information
+func ParseZone(r io.Reader, origin string, file string) ([]ResourceRecord, error) {
+	p := &zoneParser{
+		file:       file,
+		origin:     trimFQDN(origin),
+		defaultTTL: -1,
+		lastTTL:    -1,
+		lastClass:  ClassIN,
+	}
+	if err := p.parse(r); err != nil {
+		return nil, err
+	}
+	return p.records, nil
+}
+
+// ParseZoneFile parses the zone file at the given path and returns its records, see ParseZone.
+func ParseZoneFile(path string, origin string) ([]ResourceRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseZone(f, origin, path)
+}
+
+// parse parses the zone file read from r with the state of the parser.
+func (p *zoneParser) parse(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return &ZoneError{File: p.file, Err: err}
+	}
+	entries, err := lexZone(data, p.file)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := p.parseEntry(entry); err != nil {
+			var zoneErr *ZoneError
+			if errors.As(err, &zoneErr) {
+				return err
+			}
+			return &ZoneError{File: p.file, Line: entry.line, Err: err}
+		}
+	}
+	return nil
+}
+
+// parseEntry parses a directive or a record.
+func (p *zoneParser) parseEntry(entry zoneEntry) error {
+	tokens := entry.tokens
+	if entry.blankOwner {
+		if len(p.records) == 0 {
+			return fmt.Errorf("missing owner name of the first record")
+		}
+		return p.parseRecord(p.lastOwner, tokens)
+	}
+	switch strings.ToUpper(tokens[0].text) {
+	case "$ORIGIN":
+		if len(tokens) != 2 {
+			return fmt.Errorf("$ORIGIN expects a domain name")
+		}
+		origin, err := parseZoneName(tokens[1].text, p.origin)
+		if err != nil {
+			return err
+		}
+		p.origin = origin
+		return nil
+	case "$TTL":
+		if len(tokens) != 2 {
+			return fmt.Errorf("$TTL expects a TTL")
+		}
+		ttl, err := parseTTL(tokens[1].text)
+		if err != nil {
+			return err
+		}
+		p.defaultTTL = int64(ttl)
+		return nil
+	case "$INCLUDE":
+		return p.include(tokens[1:])
+	case "$GENERATE":
+		return p.generate(tokens[1:])
+	}
+	if strings.HasPrefix(tokens[0].text, "$") {
+		return fmt.Errorf("unsupported directive: %s", tokens[0].text)
+	}
+	owner, err := parseZoneName(tokens[0].text, p.origin)
+	if err != nil {
+		return err
+	}
+	return p.parseRecord(owner, tokens[1:])
+}
+
+// parseRecord parses the fields following the owner of a record: [TTL] [class] type rdata,
+// where the TTL and the class may come in any order.
+func (p *zoneParser) parseRecord(owner string, tokens []zoneToken) error {
+	ttl := int64(-1)
+	class := p.lastClass
+	hasClass := false
+	for len(tokens) > 0 {
+		text := tokens[0].text
+		if value, err := parseTTL(text); err == nil && ttl < 0 && text[0] >= '0' && text[0] <= '9' {
+			ttl = int64(value)
+		} else if value, ok := classFromString(text); ok && !hasClass {
+			class = value
+			hasClass = true
+		} else {
+			break
+		}
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("missing record type")
+	}
+	rType, ok := typeFromString(tokens[0].text)
+	if !ok {
+		return fmt.Errorf("unknown record type: %s", tokens[0].text)
+	}
+
+	data, err := parseRData(rType, tokens[1:], p.origin)
+	if err != nil {
+		return fmt.Errorf("invalid %s record: %w", RTypeToString(rType), err)
+	}
+
+	switch {
+	case ttl >= 0:
+	case p.defaultTTL >= 0:
+		ttl = p.defaultTTL
+	case p.lastTTL >= 0:
+		ttl = p.lastTTL
+	default:
+		soa, ok := data.(*SOA)
+		if !ok {
+			return fmt.Errorf("missing TTL, and no $TTL directive")
+		}
+		ttl = int64(soa.Minimum)
+	}
+
+	rr, err := NewResourceRecordWithData(owner, class, uint32(ttl), data)
+	if err != nil {
+		return fmt.Errorf("invalid %s record: %w", RTypeToString(rType), err)
+	}
+	p.records = append(p.records, *rr)
+	p.lastOwner = owner
+	p.lastTTL = ttl
+	p.lastClass = class
+	return nil
+}
+
+// parseRData parses the resource data of the given type, in its own format or in the generic format of RFC 3597.
+func parseRData(rType uint16, tokens []zoneToken, origin string) (RData, error) {
+	if rType == TypeOPT {
+		return nil, fmt.Errorf("OPT pseudo-records can not appear in zone files")
+	}
+	if len(tokens) > 0 && tokens[0].text == `\#` && !tokens[0].quoted {
+		if len(tokens) < 2 {
+			return nil, fmt.Errorf(`missing length of the \# resource data`)
+		}
+		length, err := strconv.ParseUint(tokens[1].text, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid length of the \# resource data: %s`, tokens[1].text)
+		}
+		var hexData strings.Builder
+		for _, token := range tokens[2:] {
+			hexData.WriteString(token.text)
+		}
+		rData, err := hex.DecodeString(hexData.String())
+		if err != nil || len(rData) != int(length) {
+			return nil, fmt.Errorf(`invalid \# resource data of length %d`, length)
+		}
+		return UnpackRData(rType, rData)
+	}
+
+	data := newRData(rType)
+	if err := data.parse(tokens, origin); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// include parses the file of an $INCLUDE directive: file [origin].
+// The directives of the included file do not change the origin of the including file.
+func (p *zoneParser) include(tokens []zoneToken) error {
+	if len(tokens) < 1 || len(tokens) > 2 {
+		return fmt.Errorf("$INCLUDE expects a file name and an optional domain name")
+	}
+	if p.depth >= maxIncludeDepth {
+		return fmt.Errorf("too many nested $INCLUDE directives")
+	}
+	path, err := unescapeCharacterString(tokens[0].text)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(p.file), path)
+	}
+	origin := p.origin
+	if len(tokens) == 2 {
+		if origin, err = parseZoneName(tokens[1].text, p.origin); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	included := *p
+	included.file = path
+	included.origin = origin
+	included.depth++
+	if err := included.parse(f); err != nil {
+		return err
+	}
+	p.records = included.records
+	p.lastOwner = included.lastOwner
+	p.lastTTL = included.lastTTL
+	p.lastClass = included.lastClass
+	return nil
+}
+
+// generate expands a $GENERATE directive: range owner [TTL] [class] type rdata.
+// The range is start-stop[/step] and every $ in the owner and the rdata is replaced by the iterator,
+// which can be formatted with ${offset[,width[,base]]}, base being d, o, x or X.
+func (p *zoneParser) generate(tokens []zoneToken) error {
+	if len(tokens) < 4 {
+		return fmt.Errorf("$GENERATE expects a range, an owner, a type and resource data")
+	}
+	start, stop, step, err := parseGenerateRange(tokens[0].text)
+	if err != nil {
+		return err
+	}
+	if (stop-start)/step >= maxGenerateRecords {
+		return fmt.Errorf("$GENERATE range is too large: %s", tokens[0].text)
+	}
+	for i := start; i <= stop; i += step {
+		fields := make([]zoneToken, len(tokens)-1)
+		for j, token := range tokens[1:] {
+			fields[j] = token
+			if fields[j].text, err = expandGenerate(token.text, i); err != nil {
+				return err
+			}
+		}
+		owner, err := parseZoneName(fields[0].text, p.origin)
+		if err != nil {
+			return err
+		}
+		if err := p.parseRecord(owner, fields[1:]); err != nil {
+			return err
+		}
+		if stop-i < step {
+			break // i += step could overflow
+		}
+	}
+	return nil
+}
+
+// parseGenerateRange parses the start-stop[/step] range of a $GENERATE directive.
+func parseGenerateRange(text string) (start, stop, step int, err error) {
+	rangeText, stepText, hasStep := strings.Cut(text, "/")
+	startText, stopText, ok := strings.Cut(rangeText, "-")
+	step = 1
+	if ok {
+		start, err = strconv.Atoi(startText)
+		if err == nil {
+			stop, err = strconv.Atoi(stopText)
+		}
+		if err == nil && hasStep {
+			step, err = strconv.Atoi(stepText)
+		}
+	}
+	// The bounds are 32-bit values, as in BIND
+	if !ok || err != nil || start < 0 || stop < start || stop > math.MaxInt32 || step < 1 || step > math.MaxInt32 {
+		return 0, 0, 0, fmt.Errorf("invalid $GENERATE range: %s", text)
+	}
+	return start, stop, step, nil
+}
+
+// expandGenerate replaces the $ of a $GENERATE template by the iterator. Escaped dollars (\$) are kept.
+func expandGenerate(template string, iterator int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c == '\\' && i+1 < len(template) {
+			b.WriteByte(c)
+			b.WriteByte(template[i+1])
+			i++
+			continue
+		}
+		if c != '$' {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 >= len(template) || template[i+1] != '{' {
+			b.WriteString(strconv.Itoa(iterator))
+			continue
+		}
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated $GENERATE modifier: %s", template)
+		}
+		value, err := formatGenerateModifier(template[i+2:i+end], iterator)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		i += end
+	}
+	return b.String(), nil
+}
+
+// formatGenerateModifier formats the iterator with an offset[,width[,base]] modifier.
+func formatGenerateModifier(modifier string, iterator int) (string, error) {
+	fields := strings.Split(modifier, ",")
+	if len(fields) > 3 {
+		return "", fmt.Errorf("invalid $GENERATE modifier: %s", modifier)
+	}
+	offset, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid $GENERATE offset: %s", fields[0])
+	}
+	width := 0
+	if len(fields) > 1 {
+		if width, err = strconv.Atoi(fields[1]); err != nil || width < 0 || width > 255 {
+			return "", fmt.Errorf("invalid $GENERATE width: %s", fields[1])
+		}
+	}
+	verb := "d"
+	if len(fields) > 2 {
+		verb = fields[2]
+		if verb != "d" && verb != "o" && verb != "x" && verb != "X" {
+			return "", fmt.Errorf("unsupported $GENERATE base: %s", verb)
+		}
+	}
+	value := iterator + offset
+	if value < 0 {
+		return "", fmt.Errorf("negative $GENERATE value: %d", value)
+	}
+	return fmt.Sprintf("%0*"+verb, width, value), nil
+}
+
+// parseZoneName parses a domain name of a zone file and returns it fully qualified, without the trailing dot,
+// with its escapes in their shortest form, see NewName.
+// @ stands for the origin, and names that do not end with a dot are relative to the origin.
+func parseZoneName(text string, origin string) (string, error) {
+	if text == "@" {
+		return origin, nil
+	}
+	if text == "." {
+		return "", nil
+	}
+	n, err := NewName(text)
+	if err != nil {
+		return "", err
+	}
+	name := trimFQDN(string(n))
+	if !n.IsFQDN() && origin != "" {
+		name += "." + origin
+		if _, err := decodeLabels(name); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// unescapeAt decodes the escape starting with the backslash at offset i: \DDD or \X.
+// It returns the decoded byte and the offset following the escape.
+func unescapeAt(text string, i int) (byte, int, error) {
+	if i+1 >= len(text) {
+		return 0, 0, fmt.Errorf("incomplete escape: %s", text)
+	}
+	if text[i+1] < '0' || text[i+1] > '9' {
+		return text[i+1], i + 2, nil
+	}
+	if i+4 > len(text) {
+		return 0, 0, fmt.Errorf("incomplete escape: %s", text)
+	}
+	value, err := strconv.ParseUint(text[i+1:i+4], 10, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid escape: %s", text[i:i+4])
+	}
+	return byte(value), i + 4, nil
+}
+
+// unescapeCharacterString decodes the escapes of a character-string: \DDD and \X.
+func unescapeCharacterString(text string) (string, error) {
+	if !strings.Contains(text, `\`) {
+		return text, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' {
+			b.WriteByte(text[i])
+			continue
+		}
+		value, next, err := unescapeAt(text, i)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(value)
+		i = next - 1
+	}
+	return b.String(), nil
+}
+
+// parseTTL parses a TTL given in seconds, or with the units of BIND such as 1h30m.
+func parseTTL(text string) (uint32, error) {
+	if value, err := strconv.ParseUint(text, 10, 32); err == nil {
+		return uint32(value), nil
+	}
+	units := map[rune]uint64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	var total, value uint64
+	hasValue := false
+	for _, c := range strings.ToLower(text) {
+		if c >= '0' && c <= '9' {
+			value = value*10 + uint64(c-'0')
+			hasValue = true
+		} else if unit, ok := units[c]; ok && hasValue {
+			total += value * unit
+			value = 0
+			hasValue = false
+		} else {
+			return 0, fmt.Errorf("invalid TTL: %s", text)
+		}
+		if value > 0xFFFFFFFF || total > 0xFFFFFFFF {
+			return 0, fmt.Errorf("invalid TTL: %s", text)
+		}
+	}
+	if text == "" || hasValue {
+		return 0, fmt.Errorf("invalid TTL: %s", text)
+	}
+	return uint32(total), nil
+}
+
+// parseUint parses an unsigned integer field of the given bit size.
+func parseUint(token zoneToken, bitSize int) (uint64, error) {
+	value, err := strconv.ParseUint(token.text, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %d-bit integer: %s", bitSize, token.text)
+	}
+	return value, nil
+}
+
+// expectTokens checks that the resource data has the given number of fields.
+func expectTokens(tokens []zoneToken, count int) error {
+	if len(tokens) != count {
+		return fmt.Errorf("expected %d fields, got %d", count, len(tokens))
+	}
+	return nil
+}
+
+// classFromString returns the class of the given mnemonic, ignoring its case, and whether it is known.
+func classFromString(text string) (uint16, bool) {
+	class := ClassToInt(strings.ToUpper(text))
+	return class, class != 0
+}
+
+// typeFromString returns the record type of the given mnemonic, ignoring its case, and whether it is known.
+func typeFromString(text string) (uint16, bool) {
+	rType := RTypeToInt(strings.ToUpper(text))
+	return rType, rType != 0
+}
+
+// WriteZone writes the records to w in the presentation format of zone files, one record per line.
+// Owner names are fully qualified and every record has its TTL and class, so that the output does not
+// depend on any directive and can be read back by ParseZone.
+func WriteZone(w io.Writer, records []ResourceRecord) error {
+	bw := bufio.NewWriter(w)
+	for _, rr := range records {
+		if _, err := fmt.Fprintln(bw, zoneLine(&rr)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// zoneLine returns the presentation format of the record: owner, TTL, class, type and resource data separated by tabs.
+func zoneLine(rr *ResourceRecord) string {
+	data := rr.Data
+	if data == nil {
+		data = &Unknown{RRType: rr.Type, Data: rr.RData}
+	}
+	return fmt.Sprintf("%s\t%d\t%s\t%s\t%s", escapeZoneName(fqdn(rr.Name)), rr.TTL, ClassToString(rr.Class), RTypeToString(rr.Type), data.String())
+}
+
+// escapeZoneName returns the domain name with its escapes in their shortest form, escaping the characters which have
+// a special meaning in zone files, see NewName. The names that are not valid are returned as is.
+func escapeZoneName(name string) string {
+	if n, err := NewName(name); err == nil {
+		return string(n)
+	}
+	return name
+}
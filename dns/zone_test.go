@@ -0,0 +1,206 @@
+package dns
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testZone = `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1 hostmaster (
+		2024010101 ; serial
+		2h         ; refresh
+		15m        ; retry
+		1w         ; expire
+		300 )      ; minimum
+	IN	NS	ns1
+	IN	NS	ns2.example.net.
+	IN	MX	10 mail
+ns1	300	IN	A	192.0.2.1
+mail	IN	3600	AAAA	2001:db8::25
+www		CNAME	@
+@		TXT	"v=spf1 -all" "quote \" and \\ and \059"
+_sip._tcp	SRV	10 60 5060 sip
+1.2.0.192.in-addr.arpa.	PTR	ns1 ; absolute owner
+escaped\032host	A	192.0.2.2
+generic	A	\# 4 c0000203
+`
+
+func TestZone(t *testing.T) {
+	t.Run("Should parse the records of a zone file", func(t *testing.T) {
+		records, err := ParseZone(strings.NewReader(testZone), "", "example.com.zone")
+		assert.NoError(t, err)
+		assert.Equal(t, 12, len(records))
+
+		soa := records[0]
+		assert.Equal(t, "example.com", soa.Name)
+		assert.Equal(t, uint32(3600), soa.TTL)
+		assert.Equal(t, &SOA{MName: "ns1.example.com", RName: "hostmaster.example.com", Serial: 2024010101, Refresh: 7200, Retry: 900, Expire: 604800, Minimum: 300}, soa.Data)
+
+		assert.Equal(t, "example.com", records[1].Name)
+		assert.Equal(t, &NS{Host: "ns1.example.com"}, records[1].Data)
+		assert.Equal(t, &NS{Host: "ns2.example.net"}, records[2].Data)
+		assert.Equal(t, &MX{Preference: 10, Exchange: "mail.example.com"}, records[3].Data)
+
+		assert.Equal(t, "ns1.example.com", records[4].Name)
+		assert.Equal(t, uint32(300), records[4].TTL)
+		assert.Equal(t, "192.0.2.1", records[4].RDataParsed)
+
+		assert.Equal(t, "mail.example.com", records[5].Name)
+		assert.Equal(t, uint32(3600), records[5].TTL)
+		assert.Equal(t, TypeAAAA, records[5].Type)
+
+		assert.Equal(t, &CNAME{Target: "example.com"}, records[6].Data)
+		assert.Equal(t, &TXT{Strings: []string{"v=spf1 -all", `quote " and \ and ;`}}, records[7].Data)
+		assert.Equal(t, "_sip._tcp.example.com", records[8].Name)
+		assert.Equal(t, &SRV{Priority: 10, Weight: 60, Port: 5060, Target: "sip.example.com"}, records[8].Data)
+		assert.Equal(t, "1.2.0.192.in-addr.arpa", records[9].Name)
+		assert.Equal(t, "escaped\\032host.example.com", records[10].Name)
+		assert.Equal(t, "192.0.2.3", records[11].RDataParsed)
+
+		for _, rr := range records {
+			assert.Equal(t, ClassIN, rr.Class)
+			assert.Equal(t, uint16(len(rr.RData)), rr.RDLength)
+		}
+	})
+
+	t.Run("Should expand $GENERATE directives", func(t *testing.T) {
+		zone := "$ORIGIN 2.0.192.in-addr.arpa.\n" +
+			"$GENERATE 1-5/2 $ 300 PTR host-${10,3,d}.example.com.\n" +
+			"$GENERATE 10-11 host-${0,2,x} A 192.0.2.$\n"
+		records, err := ParseZone(strings.NewReader(zone), "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, 5, len(records))
+		assert.Equal(t, "1.2.0.192.in-addr.arpa", records[0].Name)
+		assert.Equal(t, "host-011.example.com", records[0].RDataParsed)
+		assert.Equal(t, "5.2.0.192.in-addr.arpa", records[2].Name)
+		assert.Equal(t, "host-015.example.com", records[2].RDataParsed)
+		assert.Equal(t, "host-0a.2.0.192.in-addr.arpa", records[3].Name)
+		assert.Equal(t, "192.0.2.11", records[4].RDataParsed)
+		assert.Equal(t, uint32(300), records[4].TTL)
+
+		records, err = ParseZone(strings.NewReader("$GENERATE 2147483645-2147483647/2 host-$ 300 A 192.0.2.1\n"), "example.com.", "")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(records), "the iterator stops at the last value of the range")
+	})
+
+	t.Run("Should parse the files included with $INCLUDE", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "hosts.zone"), []byte("$ORIGIN other.com.\nwww A 192.0.2.80\n"), 0o644))
+		main := "$TTL 300\n$INCLUDE hosts.zone hosts\nmail A 192.0.2.25\n"
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.zone"), []byte(main), 0o644))
+
+		records, err := ParseZoneFile(filepath.Join(dir, "main.zone"), "example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(records))
+		assert.Equal(t, "www.other.com", records[0].Name)
+		assert.Equal(t, "mail.example.com", records[1].Name)
+	})
+
+	t.Run("Should report the file and the line of errors", func(t *testing.T) {
+		invalidZones := map[string]int{
+			"$TTL 300\nwww A 192.0.2.300\n":                 2,
+			"$TTL 300\n\nwww A 192.0.2.1\nwww BOGUS data\n": 4,
+			"www A 192.0.2.1\n":                             1,
+			"$TTL 300\nwww IN MX (\n 10\n mail.\n":          2,
+			"$TTL 300\nwww TXT \"unterminated\n":            2,
+			"$TTL 300\nwww MX 10\n":                         2,
+			"$TTL 300\n\tA 192.0.2.1\n":                     2,
+			"$TTL 300\nwww A \\# 4 c00002\n":                2,
+			"$TTL 300\n$INCLUDE missing.zone\n":             2,
+			"$TTL 300\n$GENERATE 5-1 host-$ A 192.0.2.$\n":  2,
+			"$TTL 300\n$GENERATE 9223372036854775807-9223372036854775807 host-$ A 192.0.2.1\n": 2,
+			"$TTL 300\nwww..dot A 192.0.2.1\n":                                                 2,
+			"$TTL 300\n$UNKNOWN\n":                                                             2,
+			"$TTL 300\nwww OPT \\# 0\n":                                                        2,
+			"$TTL 300\nwww A 192.0.2.1 )\n":                                                    2,
+		}
+		for zone, line := range invalidZones {
+			_, err := ParseZone(strings.NewReader(zone), "example.com.", "db.example")
+			var zoneErr *ZoneError
+			if assert.True(t, errors.As(err, &zoneErr), zone) {
+				assert.Equal(t, line, zoneErr.Line, zone)
+				assert.True(t, strings.HasPrefix(err.Error(), "db.example:"), err.Error())
+			}
+		}
+	})
+
+	t.Run("Should keep the escaped dots within the labels", func(t *testing.T) {
+		records, err := ParseZone(strings.NewReader("$TTL 300\nwww\\.dot A 192.0.2.1\n\\065\\.b CNAME www\\.dot\n"), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(records)) {
+			assert.Equal(t, "www\\.dot.example.com", records[0].Name)
+			assert.Equal(t, 3, CountLabels(records[0].Name))
+			assert.Equal(t, "A\\.b.example.com", records[1].Name)
+			assert.Equal(t, "A\\.b.example.com.\t300\tIN\tCNAME\twww\\.dot.example.com.", records[1].String())
+			assert.Equal(t, append([]byte{7, 'w', 'w', 'w', '.', 'd', 'o', 't'}, encodeName("example.com")...), []byte(encodeName(records[0].Name)))
+		}
+	})
+
+	t.Run("Should write the records in canonical presentation format", func(t *testing.T) {
+		records, err := ParseZone(strings.NewReader(testZone), "", "")
+		assert.NoError(t, err)
+		unknown := NewResourceRecord("example.com", 65280, ClassIN, 60, 2, []byte{1, 2})
+		records = append(records, *unknown)
+
+		var b bytes.Buffer
+		assert.NoError(t, WriteZone(&b, records))
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		assert.Equal(t, "example.com.\t3600\tIN\tSOA\tns1.example.com. hostmaster.example.com. 2024010101 7200 900 604800 300", lines[0])
+		assert.Equal(t, "escaped\\032host.example.com.\t3600\tIN\tA\t192.0.2.2", lines[10])
+		assert.Equal(t, "example.com.\t60\tIN\tTYPE65280\t\\# 2 0102", lines[12])
+
+		parsed, err := ParseZone(&b, "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, len(records), len(parsed))
+		for i := range records {
+			assert.Equal(t, records[i].Name, parsed[i].Name)
+			assert.Equal(t, records[i].TTL, parsed[i].TTL)
+			assert.Equal(t, records[i].RData, parsed[i].RData)
+		}
+	})
+
+	t.Run("Should keep the resource data of unknown types and classes byte for byte", func(t *testing.T) {
+		zone := "host 60 CLASS42 TYPE65280 \\# 5 0a0b ( 0c0D0e )\nhost 60 class42 type1 \\# 4 c0000201\nempty 60 IN TYPE65281 \\# 0\n"
+		records, err := ParseZone(strings.NewReader(zone), "example.com.", "")
+		assert.NoError(t, err)
+		if assert.Equal(t, 3, len(records)) {
+			assert.Equal(t, uint16(42), records[0].Class)
+			assert.Equal(t, uint16(65280), records[0].Type)
+			assert.Equal(t, []byte{0x0a, 0x0b, 0x0c, 0x0d, 0x0e}, records[0].RData)
+			assert.Equal(t, "192.0.2.1", records[1].RDataParsed)
+			assert.Empty(t, records[2].RData)
+		}
+
+		var b bytes.Buffer
+		assert.NoError(t, WriteZone(&b, records))
+		assert.Equal(t, "host.example.com.\t60\tCLASS42\tTYPE65280\t\\# 5 0a0b0c0d0e\n"+
+			"host.example.com.\t60\tCLASS42\tA\t192.0.2.1\n"+
+			"empty.example.com.\t60\tIN\tTYPE65281\t\\# 0\n", b.String())
+
+		parsed, err := ParseZone(&b, "", "")
+		assert.NoError(t, err)
+		for i := range records {
+			assert.Equal(t, records[i].ToBytes(), parsed[i].ToBytes())
+		}
+	})
+
+	t.Run("Should parse TTLs with units", func(t *testing.T) {
+		ttls := map[string]uint32{"300": 300, "1h30m": 5400, "1W": 604800, "2d": 172800}
+		for text, ttl := range ttls {
+			value, err := parseTTL(text)
+			assert.NoError(t, err)
+			assert.Equal(t, ttl, value)
+		}
+		for _, text := range []string{"", "h", "1x", "30m5", "4294967296"} {
+			_, err := parseTTL(text)
+			assert.Error(t, err, text)
+		}
+	})
+}
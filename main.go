@@ -3,24 +3,192 @@ package main
 import (
 	"dns-resolver-go/dns"
 	"dns-resolver-go/network"
+	"encoding/json"
 	"fmt"
+	"net/netip"
 	"os"
 	"strings"
 )
 
 func main() {
 	argLen := len(os.Args)
-	if argLen < 2 {
+	if argLen >= 2 && os.Args[1] == "update" {
+		runUpdate(os.Args[2:])
+		return
+	}
+	if argLen < 2 || (os.Args[1] == "-x" && argLen < 3) {
 		fmt.Println("Usage: go run main.go <domain> [OPTIONS]")
+		fmt.Println("       go run main.go -x <ip address> [OPTIONS]")
+		fmt.Println("       go run main.go update <server>[:<port>] <zone> < <commands>")
 		fmt.Println("OPTIONS:")
 		fmt.Println("  --no-cache: Resolve the domain without using the cache.")
+		fmt.Println("  --type=<TYPE>: Resolve the records of the given type (A, AAAA, CAA, CNAME, DNAME, DNSKEY, DS, HINFO, HTTPS, LOC, MX, NAPTR, NS, NSEC, NSEC3, NSEC3PARAM, PTR, RRSIG, SOA, SRV, SSHFP, SVCB, TLSA, TXT, URI, or TYPE<value> for any other type). Defaults to A.")
+		fmt.Println("  --no-edns: Send the queries without the EDNS(0) OPT pseudo-record.")
+		fmt.Println("  --subnet=<address>[/<prefix length>]: Send the client subnet with EDNS, to get the answers for this subnet. The prefix length defaults to 24 for IPv4 and 56 for IPv6.")
+		fmt.Println("  --no-cookies: Send the queries without DNS cookies.")
+		fmt.Println("  --show-cookies: Print the DNS cookies exchanged with each server once resolved.")
+		fmt.Println("  --dig: Print the full response of the server that answered, as dig does.")
+		fmt.Println("  --dnssec: Validate the responses with DNSSEC, and print the full response with its security status.")
+		fmt.Println("  --idn: Print the U-labels of the internationalized domain names along with their A-labels.")
+		fmt.Println("  --json: Print the full response of the server that answered in JSON (RFC 8427).")
+		fmt.Println("  -x: Perform a reverse lookup of the given IPv4 or IPv6 address.")
+		fmt.Println("update: Send the prerequisites and updates read from stdin, in the format of nsupdate, to the primary server of the zone.")
 		os.Exit(1)
 	}
-	domain := os.Args[1]
-	userOptions := strings.Join(os.Args[2:], ",")
+	reverseLookup := os.Args[1] == "-x"
+	args := os.Args[1:]
+	if reverseLookup {
+		args = os.Args[2:]
+	}
+	domain := args[0]
+	userOptions := strings.Join(args[1:], ",")
 	option := network.DefaultOption()
 	if strings.Contains(userOptions, "--no-cache") {
 		option.UseCache = false
 	}
-	network.Resolve(domain, dns.TypeA, option)
+	if strings.Contains(userOptions, "--no-edns") {
+		option.UDPSize = 0
+	}
+	if strings.Contains(userOptions, "--no-cookies") {
+		option.Cookies = nil
+	}
+	if strings.Contains(userOptions, "--dnssec") {
+		option.DNSSEC = true
+	}
+	if strings.Contains(userOptions, "--idn") {
+		option.Unicode = true
+	}
+	showCookies := strings.Contains(userOptions, "--show-cookies")
+	for _, arg := range args[1:] {
+		if subnet, ok := strings.CutPrefix(arg, "--subnet="); ok {
+			clientSubnet, err := dns.ParseClientSubnet(subnet)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			option.ClientSubnet = clientSubnet
+		}
+	}
+
+	if reverseLookup {
+		names, err := network.LookupAddr(domain, option)
+		if showCookies {
+			printCookies()
+		}
+		if err != nil {
+			fmt.Printf("Failed to resolve %s: %v\n", domain, err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nNon-authoritative answer:\n")
+		for _, name := range names {
+			fmt.Printf("%s	name = %s.\n", domain, name)
+		}
+		return
+	}
+
+	questionType := dns.TypeA
+	for _, arg := range args[1:] {
+		if rType, ok := strings.CutPrefix(arg, "--type="); ok {
+			questionType = dns.RTypeToInt(strings.ToUpper(rType))
+			if questionType == 0 {
+				fmt.Printf("Unsupported record type: %s\n", rType)
+				os.Exit(1)
+			}
+		}
+	}
+	if strings.Contains(userOptions, "--json") {
+		option.Quiet = true
+		response, err := network.LookupMessage(domain, questionType, option)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to resolve %s: %v\n", domain, err)
+			os.Exit(1)
+		}
+		output, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode the response: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		return
+	}
+	if strings.Contains(userOptions, "--dig") {
+		response, err := network.LookupMessage(domain, questionType, option)
+		if showCookies {
+			printCookies()
+		}
+		if err != nil {
+			fmt.Printf("Failed to resolve %s: %v\n", domain, err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n%s", formatResponse(response, option))
+		return
+	}
+	if option.DNSSEC {
+		response, security, err := network.LookupValidated(domain, questionType, option)
+		if showCookies {
+			printCookies()
+		}
+		if response != nil {
+			fmt.Printf("\n%s", formatResponse(response, option))
+		}
+		fmt.Printf(";; SECURITY: %s\n", security)
+		if err != nil {
+			fmt.Printf("Failed to resolve %s: %v\n", domain, err)
+			os.Exit(1)
+		}
+		return
+	}
+	result := network.Resolve(domain, questionType, option)
+	if showCookies {
+		printCookies()
+	}
+	if result == "" {
+		os.Exit(1)
+	}
+}
+
+// runUpdate sends the UPDATE message built from the commands read from stdin to the server: update <server>[:<port>] <zone>.
+func runUpdate(args []string) {
+	if len(args) != 2 {
+		fmt.Println("Usage: go run main.go update <server>[:<port>] <zone> < <commands>")
+		os.Exit(1)
+	}
+	server, err := netip.ParseAddrPort(args[0])
+	if err != nil {
+		addr, addrErr := netip.ParseAddr(args[0])
+		if addrErr != nil {
+			fmt.Printf("Invalid server address: %s\n", args[0])
+			os.Exit(1)
+		}
+		server = netip.AddrPortFrom(addr, 53)
+	}
+	update, err := dns.ParseUpdate(os.Stdin, args[1])
+	if err != nil {
+		fmt.Printf("Failed to read the update: %v\n", err)
+		os.Exit(1)
+	}
+	response, err := network.SendUpdate(server, update)
+	if response != nil {
+		fmt.Printf("\n%s", response)
+	}
+	if err != nil {
+		fmt.Printf("Failed to update %s: %v\n", args[1], err)
+		os.Exit(1)
+	}
+}
+
+// formatResponse returns the presentation format of the response, with the U-labels of its names if the option asks for them.
+func formatResponse(response *dns.DNSMessage, option network.Option) string {
+	if option.Unicode {
+		return response.UnicodeString()
+	}
+	return response.String()
+}
+
+// printCookies prints the DNS cookies exchanged with each server.
+func printCookies() {
+	fmt.Printf("\nCookies:\n")
+	for _, state := range network.DefaultCookieJar.States() {
+		fmt.Printf("%s	client = %x, server = %x, bad cookies = %d\n", state.Server, state.ClientCookie, state.ServerCookie, state.BadCookies)
+	}
 }
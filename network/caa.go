@@ -0,0 +1,108 @@
+package network
+
+import (
+	"dns-resolver-go/dns"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CAAPolicy is the certificate issuance policy of a domain name, given by its relevant CAA RRset.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8659#section-3 for more information
+type CAAPolicy struct {
+	Domain  string               // Domain is the domain name the certificate is requested for, which may be a wildcard such as *.example.com.
+	Owner   string               // Owner is the domain name holding the relevant RRset, empty if no CAA record was found.
+	Records []dns.ResourceRecord // Records is the relevant CAA RRset. Any CA is authorized if it is empty.
+}
+
+// LookupCAA returns the certificate issuance policy of the domain name.
+// The relevant CAA RRset is found by climbing the DNS tree: the CAA records of the domain name are looked up,
+// then the ones of its parent, and so on until a non-empty RRset is found or the root is reached.
+// The CNAME records are followed at each name, but the parents of their targets are not searched.
+// A wildcard domain name is searched from the name following its *. label.
+//
+// Names that do not exist or have no CAA record are skipped, but any other failure to look up
+// a name is returned, in which case a CA must not issue the certificate.
+func LookupCAA(domain string, options ...Option) (*CAAPolicy, error) {
+	return relevantCAA(domain, func(name string) ([]dns.ResourceRecord, error) {
+		return Lookup(name, dns.TypeCAA, options...)
+	})
+}
+
+// CheckCAA returns the certificate issuance policy of the domain name, see LookupCAA,
+// and whether the CA with the given issuer domain name is authorized by it, see CAAPolicy.Authorizes.
+func CheckCAA(domain string, issuer string, options ...Option) (*CAAPolicy, bool, error) {
+	policy, err := LookupCAA(domain, options...)
+	if err != nil {
+		return nil, false, err
+	}
+	return policy, policy.Authorizes(issuer), nil
+}
+
+// relevantCAA climbs the DNS tree from the domain name to find its relevant CAA RRset, see LookupCAA.
+// The records of each name are looked up with the given function.
+func relevantCAA(domain string, lookup func(name string) ([]dns.ResourceRecord, error)) (*CAAPolicy, error) {
+	policy := &CAAPolicy{Domain: domain}
+	name := strings.TrimSuffix(strings.TrimPrefix(domain, "*."), ".")
+	for name != "" {
+		records, err := lookup(name)
+		if err != nil && !errors.Is(err, ErrNoRecords) && !errors.Is(err, ErrNameNotFound) {
+			return nil, fmt.Errorf("failed to look up the CAA records of %s: %w", name, err)
+		}
+		if records = recordsOfType(records, dns.TypeCAA); len(records) > 0 {
+			policy.Owner = name
+			policy.Records = records
+			return policy, nil
+		}
+		name = dns.Parent(name)
+	}
+	return policy, nil
+}
+
+// Authorizes reports whether the CA with the given issuer domain name, such as letsencrypt.org,
+// may issue a certificate for the domain name of the policy.
+//
+// The issue properties authorize the CAs they name, and the issuewild properties replace them for wildcard
+// domain names if there is any. A policy with none of them, including an empty policy, authorizes every CA.
+// No CA is authorized if the policy holds a property with the issuer critical flag whose tag is unknown,
+// or a record that can not be decoded.
+//
+// See https://datatracker.ietf.org/doc/html/rfc8659#section-4 for more information
+func (p *CAAPolicy) Authorizes(issuer string) bool {
+	var issue, issueWild []*dns.CAA
+	for _, record := range p.Records {
+		caa, ok := record.Data.(*dns.CAA)
+		if !ok {
+			return false
+		}
+		switch strings.ToLower(caa.Tag) {
+		case dns.CAATagIssue:
+			issue = append(issue, caa)
+		case dns.CAATagIssueWild:
+			issueWild = append(issueWild, caa)
+		case dns.CAATagIODEF:
+		default:
+			if caa.IsCritical() {
+				return false
+			}
+		}
+	}
+
+	properties := issue
+	if strings.HasPrefix(p.Domain, "*.") && len(issueWild) > 0 {
+		properties = issueWild
+	}
+	if len(properties) == 0 {
+		return true
+	}
+	issuer = strings.TrimSuffix(issuer, ".")
+	for _, property := range properties {
+		// Malformed values authorize no CA
+		value, err := dns.ParseCAAIssueValue(property.Value)
+		if err == nil && value.Issuer != "" && strings.EqualFold(value.Issuer, issuer) {
+			return true
+		}
+	}
+	return false
+}
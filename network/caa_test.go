@@ -0,0 +1,109 @@
+package network
+
+import (
+	"dns-resolver-go/dns"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCAA(t *testing.T) {
+	caaRecord := func(owner string, flags uint8, tag string, value string) dns.ResourceRecord {
+		record, err := dns.NewResourceRecordWithData(owner, dns.ClassIN, 300, &dns.CAA{Flags: flags, Tag: tag, Value: value})
+		if err != nil {
+			t.Fatalf("failed to create the CAA record: %v", err)
+		}
+		return *record
+	}
+
+	t.Run("Should climb the tree up to the closest CAA RRset", func(t *testing.T) {
+		var lookedUp []string
+		zone := map[string][]dns.ResourceRecord{
+			"example.com": {caaRecord("example.com", 0, "issue", "ca.example.net")},
+			// A CNAME to a name with CAA records, whose parents are not searched
+			"alias.example.org": {*dns.NewResourceRecord("alias.example.org", dns.TypeCNAME, dns.ClassIN, 300, 0, nil), caaRecord("target.example.net", 0, "issue", "ca.example.org")},
+		}
+		lookup := func(name string) ([]dns.ResourceRecord, error) {
+			lookedUp = append(lookedUp, name)
+			if records, ok := zone[name]; ok {
+				return records, nil
+			}
+			if name == "missing.example.com" {
+				return nil, fmt.Errorf("%w: %s", ErrNameNotFound, name)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrNoRecords, name)
+		}
+
+		policy, err := relevantCAA("www.missing.example.com.", lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"www.missing.example.com", "missing.example.com", "example.com"}, lookedUp)
+		assert.Equal(t, "example.com", policy.Owner)
+		assert.Equal(t, zone["example.com"], policy.Records)
+
+		lookedUp = nil
+		policy, err = relevantCAA("*.alias.example.org", lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alias.example.org"}, lookedUp)
+		assert.Equal(t, 1, len(policy.Records))
+		assert.Equal(t, "target.example.net", policy.Records[0].Name)
+
+		lookedUp = nil
+		policy, err = relevantCAA("www.example.net", lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"www.example.net", "example.net", "net"}, lookedUp)
+		assert.Equal(t, "", policy.Owner)
+		assert.Empty(t, policy.Records)
+	})
+
+	t.Run("Should fail if a name can not be looked up", func(t *testing.T) {
+		_, err := relevantCAA("www.example.com", func(name string) ([]dns.ResourceRecord, error) {
+			return nil, errors.New("the DNS server returned an error: SERVFAIL")
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should authorize the issuers of the issue properties", func(t *testing.T) {
+		policy := &CAAPolicy{Domain: "www.example.com", Records: []dns.ResourceRecord{
+			caaRecord("example.com", 0, "issue", "ca.example.net; account=230123"),
+			caaRecord("example.com", 0, "ISSUE", "Other.Example.org"),
+			caaRecord("example.com", 0, "issuewild", "wild.example.net"),
+			caaRecord("example.com", 0, "iodef", "mailto:security@example.com"),
+			caaRecord("example.com", 0, "tbs", "Unknown"),
+		}}
+		assert.True(t, policy.Authorizes("ca.example.net"))
+		assert.True(t, policy.Authorizes("other.example.org."))
+		assert.False(t, policy.Authorizes("wild.example.net"))
+		assert.False(t, policy.Authorizes("example.net"))
+
+		policy.Domain = "*.example.com"
+		assert.True(t, policy.Authorizes("wild.example.net"))
+		assert.False(t, policy.Authorizes("ca.example.net"))
+	})
+
+	t.Run("Should authorize every issuer when no property restricts issuance", func(t *testing.T) {
+		assert.True(t, (&CAAPolicy{Domain: "example.com"}).Authorizes("ca.example.net"))
+		policy := &CAAPolicy{Domain: "*.example.com", Records: []dns.ResourceRecord{
+			caaRecord("example.com", 0, "iodef", "mailto:security@example.com"),
+		}}
+		assert.True(t, policy.Authorizes("ca.example.net"))
+	})
+
+	t.Run("Should not authorize any issuer when issuance is forbidden or misunderstood", func(t *testing.T) {
+		forbidden := &CAAPolicy{Domain: "example.com", Records: []dns.ResourceRecord{caaRecord("example.com", 0, "issue", ";")}}
+		assert.False(t, forbidden.Authorizes("ca.example.net"))
+
+		malformed := &CAAPolicy{Domain: "example.com", Records: []dns.ResourceRecord{caaRecord("example.com", 0, "issue", "%%%%%")}}
+		assert.False(t, malformed.Authorizes("ca.example.net"))
+
+		critical := &CAAPolicy{Domain: "example.com", Records: []dns.ResourceRecord{
+			caaRecord("example.com", 0, "issue", "ca.example.net"),
+			caaRecord("example.com", dns.CAAFlagIssuerCritical, "tbs", "Unknown"),
+		}}
+		assert.False(t, critical.Authorizes("ca.example.net"))
+
+		undecoded := &CAAPolicy{Domain: "example.com", Records: []dns.ResourceRecord{{Name: "example.com", Type: dns.TypeCAA, RDataParsed: `0 issue "ca.example.net"`}}}
+		assert.False(t, undecoded.Authorizes("ca.example.net"))
+	})
+}
@@ -3,9 +3,13 @@ package network
 import (
 	"dns-resolver-go/cache"
 	"dns-resolver-go/dns"
+	"errors"
 	"fmt"
 	"net"
-	"os"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +17,7 @@ import (
 type Client struct {
 	ipAddress string
 	port      int
+	cookies   *CookieJar // cookies exchanged with the server, not sent if nil
 }
 
 // NewClient creates a new Client instance.
@@ -23,6 +28,11 @@ func NewClient(addr string, port int) *Client {
 	}
 }
 
+// address returns the address of the DNS server, used to identify it in the CookieJar.
+func (c *Client) address() string {
+	return net.JoinHostPort(c.ipAddress, strconv.Itoa(c.port))
+}
+
 // ipType returns the IP type of the client's IP address.
 func (c *Client) ipType() (string, error) {
 	ip := net.ParseIP(c.ipAddress)
@@ -63,14 +73,17 @@ func (c *Client) Query(message []byte) ([]byte, error) {
 		return nil, fmt.Errorf("failed to send the DNS message: %v", err)
 	}
 
-	// Receive the response
-	buf := make([]byte, 1024)
+	// Receive the response, in a buffer large enough for the UDP payload size advertised by the message
+	buf := make([]byte, receiveBufferSize(message))
 	// Read the response
 	n, err := conn.Read(buf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read the response: %v", err)
 	}
 	response := buf[:n]
+	if n < 2 {
+		return nil, fmt.Errorf("the response is too short: %d bytes", n)
+	}
 
 	// Check if the response ID matches the request ID
 	if !IDMatcher(message[:2], response[:2]) {
@@ -80,6 +93,87 @@ func (c *Client) Query(message []byte) ([]byte, error) {
 	return response, nil
 }
 
+// receiveBufferSize returns the size of the buffer needed to receive the response to the given message.
+// It is the UDP payload size advertised in the OPT pseudo-record of the message, or 512 bytes without EDNS.
+func receiveBufferSize(message []byte) int {
+	parsedMessage, err := dns.ParseMessage(message)
+	if err != nil {
+		return dns.MaxUDPSize
+	}
+	if edns := parsedMessage.EDNS(); edns != nil && edns.UDPSize > dns.MaxUDPSize {
+		return int(edns.UDPSize)
+	}
+	return dns.MaxUDPSize
+}
+
+// exchange sends the query with the client and returns the parsed response.
+// If the query carries an OPT pseudo-record and the server rejects it with FORMERR or BADVERS,
+// the query is sent again without it, as described in RFC 6891 section 7.
+func exchange(client *Client, query *dns.DNSMessage) (*dns.DNSMessage, error) {
+	response, err := exchangeWithCookie(client, query)
+	if err != nil || query.EDNS() == nil || !rejectsEDNS(response) {
+		return response, err
+	}
+
+	plainQuery := *query
+	plainQuery.SetEDNS(nil)
+	return exchangeOnce(client, &plainQuery)
+}
+
+// exchangeWithCookie sends the query with the cookie of the server, if the client has a CookieJar and the query
+// carries an OPT pseudo-record, and returns the parsed response once its cookie is validated.
+// If the server answers with BADCOOKIE, the query is sent once more with the server cookie it returned.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7873#section-5.3 for more information
+func exchangeWithCookie(client *Client, query *dns.DNSMessage) (*dns.DNSMessage, error) {
+	edns := query.EDNS()
+	if client.cookies == nil || edns == nil {
+		return exchangeOnce(client, query)
+	}
+
+	for attempt := 0; ; attempt++ {
+		cookie := client.cookies.Cookie(client.address())
+		edns.SetOption(cookie)
+		cookieQuery := *query
+		cookieQuery.SetEDNS(edns)
+
+		response, err := exchangeOnce(client, &cookieQuery)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.cookies.Update(client.address(), cookie, response); err != nil {
+			return nil, err
+		}
+		if response.RCode() != dns.RCodeBadCookie || attempt > 0 {
+			return response, nil
+		}
+	}
+}
+
+// exchangeOnce sends the query with the client and returns the parsed response.
+func exchangeOnce(client *Client, query *dns.DNSMessage) (*dns.DNSMessage, error) {
+	message, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the query: %w", err)
+	}
+	response, err := client.Query(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the DNS server: %w", err)
+	}
+	parsedResponse, err := dns.ParseMessage(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the DNS response: %w", err)
+	}
+	return parsedResponse, nil
+}
+
+// rejectsEDNS returns whether the response shows that the server does not support the EDNS query it answers:
+// either a FORMERR without OPT pseudo-record from a server unaware of EDNS, or a BADVERS.
+func rejectsEDNS(response *dns.DNSMessage) bool {
+	rcode := response.RCode()
+	return rcode == dns.RCodeBadVersion || (rcode == uint16(dns.RCodeFormatError) && response.EDNS() == nil)
+}
+
 // IDMatcher checks if the two given IDs match.
 func IDMatcher(m1, m2 []byte) bool {
 	m1ID := m1[0:2]
@@ -90,121 +184,536 @@ func IDMatcher(m1, m2 []byte) bool {
 
 // Option represents the options for the Resolve function.
 type Option struct {
-	UseCache bool // Whether to use the cache or not.
+	UseCache     bool                 // Whether to use the cache or not.
+	UDPSize      uint16               // The UDP payload size advertised with EDNS in the queries. EDNS is not used if it is zero.
+	ClientSubnet netip.Prefix         // The client subnet sent with EDNS in the queries, see dns.ParseClientSubnet. It is not sent if invalid.
+	Cookies      *CookieJar           // The cookies sent with EDNS in the queries and validated in the responses. Cookies are not used if it is nil.
+	Quiet        bool                 // Whether to hide the progress of the resolution, which is printed in stdout otherwise.
+	DNSSEC       bool                 // Whether to validate the responses with DNSSEC, see LookupValidated. EDNS is used even if UDPSize is zero.
+	TrustAnchors []dns.ResourceRecord // The DS records of the zones trusted to validate the responses with DNSSEC, owned by these zones. RootTrustAnchors are used if nil.
+	Root         netip.AddrPort       // The root DNS server the resolution starts from, whose port is used for every server. dns.RootDNS is used if invalid.
+	Unicode      bool                 // Whether to print the U-labels of the internationalized domain names along with their A-labels, see dns.ToUnicode.
+
+	aliases int // The number of SVCB or HTTPS aliases followed so far, see serviceRecords.
+}
+
+// logf prints the progress of the resolution in stdout, unless the option is quiet.
+func (o Option) logf(format string, args ...any) {
+	if !o.Quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
+// displayName returns the domain name followed by its U-labels, such as xn--bcher-kva.example (bücher.example),
+// if the option prints them and the name holds A-labels.
+func (o Option) displayName(name string) string {
+	if unicode := dns.ToUnicode(name); o.Unicode && unicode != name {
+		return fmt.Sprintf("%s (%s)", name, unicode)
+	}
+	return name
+}
+
+// udpSize returns the UDP payload size advertised with EDNS in the queries, or zero if EDNS is not used.
+func (o Option) udpSize() uint16 {
+	if o.UDPSize == 0 && o.DNSSEC {
+		return dns.DefaultEDNSUDPSize
+	}
+	return o.UDPSize
+}
+
+// rootServer returns the address of the root DNS server the resolution starts from.
+func (o Option) rootServer() netip.AddrPort {
+	if o.Root.IsValid() {
+		return o.Root
+	}
+	return netip.AddrPortFrom(netip.MustParseAddr(dns.RootDNS), dns.RootDNSPort)
+}
+
+// clientSubnet returns the client subnet sent in the queries, or an invalid prefix if none is sent.
+func (o Option) clientSubnet() netip.Prefix {
+	if o.udpSize() == 0 {
+		return netip.Prefix{}
+	}
+	return o.ClientSubnet
 }
 
 // DefaultOption returns the default options for the Resolve function.
 func DefaultOption() Option {
 	return Option{
 		UseCache: true,
+		UDPSize:  dns.DefaultEDNSUDPSize,
+		Cookies:  DefaultCookieJar,
+	}
+}
+
+// Errors returned by Lookup when the DNS servers have no records to answer with.
+var (
+	ErrNameNotFound = errors.New("the domain name does not exist")
+	ErrNoRecords    = errors.New("no records of the requested type")
+)
+
+// maxReferrals is the maximum number of referrals followed while resolving a single name.
+const maxReferrals = 32
+
+// maxAliases is the maximum number of SVCB or HTTPS records in AliasMode followed while resolving a service.
+const maxAliases = 8
+
+// optionFrom returns the given option, or the default option if none is given.
+func optionFrom(options []Option) Option {
+	if len(options) > 0 {
+		return options[0]
 	}
+	return DefaultOption()
 }
 
 // Resolve sends a DNS query to the DNS server and returns the 1st answer of the query in parsed format.
 // This function recursively queries the DNS server until it finds the Answer of the given type.
-// It also prints all the non-authoritative answers in stdout.
+// It also prints all the non-authoritative answers in stdout, with the U-labels of their names if the option asks for them.
+//
+// It returns an empty string if the domain could not be resolved. Use Lookup to get the error.
 func Resolve(domain string, questionType uint16, options ...Option) string {
-	var option Option
-	if len(options) > 0 {
-		option = options[0]
-	} else {
-		option = DefaultOption()
+	option := optionFrom(options)
+	records, err := Lookup(domain, questionType, option)
+	if err != nil {
+		fmt.Printf("Failed to resolve %s: %v\n", domain, err)
+		return ""
+	}
+
+	fmt.Printf("\nNon-authoritative answer:\n")
+	result := ""
+	for _, record := range records {
+		if record.Type == dns.TypeCNAME && questionType != dns.TypeCNAME {
+			fmt.Printf("%s	canonical name = %s\n", option.displayName(record.Name), option.displayName(record.RDataParsed))
+			continue
+		}
+		printAnswer(record, option)
+		if result == "" {
+			result = record.RDataParsed
+		}
+	}
+	return result
+}
+
+// Lookup returns the records of the given type for the domain.
+// The records are read from the cache if the option allows it and does not validate them with DNSSEC,
+// as the cache does not keep their security status. Otherwise they are resolved iteratively
+// starting from the root DNS server, in which case they are added to the cache.
+//
+// CNAME records are followed: the returned records hold the CNAME records that lead to the records
+// of the given type, followed by these records.
+//
+// Internationalized domain names are looked up by their A-labels, see dns.ToASCII.
+// It fails with dns.ErrInvalidDomainName if the domain is not a valid name.
+func Lookup(domain string, questionType uint16, options ...Option) ([]dns.ResourceRecord, error) {
+	option := optionFrom(options)
+	domain, err := dns.ToASCII(domain)
+	if err != nil {
+		return nil, err
 	}
 
 	cacheClient, err := cache.NewClient()
 	if err != nil {
-		fmt.Printf("Failed to create the cache client: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to create the cache client: %w", err)
 	}
 	defer cacheClient.Close()
 
 	// Using cache
-	if option.UseCache {
-		results, err := cacheClient.Get(domain)
+	if option.UseCache && !option.DNSSEC {
+		results, err := cacheClient.GetBySubnet(domain, questionType, option.clientSubnet())
 		if err != nil {
-			fmt.Printf("Failed to get the cached results: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("failed to get the cached results: %w", err)
 		}
 		if len(results) > 0 {
-			fmt.Printf("Cache hit for %s\n", domain)
-			for _, result := range results {
-				fmt.Printf("Name: %s\n", result.Name)
-				fmt.Printf("Address: %s\n", result.RDataParsed)
-			}
-			return results[0].RDataParsed
+			option.logf("Cache hit for %s\n", domain)
+			return results, nil
+		}
+	}
+
+	records, scope, targetAddresses, err := resolveIteratively(domain, questionType, option)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Type == questionType {
+			cacheClient.InsertRecord(domain, record, scope)
+		}
+	}
+	// The addresses of the service targets save the queries the client is about to send for them
+	for _, record := range targetAddresses {
+		cacheClient.InsertRecord(record.Name, record, scope)
+	}
+	return records, nil
+}
+
+// newQuery creates the query for the records of the given type for the domain.
+// It carries an OPT pseudo-record, with the client subnet if any, when the option enables EDNS,
+// and asks for the DNSSEC records with the DO bit when the option validates them.
+func newQuery(domain string, questionType uint16, option Option) *dns.DNSMessage {
+	query := dns.NewQuery(domain, questionType)
+	if option.udpSize() > 0 {
+		query.WithEDNS(option.udpSize())
+		if option.DNSSEC {
+			query.WithDO()
+		}
+		if subnet := option.clientSubnet(); subnet.IsValid() {
+			query.WithEDNSOption(dns.NewClientSubnetOption(subnet))
+		}
+	}
+	return query
+}
+
+// responseScope returns the client subnet for which the answer of the response is valid,
+// or an invalid prefix if it is valid for any client.
+// It fails if the response carries a client subnet that does not match the one of the query.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7871#section-7.3 for more information
+func responseScope(response *dns.DNSMessage, option Option) (netip.Prefix, error) {
+	subnet := option.clientSubnet()
+	edns := response.EDNS()
+	if !subnet.IsValid() || edns == nil {
+		return netip.Prefix{}, nil
+	}
+	ecs, ok := edns.Option(dns.EDNSOptionClientSubnet).(*dns.ClientSubnetOption)
+	if !ok {
+		// The server ignored the client subnet, so the answer is the same for all clients
+		return netip.Prefix{}, nil
+	}
+	if ecs.Source != subnet.Masked() {
+		return netip.Prefix{}, fmt.Errorf("the client subnet of the response %s does not match the query %s", ecs.Source, subnet)
+	}
+	if ecs.ScopePrefix == 0 {
+		return netip.Prefix{}, nil
+	}
+	return ecs.Scope(), nil
+}
+
+// LookupMessage resolves the records of the given type for the domain iteratively, starting from the
+// root DNS server, and returns the full response of the server that answered, without using the cache.
+// Responses with an error code, such as NXDOMAIN, are returned as well.
+// If the option validates the responses with DNSSEC, the response is validated as by LookupValidated
+// and bogus responses are replaced with a SERVFAIL response.
+func LookupMessage(domain string, questionType uint16, options ...Option) (*dns.DNSMessage, error) {
+	option := optionFrom(options)
+	if option.DNSSEC {
+		response, _, err := LookupValidated(domain, questionType, option)
+		if errors.Is(err, ErrBogus) {
+			option.logf("%v\n", err)
+			return response, nil
 		}
+		return response, err
 	}
+	response, _, err := queryIteratively(domain, questionType, option)
+	return response, err
+}
 
-	question := dns.NewQuestion(domain, questionType, dns.ClassIN)
-	flag := dns.NewHeaderFlag(false, 0, false, false, false, false, 0, 0).GenerateFlag()
-	header := dns.NewHeader(22, flag, 1, 0, 0, 0)
-	DNSMessage := dns.NewDNSMessage(*header, []dns.Question{*question})
-	var parsedResponse *dns.DNSMessage
-	dnsServerIP := dns.RootDNS
-	dnsServerPort := dns.RootDNSPort
+// queryIteratively sends the query for the records of the given type for the domain, following the referrals
+// from the root DNS server down to an authoritative server. It returns the response that ends the resolution:
+// an answer, an error code, or a response without answer nor referral.
+//
+// If the option validates the responses with DNSSEC, the zone cuts crossed by the referrals are validated
+// and the security of the response is returned, see validator. Otherwise the security is indeterminate.
+// It fails with dns.ErrInvalidDomainName if the domain is not a valid name.
+func queryIteratively(domain string, questionType uint16, option Option) (*dns.DNSMessage, Security, error) {
+	domain, err := dns.ToASCII(domain)
+	if err != nil {
+		return nil, SecurityIndeterminate, err
+	}
+	DNSMessage := newQuery(domain, questionType, option)
+	root := option.rootServer()
+	dnsServerIP := root.Addr().String()
+	dnsServerPort := int(root.Port())
 
-	for {
-		fmt.Printf("Querying %s for %s\n", dnsServerIP, domain)
-		// fmt.Printf("DNS Message:\n %+v\n\n", DNSMessage)
+	var v *validator
+	for referrals := 0; referrals < maxReferrals; referrals++ {
+		option.logf("Querying %s for %s\n", dnsServerIP, domain)
 		client := NewClient(dnsServerIP, dnsServerPort)
-		response, err := client.Query(DNSMessage.ToBytes())
+		client.cookies = option.Cookies
+		if option.DNSSEC && v == nil {
+			var err error
+			if v, err = newValidator(client, option); err != nil {
+				return nil, SecurityBogus, err
+			}
+		}
+		parsedResponse, err := exchange(client, DNSMessage)
 		if err != nil {
-			fmt.Printf("Failed to query the DNS server: %v\n", err)
-			return ""
+			return nil, SecurityIndeterminate, err
 		}
-		parsedResponse = dns.DNSMessageFromBytes(response)
-		// fmt.Printf("parsedResponse:\n %+v\n\n", parsedResponse)
 		flags := dns.HeaderFlagFromUint16(parsedResponse.Header.Flags)
 
-		if flags.HasError() {
-			fmt.Printf("The DNS server returned an error: %s\n", parsedResponse.Answers[0].RDataParsed)
-			os.Exit(1)
-		}
-
 		if flags.IsQuery() {
-			fmt.Printf("The returned DNS message is not a response.\n")
-			os.Exit(1)
-		}
-
-		if parsedResponse.Header.ANCount > 0 {
-			fmt.Printf("\nNon-authoritative answer:\n")
-			if parsedResponse.Answers[0].Type == dns.TypeCNAME {
-				fmt.Printf("%s	canonical name = %s.\n", parsedResponse.Answers[0].Name, parsedResponse.Answers[0].RDataParsed)
-				Resolve(parsedResponse.Answers[0].RDataParsed, dns.TypeA)
-			} else {
-				for _, answer := range parsedResponse.Answers {
-					fmt.Printf("Name: %s\n", answer.Name)
-					fmt.Printf("Address: %s\n", answer.RDataParsed)
-					cacheClient.Insert(domain, dns.TypeA, answer.RDataParsed, int(answer.TTL))
-				}
+			return nil, SecurityIndeterminate, fmt.Errorf("the returned DNS message is not a response")
+		}
+		if parsedResponse.RCode() != uint16(dns.RCodeNoError) || parsedResponse.Header.ANCount > 0 {
+			return validated(v, client, parsedResponse, domain, questionType)
+		}
+		nextIP := getRecord(parsedResponse.AdditionalRRs)
+		nsDomain := getRecord(parsedResponse.AuthorityRRs)
+		if nextIP == "" {
+			if nsDomain == "" {
+				// The authority section holds no referral (e.g. only an SOA record), so the name has no such records
+				return validated(v, client, parsedResponse, domain, questionType)
 			}
-			break
-		} else if parsedResponse.Header.ARCount > 0 {
-			if ip := getRecord(parsedResponse.AdditionalRRs); ip != "" {
-				dnsServerIP = ip
+			nsRecords, err := Lookup(nsDomain, dns.TypeA, option)
+			if err != nil {
+				return nil, SecurityIndeterminate, fmt.Errorf("failed to resolve the name server %s: %w", nsDomain, err)
 			}
+			nextIP = getRecord(nsRecords)
+		}
+		if nextIP == "" {
 			continue
-		} else if parsedResponse.Header.NSCount > 0 {
-			if nsDomain := getRecord(parsedResponse.AuthorityRRs); nsDomain != "" {
-				dnsServerIP = Resolve(nsDomain, dns.TypeA)
+		}
+		if zone := referralZone(parsedResponse); v != nil && zone != "" {
+			child := NewClient(nextIP, dnsServerPort)
+			child.cookies = option.Cookies
+			if err := v.delegate(client, child, zone, parsedResponse); err != nil {
+				return nil, SecurityBogus, err
 			}
-		} else {
-			fmt.Printf("No answers found for %s\n", domain)
-			os.Exit(1)
 		}
+		dnsServerIP = nextIP
+	}
+	return nil, SecurityIndeterminate, fmt.Errorf("too many referrals while resolving %s", domain)
+}
+
+// validated returns the response that ends the resolution along with its security, validated by v unless it is nil.
+func validated(v *validator, client *Client, response *dns.DNSMessage, domain string, questionType uint16) (*dns.DNSMessage, Security, error) {
+	if v == nil {
+		return response, SecurityIndeterminate, nil
+	}
+	security, err := v.validate(client, response, domain, questionType)
+	if err != nil {
+		return nil, SecurityBogus, err
+	}
+	return response, security, nil
+}
+
+// referralZone returns the zone a referral delegates to, the owner of its NS records, or an empty string if there is none.
+func referralZone(response *dns.DNSMessage) string {
+	for _, record := range response.AuthorityRRs {
+		if record.Type == dns.TypeNS {
+			return strings.TrimSuffix(record.Name, ".")
+		}
+	}
+	return ""
+}
+
+// resolveIteratively resolves the records of the given type for the domain, see queryIteratively.
+// It also returns the client subnet for which the records are valid, see responseScope,
+// and the addresses of the targets of SVCB or HTTPS records sent along by the server, see serviceTargetAddresses.
+func resolveIteratively(domain string, questionType uint16, option Option) ([]dns.ResourceRecord, netip.Prefix, []dns.ResourceRecord, error) {
+	response, _, err := queryIteratively(domain, questionType, option)
+	if err != nil {
+		return nil, netip.Prefix{}, nil, err
+	}
+	rcode := response.RCode()
+	if rcode == uint16(dns.RCodeNameError) {
+		return nil, netip.Prefix{}, nil, fmt.Errorf("%w: %s", ErrNameNotFound, domain)
+	}
+	if rcode != uint16(dns.RCodeNoError) {
+		return nil, netip.Prefix{}, nil, fmt.Errorf("the DNS server returned an error: %s", dns.RCodeToString(rcode))
+	}
+	if response.Header.ANCount == 0 {
+		return nil, netip.Prefix{}, nil, fmt.Errorf("%w: %s %s", ErrNoRecords, domain, dns.RTypeToString(questionType))
+	}
+
+	scope, err := responseScope(response, option)
+	if err != nil {
+		return nil, netip.Prefix{}, nil, err
+	}
+	records, err := answerRecords(response.Answers, domain, questionType, option)
+	if err != nil {
+		return nil, netip.Prefix{}, nil, err
+	}
+	return records, scope, serviceTargetAddresses(response), nil
+}
+
+// answerRecords returns the aliases that redirect the domain, see aliasRecords, and the records of the given type
+// found in the answers. If the answers only hold a chain of aliases, the target of the last one is resolved as well.
+func answerRecords(answers []dns.ResourceRecord, domain string, questionType uint16, option Option) ([]dns.ResourceRecord, error) {
+	records := recordsOfType(answers, questionType)
+	if questionType == dns.TypeCNAME && len(records) > 0 {
+		return records, nil
+	}
+	cnames, err := aliasRecords(answers, domain)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && (questionType == dns.TypeSVCB || questionType == dns.TypeHTTPS) {
+		return serviceRecords(cnames, records, questionType, option)
+	}
+	if len(records) > 0 {
+		return append(cnames, records...), nil
+	}
+	if len(cnames) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoRecords, domain, dns.RTypeToString(questionType))
+	}
+
+	target := cnames[len(cnames)-1].Data.(*dns.CNAME).Target
+	targetRecords, err := Lookup(target, questionType, option)
+	if err != nil {
+		return nil, err
+	}
+	return append(cnames, targetRecords...), nil
+}
+
+// aliasRecords returns the CNAME and DNAME records of the answers that redirect the domain, in the order in which
+// they are followed. Each DNAME record is followed by the CNAME record it synthesizes for the name it redirects,
+// which replaces the one sent along by the server: the server may not send it, and it is not trusted if it does
+// not match the DNAME record.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6672#section-3.4 for more information
+func aliasRecords(answers []dns.ResourceRecord, domain string) ([]dns.ResourceRecord, error) {
+	var aliases []dns.ResourceRecord
+	name := domain
+	// Each record is followed at most once, which stops the loops
+	for followed := 0; followed < len(answers); followed++ {
+		if dname := redirectingDNAME(answers, name); dname != nil {
+			cname, err := dns.SynthesizeCNAME(*dname, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to follow the DNAME record of %s: %w", dname.Name, err)
+			}
+			aliases = append(aliases, *dname, *cname)
+			name = cname.Data.(*dns.CNAME).Target
+			continue
+		}
+		i := slices.IndexFunc(answers, func(record dns.ResourceRecord) bool {
+			return record.Type == dns.TypeCNAME && strings.EqualFold(strings.TrimSuffix(record.Name, "."), strings.TrimSuffix(name, "."))
+		})
+		if i < 0 {
+			break
+		}
+		aliases = append(aliases, answers[i])
+		name = answers[i].Data.(*dns.CNAME).Target
+	}
+	return aliases, nil
+}
+
+// redirectingDNAME returns the DNAME record of the answers owned by an ancestor of the name, or nil if there is none.
+func redirectingDNAME(answers []dns.ResourceRecord, name string) *dns.ResourceRecord {
+	for i, record := range answers {
+		if _, ok := record.Data.(*dns.DNAME); ok && dns.IsSubdomain(name, record.Name) && !dns.IsSubdomain(record.Name, name) {
+			return &answers[i]
+		}
+	}
+	return nil
+}
+
+// serviceRecords returns the CNAME records followed by the SVCB or HTTPS records to connect to the service.
+// If the records hold one in AliasMode, the records in ServiceMode are ignored and the alias is followed to the
+// records of its target, as a CNAME would be. If the target has none, the alias is returned last, so that the
+// client connects to the addresses of its target.
+// Otherwise the records in ServiceMode are returned by order of priority, without the malformed ones.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-3 for more information
+func serviceRecords(cnames []dns.ResourceRecord, records []dns.ResourceRecord, questionType uint16, option Option) ([]dns.ResourceRecord, error) {
+	var services []dns.ResourceRecord
+	for _, record := range records {
+		binding := serviceBinding(record)
+		if binding == nil {
+			continue
+		}
+		if !binding.IsAliasMode() {
+			services = append(services, record)
+			continue
+		}
+		if binding.Target == "" {
+			// An alias to the root means that the service is not available
+			return nil, fmt.Errorf("%w: %s %s", ErrNoRecords, record.Name, dns.RTypeToString(questionType))
+		}
+		if option.aliases >= maxAliases {
+			return nil, fmt.Errorf("too many aliases while resolving %s", record.Name)
+		}
+		option.aliases++
+		chain := append(cnames, record)
+		targetRecords, err := Lookup(binding.Target, questionType, option)
+		if errors.Is(err, ErrNoRecords) {
+			return chain, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return append(chain, targetRecords...), nil
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoRecords, records[0].Name, dns.RTypeToString(questionType))
+	}
+	slices.SortStableFunc(services, func(a, b dns.ResourceRecord) int {
+		return int(serviceBinding(a).Priority) - int(serviceBinding(b).Priority)
+	})
+	return append(cnames, services...), nil
+}
+
+// serviceTargetAddresses returns the A and AAAA records of the additional section of the response which resolve the
+// targets of its SVCB or HTTPS records in ServiceMode, sent by servers to save the client the queries for them.
+// Only the targets at or below the owner of their record are accepted, as the server answering for the owner
+// may not be authoritative for the other names.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9460#section-4.1 for more information
+func serviceTargetAddresses(response *dns.DNSMessage) []dns.ResourceRecord {
+	targets := make(map[string]bool)
+	for _, answer := range response.Answers {
+		binding := serviceBinding(answer)
+		if binding == nil || binding.IsAliasMode() {
+			continue
+		}
+		if target := binding.TargetName(answer.Name); dns.IsSubdomain(target, answer.Name) {
+			targets[strings.ToLower(target)] = true
+		}
+	}
+	var addresses []dns.ResourceRecord
+	for _, record := range response.AdditionalRRs {
+		if (record.Type == dns.TypeA || record.Type == dns.TypeAAAA) && targets[strings.ToLower(record.Name)] {
+			addresses = append(addresses, record)
+		}
+	}
+	return addresses
+}
+
+// serviceBinding returns the resource data of an SVCB or HTTPS record, or nil if the record is not one or is malformed.
+func serviceBinding(record dns.ResourceRecord) *dns.SVCB {
+	switch data := record.Data.(type) {
+	case *dns.SVCB:
+		return data
+	case *dns.HTTPS:
+		return &data.SVCB
+	}
+	return nil
+}
+
+// recordsOfType returns the records of the given type, in the order in which they appear.
+func recordsOfType(records []dns.ResourceRecord, rType uint16) []dns.ResourceRecord {
+	var filtered []dns.ResourceRecord
+	for _, record := range records {
+		if record.Type == rType {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// printAnswer prints the name and the parsed resource data of an answer in stdout.
+func printAnswer(answer dns.ResourceRecord, option Option) {
+	fmt.Printf("Name: %s\n", option.displayName(answer.Name))
+	switch answer.Type {
+	case dns.TypeA, dns.TypeAAAA:
+		fmt.Printf("Address: %s\n", answer.RDataParsed)
+	default:
+		fmt.Printf("%s: %s\n", dns.RTypeToString(answer.Type), answer.RDataParsed)
 	}
-	return parsedResponse.Answers[0].RDataParsed
 }
 
 // getRecord returns the first record of the given type from the given records.
-// It is used to get the parsed address of the whitelisted record type.
+// It is used to get the address or the host name held by the whitelisted record types.
 //
 // It returns an empty string if no record of the given type is found.
 func getRecord(records []dns.ResourceRecord) string {
 	for _, record := range records {
-		switch record.Type {
-		case dns.TypeA, dns.TypeNS, dns.TypeCNAME:
-			return record.RDataParsed
+		switch data := record.Data.(type) {
+		case *dns.A:
+			return data.Address.String()
+		case *dns.NS:
+			return data.Host
+		case *dns.CNAME:
+			return data.Target
 		}
 	}
 	return ""
@@ -3,6 +3,10 @@ package network
 import (
 	"dns-resolver-go/dns"
 	"encoding/hex"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,4 +31,243 @@ func TestClient(t *testing.T) {
 		ip := Resolve("dns.google.com", dns.TypeA)
 		assert.Regexp(t, `\b(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\b`, ip)
 	})
+	t.Run("Should size the receive buffer to the advertised UDP payload size", func(t *testing.T) {
+		question := dns.NewQuestion("dns.google.com", dns.TypeA, dns.ClassIN)
+		query := dns.NewDNSMessage(*dns.NewHeader(22, 0, 1, 0, 0, 0), []dns.Question{*question})
+		assert.Equal(t, dns.MaxUDPSize, receiveBufferSize(query.ToBytes()))
+
+		query.SetEDNS(dns.NewEDNS(4096))
+		assert.Equal(t, 4096, receiveBufferSize(query.ToBytes()))
+	})
+
+	t.Run("Should retry without EDNS when the server rejects it", func(t *testing.T) {
+		var mu sync.Mutex
+		queries := make([]*dns.DNSMessage, 0)
+		port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+			mu.Lock()
+			defer mu.Unlock()
+			queries = append(queries, query)
+			rcode := uint8(dns.RCodeNoError)
+			if query.EDNS() != nil {
+				rcode = dns.RCodeFormatError
+			}
+			flag := dns.NewHeaderFlag(true, 0, false, false, false, false, 0, rcode).GenerateFlag()
+			return dns.NewDNSMessage(*dns.NewHeader(query.Header.ID, flag, 1, 0, 0, 0), query.Questions)
+		})
+
+		question := dns.NewQuestion("dns.google.com", dns.TypeA, dns.ClassIN)
+		query := dns.NewDNSMessage(*dns.NewHeader(22, 0, 1, 0, 0, 0), []dns.Question{*question})
+		query.SetEDNS(dns.NewEDNS(1232))
+		response, err := exchange(NewClient("127.0.0.1", port), query)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(dns.RCodeNoError), response.RCode())
+		assert.Equal(t, 2, len(queries))
+		assert.NotNil(t, queries[0].EDNS())
+		assert.Nil(t, queries[1].EDNS())
+	})
+
+	t.Run("Should send the client subnet with EDNS", func(t *testing.T) {
+		option := DefaultOption()
+		option.ClientSubnet = netip.MustParsePrefix("198.51.100.0/24")
+		edns := newQuery("cdn.example.com", dns.TypeA, option).EDNS()
+		assert.NotNil(t, edns)
+		assert.Equal(t, dns.NewClientSubnetOption(option.ClientSubnet), edns.Option(dns.EDNSOptionClientSubnet))
+
+		option.UDPSize = 0
+		assert.Nil(t, newQuery("cdn.example.com", dns.TypeA, option).EDNS())
+
+		assert.Nil(t, newQuery("cdn.example.com", dns.TypeA, DefaultOption()).EDNS().Option(dns.EDNSOptionClientSubnet))
+	})
+
+	t.Run("Should return the scope of the client subnet of the response", func(t *testing.T) {
+		option := DefaultOption()
+		option.ClientSubnet = netip.MustParsePrefix("198.51.100.0/24")
+		response := newQuery("cdn.example.com", dns.TypeA, option)
+
+		ecs := response.EDNS().Option(dns.EDNSOptionClientSubnet).(*dns.ClientSubnetOption)
+		ecs.ScopePrefix = 20
+		scope, err := responseScope(response, option)
+		assert.NoError(t, err)
+		assert.Equal(t, netip.MustParsePrefix("198.51.96.0/20"), scope)
+
+		ecs.ScopePrefix = 0
+		scope, err = responseScope(response, option)
+		assert.NoError(t, err)
+		assert.False(t, scope.IsValid())
+
+		response.SetEDNS(dns.NewEDNS(1232, dns.NewClientSubnetOption(netip.MustParsePrefix("203.0.113.0/24"))))
+		_, err = responseScope(response, option)
+		assert.Error(t, err)
+
+		scope, err = responseScope(response, DefaultOption())
+		assert.NoError(t, err)
+		assert.False(t, scope.IsValid())
+	})
+
+	t.Run("Should order the HTTPS records in ServiceMode by priority", func(t *testing.T) {
+		cname := *dns.NewResourceRecord("www.example.com", dns.TypeCNAME, dns.ClassIN, 300, 0, nil)
+		records := []dns.ResourceRecord{
+			httpsRecord(t, "example.com", &dns.HTTPS{SVCB: dns.SVCB{Priority: 2, Target: "backup.example.com"}}),
+			*dns.NewResourceRecord("example.com", dns.TypeHTTPS, dns.ClassIN, 300, 1, []byte{0}),
+			httpsRecord(t, "example.com", &dns.HTTPS{SVCB: dns.SVCB{Priority: 1}}),
+		}
+		services, err := serviceRecords([]dns.ResourceRecord{cname}, records, dns.TypeHTTPS, DefaultOption())
+		assert.NoError(t, err)
+		if assert.Equal(t, 3, len(services)) {
+			assert.Equal(t, dns.TypeCNAME, services[0].Type)
+			assert.Equal(t, "1 .", services[1].RDataParsed)
+			assert.Equal(t, "2 backup.example.com.", services[2].RDataParsed)
+		}
+	})
+
+	t.Run("Should report a service aliased to the root as unavailable", func(t *testing.T) {
+		records := []dns.ResourceRecord{
+			httpsRecord(t, "example.com", &dns.HTTPS{SVCB: dns.SVCB{Priority: 1}}),
+			httpsRecord(t, "example.com", &dns.HTTPS{SVCB: dns.SVCB{Priority: 0}}),
+		}
+		_, err := serviceRecords(nil, records, dns.TypeHTTPS, DefaultOption())
+		assert.ErrorIs(t, err, ErrNoRecords)
+
+		option := DefaultOption()
+		option.aliases = maxAliases
+		records[1] = httpsRecord(t, "example.com", &dns.HTTPS{SVCB: dns.SVCB{Priority: 0, Target: "svc.example.net"}})
+		_, err = serviceRecords(nil, records, dns.TypeHTTPS, option)
+		assert.ErrorContains(t, err, "too many aliases")
+	})
+
+	t.Run("Should only keep the additional addresses of the targets in the zone of the owner", func(t *testing.T) {
+		response := newQuery("example.com", dns.TypeHTTPS, DefaultOption())
+		response.Answers = []dns.ResourceRecord{
+			httpsRecord(t, "example.com", &dns.HTTPS{SVCB: dns.SVCB{Priority: 1}}),
+			httpsRecord(t, "example.com", &dns.HTTPS{SVCB: dns.SVCB{Priority: 2, Target: "Backup.example.com"}}),
+			httpsRecord(t, "example.com", &dns.HTTPS{SVCB: dns.SVCB{Priority: 3, Target: "cdn.example.net"}}),
+		}
+		addresses := []dns.ResourceRecord{
+			*dns.NewResourceRecord("example.com", dns.TypeA, dns.ClassIN, 300, 4, []byte{192, 0, 2, 1}),
+			*dns.NewResourceRecord("backup.example.com", dns.TypeAAAA, dns.ClassIN, 300, 16, net.ParseIP("2001:db8::1")),
+			*dns.NewResourceRecord("cdn.example.net", dns.TypeA, dns.ClassIN, 300, 4, []byte{192, 0, 2, 3}),
+			*dns.NewResourceRecord("other.example.com", dns.TypeA, dns.ClassIN, 300, 4, []byte{192, 0, 2, 4}),
+		}
+		response.AdditionalRRs = append(addresses, response.AdditionalRRs...)
+		assert.Equal(t, addresses[:2], serviceTargetAddresses(response))
+	})
+
+	t.Run("Should synthesize the CNAME records of the names redirected by DNAME records", func(t *testing.T) {
+		record := func(owner string, data dns.RData) dns.ResourceRecord {
+			rr, err := dns.NewResourceRecordWithData(owner, dns.ClassIN, 300, data)
+			if err != nil {
+				t.Fatalf("failed to create the record: %v", err)
+			}
+			return *rr
+		}
+		answers := []dns.ResourceRecord{
+			record("example.com", &dns.DNAME{Target: "example.net"}),
+			record("www.example.com", &dns.CNAME{Target: "www.attacker.example"}),
+			record("www.example.net", &dns.CNAME{Target: "cdn.example.org"}),
+		}
+		aliases, err := aliasRecords(answers, "www.Example.com.")
+		assert.NoError(t, err)
+		if assert.Equal(t, 3, len(aliases)) {
+			assert.Equal(t, answers[0], aliases[0])
+			assert.Equal(t, "www.example.net", aliases[1].RDataParsed, "the CNAME record that does not match the DNAME record is replaced")
+			assert.Equal(t, answers[2], aliases[2])
+		}
+
+		long := strings.Repeat("a", 63) + "." + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + ".example.net"
+		_, err = aliasRecords([]dns.ResourceRecord{record("example.com", &dns.DNAME{Target: long})}, strings.Repeat("d", 63)+".example.com")
+		assert.ErrorIs(t, err, dns.ErrNameTooLong)
+
+		port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+			name := query.Questions[0].Name
+			response := *query
+			response.Header.Flags = dns.NewHeaderFlag(true, 0, true, false, false, false, 0, 0).GenerateFlag()
+			switch {
+			case dns.IsSubdomain(name, "example.com"):
+				// A server that does not send the synthesized CNAME record, nor the records of its target
+				response.Answers = []dns.ResourceRecord{record("example.com", &dns.DNAME{Target: "example.net"})}
+			case dns.IsSubdomain(name, "www.example.net"):
+				response.Answers = []dns.ResourceRecord{*dns.NewResourceRecord(name, dns.TypeA, dns.ClassIN, 300, 4, []byte{192, 0, 2, 1})}
+			}
+			response.Header.ANCount = uint16(len(response.Answers))
+			return &response
+		})
+		option := Option{Quiet: true, Root: netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), uint16(port))}
+		records, err := Lookup("www.example.com", dns.TypeA, option)
+		assert.NoError(t, err)
+		if assert.Equal(t, 3, len(records)) {
+			assert.Equal(t, dns.TypeDNAME, records[0].Type)
+			assert.Equal(t, "www.example.com", records[1].Name)
+			assert.Equal(t, "192.0.2.1", records[2].RDataParsed)
+		}
+	})
+
+	t.Run("Should look up the A-labels of the internationalized domain names", func(t *testing.T) {
+		var names []string
+		port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+			name := query.Questions[0].Name
+			names = append(names, name)
+			response := *query
+			response.Header.Flags = dns.NewHeaderFlag(true, 0, true, false, false, false, 0, 0).GenerateFlag()
+			response.Answers = []dns.ResourceRecord{*dns.NewResourceRecord(name, dns.TypeA, dns.ClassIN, 300, 4, []byte{192, 0, 2, 1})}
+			response.Header.ANCount = 1
+			return &response
+		})
+		option := Option{Quiet: true, Root: netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), uint16(port))}
+		records, err := Lookup("www.Bücher.example", dns.TypeA, option)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"www.xn--bcher-kva.example"}, names)
+		if assert.Equal(t, 1, len(records)) {
+			assert.Equal(t, "www.xn--bcher-kva.example", records[0].Name)
+		}
+
+		_, err = Lookup("www..example", dns.TypeA, option)
+		assert.ErrorIs(t, err, dns.ErrInvalidDomainName)
+		_, err = LookupMessage("-bücher.example", dns.TypeA, option)
+		assert.ErrorIs(t, err, dns.ErrInvalidDomainName)
+		assert.Equal(t, 1, len(names), "the invalid names are not sent")
+	})
+
+	t.Run("Should display the U-labels along with the A-labels if the option asks for them", func(t *testing.T) {
+		option := Option{Unicode: true}
+		assert.Equal(t, "www.xn--bcher-kva.example (www.bücher.example)", option.displayName("www.xn--bcher-kva.example"))
+		assert.Equal(t, "www.example.com", option.displayName("www.example.com"))
+		assert.Equal(t, "www.xn--bcher-kva.example", Option{}.displayName("www.xn--bcher-kva.example"))
+	})
+}
+
+// httpsRecord returns an HTTPS record of the owner with the given resource data.
+func httpsRecord(t *testing.T, owner string, data *dns.HTTPS) dns.ResourceRecord {
+	record, err := dns.NewResourceRecordWithData(owner, dns.ClassIN, 300, data)
+	if err != nil {
+		t.Fatalf("failed to create the HTTPS record: %v", err)
+	}
+	return *record
+}
+
+// startTestServer starts a UDP DNS server on a random local port, answering the queries with the handler.
+// It returns the port of the server, which is stopped at the end of the test.
+func startTestServer(t *testing.T, handler func(query *dns.DNSMessage) *dns.DNSMessage) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start the test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query, err := dns.ParseMessage(buf[:n])
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(handler(query).ToBytes(), addr)
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
 }
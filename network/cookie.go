@@ -0,0 +1,146 @@
+package network
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"dns-resolver-go/dns"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Errors returned when the cookie of a response shows that it may be spoofed.
+var (
+	ErrCookieMismatch = errors.New("the client cookie of the response does not match the query")
+	ErrCookieMissing  = errors.New("the response has no cookie while the server is known to send one")
+)
+
+// CookieState describes the cookies exchanged with a server, for diagnostics.
+type CookieState struct {
+	Server       string    // Server is the address of the server.
+	ClientCookie []byte    // ClientCookie is the client cookie sent to the server.
+	ServerCookie []byte    // ServerCookie is the last server cookie received from the server, empty if none.
+	UpdatedAt    time.Time // UpdatedAt is the time the server cookie was received.
+	BadCookies   int       // BadCookies is the number of BADCOOKIE responses received from the server.
+}
+
+// CookieJar holds the DNS cookies exchanged with the servers.
+// It is safe for concurrent use.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7873#section-5.1 for more information
+type CookieJar struct {
+	mu      sync.Mutex
+	secret  []byte
+	servers map[string]*CookieState
+}
+
+// DefaultCookieJar is the CookieJar used by DefaultOption.
+var DefaultCookieJar = NewCookieJar()
+
+// NewCookieJar creates a new CookieJar with a random client secret.
+func NewCookieJar() *CookieJar {
+	secret := make([]byte, 16)
+	rand.Read(secret)
+	return &CookieJar{
+		secret:  secret,
+		servers: make(map[string]*CookieState),
+	}
+}
+
+// Cookie returns the cookie option to send to the server: its client cookie, with the last server cookie received from it.
+func (j *CookieJar) Cookie(server string) *dns.CookieOption {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state := j.state(server)
+	return &dns.CookieOption{
+		Client: bytes.Clone(state.ClientCookie),
+		Server: bytes.Clone(state.ServerCookie),
+	}
+}
+
+// State returns the cookies exchanged with the server, and whether any was.
+func (j *CookieJar) State(server string) (CookieState, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state, ok := j.servers[server]
+	if !ok {
+		return CookieState{}, false
+	}
+	return state.clone(), true
+}
+
+// States returns the cookies exchanged with all the servers, sorted by server address.
+func (j *CookieJar) States() []CookieState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	states := make([]CookieState, 0, len(j.servers))
+	for _, state := range j.servers {
+		states = append(states, state.clone())
+	}
+	sort.Slice(states, func(a, b int) bool { return states[a].Server < states[b].Server })
+	return states
+}
+
+// Update validates the cookie of the response from the server against the cookie sent in the query,
+// and stores the server cookie it carries.
+// It fails if the response may be spoofed: its client cookie is not the one sent, or it has no cookie
+// while the server sent one before.
+//
+// See https://datatracker.ietf.org/doc/html/rfc7873#section-5.3 for more information
+func (j *CookieJar) Update(server string, sent *dns.CookieOption, response *dns.DNSMessage) error {
+	var received *dns.CookieOption
+	if edns := response.EDNS(); edns != nil {
+		received, _ = edns.Option(dns.EDNSOptionCookie).(*dns.CookieOption)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	state := j.state(server)
+	if received == nil {
+		if len(state.ServerCookie) > 0 {
+			return fmt.Errorf("%w: %s", ErrCookieMissing, server)
+		}
+		return nil
+	}
+	if !bytes.Equal(received.Client, sent.Client) {
+		return fmt.Errorf("%w: %s", ErrCookieMismatch, server)
+	}
+	if response.RCode() == dns.RCodeBadCookie {
+		state.BadCookies++
+	}
+	if len(received.Server) > 0 {
+		state.ServerCookie = bytes.Clone(received.Server)
+		state.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// state returns the state of the server, creating it with its client cookie if needed.
+// The client cookie is derived from the client secret and the server address, as suggested by RFC 7873 section 4.1.
+// The client address is left out since the queries are not sent from a fixed address.
+// The caller must hold the lock.
+func (j *CookieJar) state(server string) *CookieState {
+	if state, ok := j.servers[server]; ok {
+		return state
+	}
+	mac := hmac.New(sha256.New, j.secret)
+	mac.Write([]byte(server))
+	state := &CookieState{
+		Server:       server,
+		ClientCookie: mac.Sum(nil)[:dns.ClientCookieLength],
+	}
+	j.servers[server] = state
+	return state
+}
+
+// clone returns a copy of the state which does not share its cookies.
+func (s *CookieState) clone() CookieState {
+	state := *s
+	state.ClientCookie = bytes.Clone(s.ClientCookie)
+	state.ServerCookie = bytes.Clone(s.ServerCookie)
+	return state
+}
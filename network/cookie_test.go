@@ -0,0 +1,150 @@
+package network
+
+import (
+	"dns-resolver-go/dns"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookieJar(t *testing.T) {
+	serverCookie := []byte{1, 0, 0, 0, 0x5f, 0x5e, 0x5d, 0x5c, 0xe1, 0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8}
+
+	// reply answers the query with the given response code and cookie option.
+	reply := func(query *dns.DNSMessage, rcode uint16, cookie *dns.CookieOption) *dns.DNSMessage {
+		flag := dns.NewHeaderFlag(true, 0, false, false, false, false, 0, uint8(rcode&0b1111)).GenerateFlag()
+		response := dns.NewDNSMessage(*dns.NewHeader(query.Header.ID, flag, 1, 0, 0, 0), query.Questions)
+		edns := dns.NewEDNS(1232)
+		edns.ExtendedRCode = uint8(rcode >> 4)
+		if cookie != nil {
+			edns.SetOption(cookie)
+		}
+		response.SetEDNS(edns)
+		return response
+	}
+	// sentCookie returns the cookie option of the query, or nil if it has none.
+	sentCookie := func(query *dns.DNSMessage) *dns.CookieOption {
+		cookie, _ := query.EDNS().Option(dns.EDNSOptionCookie).(*dns.CookieOption)
+		return cookie
+	}
+	cookieQuery := func() *dns.DNSMessage {
+		return newQuery("dns.google.com", dns.TypeA, Option{UDPSize: 1232})
+	}
+
+	t.Run("Should derive a stable client cookie per server", func(t *testing.T) {
+		jar := NewCookieJar()
+		first := jar.Cookie("192.0.2.1:53")
+		assert.Equal(t, dns.ClientCookieLength, len(first.Client))
+		assert.Empty(t, first.Server)
+		assert.Equal(t, first, jar.Cookie("192.0.2.1:53"))
+		assert.NotEqual(t, first.Client, jar.Cookie("192.0.2.2:53").Client)
+		assert.NotEqual(t, first.Client, NewCookieJar().Cookie("192.0.2.1:53").Client)
+	})
+
+	t.Run("Should store the server cookie and send it in the next queries", func(t *testing.T) {
+		var mu sync.Mutex
+		cookies := make([]*dns.CookieOption, 0)
+		port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+			mu.Lock()
+			defer mu.Unlock()
+			cookie := sentCookie(query)
+			cookies = append(cookies, cookie)
+			return reply(query, uint16(dns.RCodeNoError), &dns.CookieOption{Client: cookie.Client, Server: serverCookie})
+		})
+
+		client := NewClient("127.0.0.1", port)
+		client.cookies = NewCookieJar()
+		_, err := exchange(client, cookieQuery())
+		assert.NoError(t, err)
+		_, err = exchange(client, cookieQuery())
+		assert.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 2, len(cookies))
+		assert.Empty(t, cookies[0].Server)
+		assert.Equal(t, serverCookie, cookies[1].Server)
+
+		state, ok := client.cookies.State(client.address())
+		assert.True(t, ok)
+		assert.Equal(t, cookies[0].Client, state.ClientCookie)
+		assert.Equal(t, serverCookie, state.ServerCookie)
+		assert.False(t, state.UpdatedAt.IsZero())
+		assert.Equal(t, []CookieState{state}, client.cookies.States())
+	})
+
+	t.Run("Should retry once with the server cookie returned with BADCOOKIE", func(t *testing.T) {
+		var mu sync.Mutex
+		cookies := make([]*dns.CookieOption, 0)
+		port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+			mu.Lock()
+			defer mu.Unlock()
+			cookie := sentCookie(query)
+			cookies = append(cookies, cookie)
+			rcode := uint16(dns.RCodeNoError)
+			if len(cookie.Server) == 0 {
+				rcode = dns.RCodeBadCookie
+			}
+			return reply(query, rcode, &dns.CookieOption{Client: cookie.Client, Server: serverCookie})
+		})
+
+		client := NewClient("127.0.0.1", port)
+		client.cookies = NewCookieJar()
+		response, err := exchange(client, cookieQuery())
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(dns.RCodeNoError), response.RCode())
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 2, len(cookies))
+		assert.Equal(t, serverCookie, cookies[1].Server)
+		state, _ := client.cookies.State(client.address())
+		assert.Equal(t, 1, state.BadCookies)
+	})
+
+	t.Run("Should reject responses with another client cookie", func(t *testing.T) {
+		port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+			return reply(query, uint16(dns.RCodeNoError), &dns.CookieOption{Client: []byte{1, 2, 3, 4, 5, 6, 7, 8}, Server: serverCookie})
+		})
+
+		client := NewClient("127.0.0.1", port)
+		client.cookies = NewCookieJar()
+		_, err := exchange(client, cookieQuery())
+		assert.ErrorIs(t, err, ErrCookieMismatch)
+		state, _ := client.cookies.State(client.address())
+		assert.Empty(t, state.ServerCookie)
+	})
+
+	t.Run("Should reject responses without cookie from a server known to send one", func(t *testing.T) {
+		var mu sync.Mutex
+		withCookie := true
+		port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+			mu.Lock()
+			defer mu.Unlock()
+			if !withCookie {
+				return reply(query, uint16(dns.RCodeNoError), nil)
+			}
+			withCookie = false
+			return reply(query, uint16(dns.RCodeNoError), &dns.CookieOption{Client: sentCookie(query).Client, Server: serverCookie})
+		})
+
+		client := NewClient("127.0.0.1", port)
+		client.cookies = NewCookieJar()
+		_, err := exchange(client, cookieQuery())
+		assert.NoError(t, err)
+		_, err = exchange(client, cookieQuery())
+		assert.ErrorIs(t, err, ErrCookieMissing)
+	})
+
+	t.Run("Should accept responses without cookie from servers which do not support them", func(t *testing.T) {
+		port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+			return reply(query, uint16(dns.RCodeNoError), nil)
+		})
+
+		client := NewClient("127.0.0.1", port)
+		client.cookies = NewCookieJar()
+		_, err := exchange(client, cookieQuery())
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,794 @@
+package network
+
+import (
+	"bytes"
+	"dns-resolver-go/dns"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Security is the security status of a response validated with DNSSEC.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-4.3 for more information
+type Security int
+
+const (
+	// SecurityIndeterminate means that no trust anchor tells whether the response should be signed.
+	SecurityIndeterminate Security = iota
+	// SecurityInsecure means that the response comes from a zone proven to be unsigned, below a signed delegation without DS records.
+	SecurityInsecure
+	// SecuritySecure means that the response is signed by a chain of trust starting from a trust anchor.
+	SecuritySecure
+	// SecurityBogus means that the response should be signed but its validation failed.
+	SecurityBogus
+)
+
+// String returns the name of the security status.
+func (s Security) String() string {
+	switch s {
+	case SecurityIndeterminate:
+		return "indeterminate"
+	case SecurityInsecure:
+		return "insecure"
+	case SecuritySecure:
+		return "secure"
+	case SecurityBogus:
+		return "bogus"
+	default:
+		return fmt.Sprintf("Security(%d)", int(s))
+	}
+}
+
+// ErrBogus is returned when a response fails the DNSSEC validation, in which case a validating resolver
+// answers with SERVFAIL.
+var ErrBogus = errors.New("the DNSSEC validation failed (SERVFAIL)")
+
+// RootTrustAnchors are the DS records of the key signing keys of the root zone published by IANA,
+// KSK-2017 and KSK-2024, used to validate the responses unless the option gives other trust anchors.
+//
+// See https://data.iana.org/root-anchors/root-anchors.xml for more information
+var RootTrustAnchors = []dns.ResourceRecord{
+	trustAnchor(20326, "E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"),
+	trustAnchor(38696, "683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16"),
+}
+
+// maxNSEC3Iterations is the maximum number of NSEC3 hash iterations accepted in a proof.
+// Responses proven with more iterations are treated as insecure, as hashing them is a denial of service vector.
+//
+// See https://datatracker.ietf.org/doc/html/rfc9276#section-3.2 for more information
+const maxNSEC3Iterations = 100
+
+// nsec3Hex is the base32 encoding of the hashed owner names of NSEC3 records, with the extended hex alphabet and no padding.
+var nsec3Hex = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// trustAnchor returns the DS record of a key signing key of the root zone, using RSA/SHA-256 and a SHA-256 digest.
+func trustAnchor(keyTag uint16, digest string) dns.ResourceRecord {
+	decoded, _ := hex.DecodeString(digest)
+	record, _ := dns.NewResourceRecordWithData("", dns.ClassIN, 0, &dns.DS{
+		KeyTag: keyTag, Algorithm: dns.AlgorithmRSASHA256, DigestType: dns.DigestSHA256, Digest: decoded,
+	})
+	return *record
+}
+
+// LookupValidated resolves the records of the given type for the domain iteratively like LookupMessage, and validates
+// the response with DNSSEC whatever the option: the chain of trust is followed from the trust anchors down the zone
+// cuts crossed by the resolution, and the negative responses are proven with the NSEC or NSEC3 records of the zone.
+//
+// It returns the response along with its security status. Secure responses have the AD bit set.
+// Bogus responses are replaced with a SERVFAIL response, returned along with an error wrapping ErrBogus
+// which tells why the validation failed.
+func LookupValidated(domain string, questionType uint16, options ...Option) (*dns.DNSMessage, Security, error) {
+	option := optionFrom(options)
+	option.DNSSEC = true
+	response, security, err := queryIteratively(domain, questionType, option)
+	if errors.Is(err, ErrBogus) {
+		return serverFailure(newQuery(domain, questionType, option)), SecurityBogus, err
+	}
+	if err != nil {
+		return nil, security, err
+	}
+	flags := dns.HeaderFlagFromUint16(response.Header.Flags)
+	flags.AD = security == SecuritySecure
+	response.Header.Flags = flags.GenerateFlag()
+	return response, security, nil
+}
+
+// serverFailure returns the SERVFAIL response to the query.
+func serverFailure(query *dns.DNSMessage) *dns.DNSMessage {
+	response := query.Reply().SetRCode(uint16(dns.RCodeServerFailure))
+	flags := dns.HeaderFlagFromUint16(response.Header.Flags)
+	flags.RA = true
+	response.Header.Flags = flags.GenerateFlag()
+	response.SetEDNS(query.EDNS())
+	return response
+}
+
+// validator follows the chain of trust down the zone cuts crossed by an iterative resolution,
+// and validates the records of the responses with the keys of the zone of the server that sent them.
+type validator struct {
+	option   Option
+	anchors  []dns.ResourceRecord // anchors are the DS records of the trusted zones.
+	zone     string               // zone is the zone of the servers queried, which signs their responses.
+	keys     []dns.ResourceRecord // keys is the validated DNSKEY RRset of the zone, if it is secure.
+	security Security             // security is the status of the zone: secure, insecure or indeterminate.
+	now      time.Time            // now is the time at which the signatures must be valid.
+}
+
+// newValidator creates a validator starting at the root zone, whose servers are queried with client.
+// It fails if the root zone has trust anchors but its keys can not be validated.
+func newValidator(client *Client, option Option) (*validator, error) {
+	anchors := option.TrustAnchors
+	if anchors == nil {
+		anchors = RootTrustAnchors
+	}
+	v := &validator{option: option, anchors: anchors, security: SecurityIndeterminate, now: time.Now()}
+	if rootAnchors := v.trustAnchors(""); len(rootAnchors) > 0 {
+		return v, v.trust(client, "", rootAnchors)
+	}
+	return v, nil
+}
+
+// bogusf returns an error wrapping ErrBogus with the formatted reason.
+func bogusf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrBogus, fmt.Sprintf(format, args...))
+}
+
+// trustAnchors returns the trust anchors of the zone.
+func (v *validator) trustAnchors(zone string) []dns.ResourceRecord {
+	var anchors []dns.ResourceRecord
+	for _, anchor := range v.anchors {
+		if anchor.Type == dns.TypeDS && dns.Name(anchor.Name).Equal(dns.Name(zone)) {
+			anchors = append(anchors, anchor)
+		}
+	}
+	return anchors
+}
+
+// query sends the query for the records of the given type for the name with the client, asking for the DNSSEC records.
+func (v *validator) query(client *Client, name string, questionType uint16) (*dns.DNSMessage, error) {
+	response, err := exchange(client, newQuery(name, questionType, v.option))
+	if err != nil {
+		return nil, err
+	}
+	if rcode := response.RCode(); rcode != uint16(dns.RCodeNoError) {
+		return nil, fmt.Errorf("the DNS server returned an error: %s", dns.RCodeToString(rcode))
+	}
+	return response, nil
+}
+
+// trust validates the DNSKEY RRset of the zone, queried with client, against the DS records of the zone.
+// The zone is secure if a key matching one of them signs the RRset, and insecure if none of them uses
+// a supported algorithm and digest type.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-5.2 for more information
+func (v *validator) trust(client *Client, zone string, dsRecords []dns.ResourceRecord) error {
+	v.zone, v.keys, v.security = zone, nil, SecurityBogus
+	var supported []*dns.DS
+	for _, record := range dsRecords {
+		if ds, ok := record.Data.(*dns.DS); ok && dns.IsSupportedAlgorithm(ds.Algorithm) && isSupportedDigest(ds.DigestType) {
+			supported = append(supported, ds)
+		}
+	}
+	if len(supported) == 0 {
+		v.security = SecurityInsecure
+		return nil
+	}
+
+	response, err := v.query(client, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return bogusf("failed to get the DNSKEY records of %s: %v", dns.Name(zone).FQDN(), err)
+	}
+	keys := findRRset(signedRRsets(response.Answers), zone, dns.TypeDNSKEY)
+	if keys == nil {
+		return bogusf("%s has no DNSKEY records", dns.Name(zone).FQDN())
+	}
+	for _, ds := range supported {
+		for _, record := range keys.records {
+			key, ok := record.Data.(*dns.DNSKEY)
+			if !ok || key.Algorithm != ds.Algorithm || key.KeyTag() != ds.KeyTag {
+				continue
+			}
+			digest, err := key.ToDS(zone, ds.DigestType)
+			if err != nil || !bytes.Equal(digest.Digest, ds.Digest) {
+				continue
+			}
+			if verifyRRset(keys, zone, []dns.ResourceRecord{record}, v.now) == nil {
+				v.keys, v.security = keys.records, SecuritySecure
+				v.option.logf("Validated the DNSKEY records of %s with the key %d\n", dns.Name(zone).FQDN(), ds.KeyTag)
+				return nil
+			}
+		}
+	}
+	return bogusf("no DNSKEY of %s matching its DS records signs its DNSKEY RRset", dns.Name(zone).FQDN())
+}
+
+// delegate follows the zone cut from the current zone down to the child zone, whose servers are queried with child.
+// The DS RRset of the child zone, or the proof that it has none, is read from the response of the parent zone,
+// whose servers are queried with parent. A child zone without DS records is insecure.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-5.2 for more information
+func (v *validator) delegate(parent, child *Client, zone string, response *dns.DNSMessage) error {
+	if dns.Name(zone).Equal(dns.Name(v.zone)) || !dns.IsSubdomain(zone, v.zone) {
+		return bogusf("the zone %s is not below the zone %s", dns.Name(zone).FQDN(), dns.Name(v.zone).FQDN())
+	}
+	if anchors := v.trustAnchors(zone); len(anchors) > 0 {
+		return v.trust(child, zone, anchors)
+	}
+	if v.security != SecuritySecure {
+		v.zone = zone
+		return nil
+	}
+
+	sets := signedRRsets(append(slices.Clone(response.Answers), response.AuthorityRRs...))
+	if ds := findRRset(sets, zone, dns.TypeDS); ds != nil {
+		security, err := v.verify(parent, ds)
+		if err != nil {
+			return err
+		}
+		if security != SecuritySecure {
+			v.zone = zone
+			return nil
+		}
+		return v.trust(child, zone, ds.records)
+	}
+	if _, err := v.deny(parent, sets, zone, dns.TypeDS, false); err != nil {
+		return err
+	}
+	v.option.logf("%s is not signed\n", dns.Name(zone).FQDN())
+	v.zone, v.keys, v.security = zone, nil, SecurityInsecure
+	return nil
+}
+
+// descend follows the zone cuts from the current zone down to the zone below it, which is served by the same servers.
+func (v *validator) descend(client *Client, zone string) error {
+	response := &dns.DNSMessage{}
+	if v.security == SecuritySecure && len(v.trustAnchors(zone)) == 0 {
+		var err error
+		if response, err = v.query(client, zone, dns.TypeDS); err != nil {
+			return bogusf("failed to get the DS records of %s: %v", dns.Name(zone).FQDN(), err)
+		}
+	}
+	return v.delegate(client, client, zone, response)
+}
+
+// verify checks the signatures of the RRset with the keys of the current zone, and returns the security of the RRset.
+// If the RRset is signed by a zone below the current zone, the zone cuts down to it are followed first.
+func (v *validator) verify(client *Client, set *signedRRset) (Security, error) {
+	if signer := set.signer(); signer != "" && !dns.Name(signer).Equal(dns.Name(v.zone)) && dns.IsSubdomain(signer, v.zone) && dns.IsSubdomain(set.name, signer) {
+		if err := v.descend(client, signer); err != nil {
+			return SecurityBogus, err
+		}
+	}
+	if v.security != SecuritySecure {
+		return v.security, nil
+	}
+	if err := verifyRRset(set, v.zone, v.keys, v.now); err != nil {
+		return SecurityBogus, err
+	}
+	return SecuritySecure, nil
+}
+
+// validate checks the response that ends the resolution of the query, sent by the servers of the current zone,
+// and returns its security: the weakest security of its answers, and for negative responses the security of the
+// proof that the name or the type does not exist. The answers outside of the zone are dropped from the response,
+// as the zone can not vouch for them.
+func (v *validator) validate(client *Client, response *dns.DNSMessage, domain string, questionType uint16) (Security, error) {
+	rcode := response.RCode()
+	if rcode != uint16(dns.RCodeNoError) && rcode != uint16(dns.RCodeNameError) {
+		return SecurityIndeterminate, nil
+	}
+	answers := response.Answers[:0:0]
+	for _, answer := range response.Answers {
+		if dns.IsSubdomain(answer.Name, v.zone) {
+			answers = append(answers, answer)
+		}
+	}
+	response.Answers = answers
+	response.Header.ANCount = uint16(len(answers))
+	authority := signedRRsets(response.AuthorityRRs)
+
+	security := SecuritySecure
+	name, found := domain, false
+	sets := signedRRsets(answers)
+	for _, set := range sets {
+		if set.rType == dns.TypeCNAME && len(set.sigs) == 0 && synthesized(sets, set) {
+			// The CNAME record synthesized from a DNAME record is not signed, the DNAME record vouches for it
+			if dns.Name(set.name).Equal(dns.Name(name)) {
+				name = set.records[0].Data.(*dns.CNAME).Target
+			}
+			continue
+		}
+		setSecurity, err := v.verify(client, set)
+		if err != nil {
+			return SecurityBogus, err
+		}
+		if setSecurity == SecuritySecure && set.expanded() {
+			// The RRset was synthesized from a wildcard, which is only valid if the name does not exist
+			setSecurity, err = v.deny(client, authority, set.name, questionType, true, set.wildcardLabels())
+			if err != nil {
+				return SecurityBogus, err
+			}
+		}
+		security = weakest(security, setSecurity)
+		if !dns.Name(set.name).Equal(dns.Name(name)) {
+			continue
+		}
+		if set.rType == questionType || questionType == dns.TypeAll {
+			found = true
+		} else if cname, ok := set.records[0].Data.(*dns.CNAME); ok && set.rType == dns.TypeCNAME {
+			name = cname.Target
+		}
+	}
+
+	// The target of the aliases must be proven missing as well when the zone is authoritative for it, so that its
+	// records can not be stripped from the response
+	if rcode == uint16(dns.RCodeNameError) || (!found && (len(answers) == 0 || dns.IsSubdomain(name, v.zone))) {
+		var denial Security
+		var err error
+		if cut := delegation(authority, name, v.zone); cut != "" && rcode != uint16(dns.RCodeNameError) {
+			// The target is below a zone cut, and its records are served by the child zone
+			denial, err = v.proveDelegation(client, authority, cut)
+		} else {
+			denial, err = v.deny(client, authority, name, questionType, rcode == uint16(dns.RCodeNameError))
+		}
+		if err != nil {
+			return SecurityBogus, err
+		}
+		security = weakest(security, denial)
+	}
+	v.option.logf("The response for %s is %s\n", domain, security)
+	return security, nil
+}
+
+// delegation returns the zone cut below the zone that the name is at or below, the owner of the NS records
+// of the authority section, or an empty string if there is none.
+func delegation(authority []*signedRRset, name, zone string) string {
+	for _, set := range authority {
+		if set.rType == dns.TypeNS && !dns.Name(set.name).Equal(dns.Name(zone)) && dns.IsSubdomain(set.name, zone) && dns.IsSubdomain(name, set.name) {
+			return set.name
+		}
+	}
+	return ""
+}
+
+// proveDelegation checks the zone cut of the authority section with the signed DS RRset of the child zone,
+// or with the NSEC or NSEC3 records proving that it has none, and returns its security.
+func (v *validator) proveDelegation(client *Client, authority []*signedRRset, cut string) (Security, error) {
+	if ds := findRRset(authority, cut, dns.TypeDS); ds != nil {
+		return v.verify(client, ds)
+	}
+	return v.deny(client, authority, cut, dns.TypeDS, false)
+}
+
+// synthesized reports whether the CNAME RRset is the one synthesized from a DNAME RRset of the same section.
+//
+// See https://datatracker.ietf.org/doc/html/rfc6672#section-5.3.3 for more information
+func synthesized(sets []*signedRRset, cname *signedRRset) bool {
+	if len(cname.records) != 1 {
+		return false
+	}
+	for _, set := range sets {
+		if set.rType != dns.TypeDNAME || len(set.records) != 1 {
+			continue
+		}
+		synthesized, err := dns.SynthesizeCNAME(set.records[0], cname.name)
+		if err == nil && synthesized.Data.Equal(cname.records[0].Data) {
+			return true
+		}
+	}
+	return false
+}
+
+// deny checks the signatures of the NSEC or NSEC3 records of the authority section, and returns the security
+// of the proof they give that the name does not exist if nameError is set, or that it has no records of the given type
+// otherwise. If the labels of a wildcard are given, they prove instead that the name did not exist
+// for the wildcard to be expanded.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-5.4 and https://datatracker.ietf.org/doc/html/rfc5155#section-8
+// for more information
+func (v *validator) deny(client *Client, authority []*signedRRset, name string, questionType uint16, nameError bool, wildcardLabels ...int) (Security, error) {
+	var nsecs, nsec3s []dns.ResourceRecord
+	for _, set := range authority {
+		if set.rType != dns.TypeNSEC && set.rType != dns.TypeNSEC3 && set.rType != dns.TypeSOA {
+			continue
+		}
+		if _, err := v.verify(client, set); err != nil {
+			return SecurityBogus, err
+		}
+		switch set.rType {
+		case dns.TypeNSEC:
+			nsecs = append(nsecs, set.records...)
+		case dns.TypeNSEC3:
+			nsec3s = append(nsec3s, set.records...)
+		}
+	}
+	if v.security != SecuritySecure {
+		return v.security, nil
+	}
+
+	var security Security
+	var err error
+	switch {
+	case len(nsecs) > 0:
+		security, err = SecuritySecure, proveWithNSEC(nsecs, name, questionType, nameError, wildcardLabels...)
+	case len(nsec3s) > 0:
+		security, err = proveWithNSEC3(nsec3s, name, questionType, nameError, wildcardLabels...)
+	default:
+		err = fmt.Errorf("no NSEC or NSEC3 records")
+	}
+	if err != nil {
+		return SecurityBogus, bogusf("failed to prove that %s %s does not exist: %v", dns.Name(name).FQDN(), dns.RTypeToString(questionType), err)
+	}
+	return security, nil
+}
+
+// proveWithNSEC checks that the NSEC records prove that the name does not exist, or has no records of the given type.
+// With the labels of a wildcard, it checks that the name does not exist, so that the wildcard could be expanded.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-5.4 for more information
+func proveWithNSEC(nsecs []dns.ResourceRecord, name string, questionType uint16, nameError bool, wildcardLabels ...int) error {
+	covering := findNSEC(nsecs, func(record dns.ResourceRecord) bool { return nsecCovers(record, name) })
+	if len(wildcardLabels) > 0 {
+		if covering == nil {
+			return fmt.Errorf("no NSEC record covers the name expanded from a wildcard")
+		}
+		return nil
+	}
+	if !nameError {
+		if matching := findNSEC(nsecs, func(record dns.ResourceRecord) bool { return dns.Name(record.Name).Equal(dns.Name(name)) }); matching != nil {
+			return checkNoData(matching.Data.(*dns.NSEC).TypeBitMap, questionType)
+		}
+	}
+	if covering == nil {
+		return fmt.Errorf("no NSEC record covers the name")
+	}
+
+	// The closest encloser is the longest ancestor of the name in the zone, under which the wildcard would be
+	closestEncloser := commonAncestor(name, covering.Name)
+	if next := commonAncestor(name, covering.Data.(*dns.NSEC).NextDomain); dns.CountLabels(next) > dns.CountLabels(closestEncloser) {
+		closestEncloser = next
+	}
+	wildcard := joinName("*", closestEncloser)
+	if nameError {
+		if findNSEC(nsecs, func(record dns.ResourceRecord) bool { return nsecCovers(record, wildcard) }) == nil {
+			return fmt.Errorf("no NSEC record covers the wildcard %s", dns.Name(wildcard).FQDN())
+		}
+		return nil
+	}
+	matching := findNSEC(nsecs, func(record dns.ResourceRecord) bool { return dns.Name(record.Name).Equal(dns.Name(wildcard)) })
+	if matching == nil {
+		return fmt.Errorf("no NSEC record matches the name or the wildcard %s", dns.Name(wildcard).FQDN())
+	}
+	return checkNoData(matching.Data.(*dns.NSEC).TypeBitMap, questionType)
+}
+
+// findNSEC returns the first NSEC record accepted by the function, or nil if there is none.
+func findNSEC(nsecs []dns.ResourceRecord, accept func(record dns.ResourceRecord) bool) *dns.ResourceRecord {
+	for i, record := range nsecs {
+		if _, ok := record.Data.(*dns.NSEC); ok && accept(record) {
+			return &nsecs[i]
+		}
+	}
+	return nil
+}
+
+// nsecCovers reports whether the name sorts strictly between the owner and the next domain name of the NSEC record,
+// which proves that it does not exist. The last NSEC record of a zone loops back to its apex.
+// An NSEC record of a delegation does not cover the names of the child zone.
+func nsecCovers(record dns.ResourceRecord, name string) bool {
+	nsec := record.Data.(*dns.NSEC)
+	if isDelegation(nsec.TypeBitMap) && dns.IsSubdomain(name, record.Name) {
+		return false
+	}
+	if dns.CompareNames(record.Name, nsec.NextDomain) < 0 {
+		return dns.CompareNames(record.Name, name) < 0 && dns.CompareNames(name, nsec.NextDomain) < 0
+	}
+	return dns.IsSubdomain(name, nsec.NextDomain) && (dns.CompareNames(record.Name, name) < 0 || dns.CompareNames(name, nsec.NextDomain) < 0)
+}
+
+// proveWithNSEC3 checks that the NSEC3 records prove that the name does not exist, or has no records of the given type,
+// and returns the security of the proof: insecure if it relies on an opt-out record or on too many iterations.
+// With the labels of a wildcard, it checks that the name does not exist, so that the wildcard could be expanded.
+//
+// See https://datatracker.ietf.org/doc/html/rfc5155#section-8 for more information
+func proveWithNSEC3(records []dns.ResourceRecord, name string, questionType uint16, nameError bool, wildcardLabels ...int) (Security, error) {
+	chain, err := newNSEC3Chain(records)
+	if err != nil {
+		return SecurityBogus, err
+	}
+	if chain.iterations > maxNSEC3Iterations {
+		return SecurityInsecure, nil
+	}
+
+	if len(wildcardLabels) > 0 {
+		labels := dns.SplitLabels(name)
+		nextCloser := strings.Join(labels[len(labels)-wildcardLabels[0]-1:], ".")
+		if chain.covering(nextCloser) == nil {
+			return SecurityBogus, fmt.Errorf("no NSEC3 record covers the next closer name %s", dns.Name(nextCloser).FQDN())
+		}
+		return SecuritySecure, nil
+	}
+	if !nameError {
+		if matching := chain.matching(name); matching != nil {
+			return SecuritySecure, checkNoData(matching.TypeBitMap, questionType)
+		}
+	}
+
+	closestEncloser, nextCloser, err := chain.closestEncloser(name)
+	if err != nil {
+		return SecurityBogus, err
+	}
+	if nextCloser.IsOptOut() && (nameError || questionType == dns.TypeDS) {
+		// The name may be an unsigned delegation skipped by the chain
+		return SecurityInsecure, nil
+	}
+	wildcard := joinName("*", closestEncloser)
+	if nameError {
+		if chain.covering(wildcard) == nil {
+			return SecurityBogus, fmt.Errorf("no NSEC3 record covers the wildcard %s", dns.Name(wildcard).FQDN())
+		}
+		return SecuritySecure, nil
+	}
+	matching := chain.matching(wildcard)
+	if matching == nil {
+		return SecurityBogus, fmt.Errorf("no NSEC3 record matches the name or the wildcard %s", dns.Name(wildcard).FQDN())
+	}
+	return SecuritySecure, checkNoData(matching.TypeBitMap, questionType)
+}
+
+// nsec3Chain holds the NSEC3 records of a zone, with the parameters shared by their hashes.
+type nsec3Chain struct {
+	zone       string
+	hashes     [][]byte // hashes are the hashed owner names of the records.
+	records    []*dns.NSEC3
+	iterations uint16
+	salt       []byte
+}
+
+// newNSEC3Chain creates the chain of the NSEC3 records, which must belong to the same zone and use the same parameters.
+func newNSEC3Chain(records []dns.ResourceRecord) (*nsec3Chain, error) {
+	chain := &nsec3Chain{}
+	for i, record := range records {
+		nsec3, ok := record.Data.(*dns.NSEC3)
+		if !ok {
+			continue
+		}
+		labels, zone := dns.SplitLabels(record.Name), dns.Parent(record.Name)
+		if len(labels) == 0 {
+			return nil, fmt.Errorf("invalid NSEC3 record %s", dns.Name(record.Name).FQDN())
+		}
+		hash, err := nsec3Hex.DecodeString(strings.ToUpper(labels[0]))
+		if err != nil || nsec3.HashAlgorithm != dns.NSEC3HashSHA1 {
+			return nil, fmt.Errorf("invalid NSEC3 record %s", dns.Name(record.Name).FQDN())
+		}
+		if i == 0 {
+			chain.zone, chain.iterations, chain.salt = zone, nsec3.Iterations, nsec3.Salt
+		} else if !dns.Name(zone).Equal(dns.Name(chain.zone)) || nsec3.Iterations != chain.iterations || !bytes.Equal(nsec3.Salt, chain.salt) {
+			return nil, fmt.Errorf("the NSEC3 records do not share the same zone and parameters")
+		}
+		chain.hashes = append(chain.hashes, hash)
+		chain.records = append(chain.records, nsec3)
+	}
+	return chain, nil
+}
+
+// hash returns the hashed owner name of the name in the chain, or nil if the name is not in the zone of the chain.
+func (c *nsec3Chain) hash(name string) []byte {
+	if !dns.IsSubdomain(name, c.zone) {
+		return nil
+	}
+	hash, _ := dns.NSEC3Hash(name, dns.NSEC3HashSHA1, c.iterations, c.salt)
+	return hash
+}
+
+// matching returns the NSEC3 record of the name, or nil if there is none.
+func (c *nsec3Chain) matching(name string) *dns.NSEC3 {
+	hash := c.hash(name)
+	for i, owner := range c.hashes {
+		if hash != nil && bytes.Equal(owner, hash) {
+			return c.records[i]
+		}
+	}
+	return nil
+}
+
+// covering returns the NSEC3 record whose hashed owner name and next hashed owner name surround the hash of the name,
+// which proves that it does not exist, or nil if there is none. The last record of the chain loops back to the first.
+func (c *nsec3Chain) covering(name string) *dns.NSEC3 {
+	hash := c.hash(name)
+	if hash == nil {
+		return nil
+	}
+	for i, owner := range c.hashes {
+		next := c.records[i].NextHashedOwner
+		if bytes.Compare(owner, next) < 0 {
+			if bytes.Compare(owner, hash) < 0 && bytes.Compare(hash, next) < 0 {
+				return c.records[i]
+			}
+		} else if bytes.Compare(owner, hash) < 0 || bytes.Compare(hash, next) < 0 {
+			return c.records[i]
+		}
+	}
+	return nil
+}
+
+// closestEncloser returns the closest encloser of the name, its longest ancestor matched by an NSEC3 record,
+// and the record covering the next closer name, the ancestor of the name one label longer.
+//
+// See https://datatracker.ietf.org/doc/html/rfc5155#section-8.3 for more information
+func (c *nsec3Chain) closestEncloser(name string) (string, *dns.NSEC3, error) {
+	labels := dns.SplitLabels(name)
+	for i := 1; i < len(labels) && dns.IsSubdomain(strings.Join(labels[i:], "."), c.zone); i++ {
+		closestEncloser := strings.Join(labels[i:], ".")
+		if c.matching(closestEncloser) == nil {
+			continue
+		}
+		nextCloser := strings.Join(labels[i-1:], ".")
+		covering := c.covering(nextCloser)
+		if covering == nil {
+			return "", nil, fmt.Errorf("no NSEC3 record covers the next closer name %s", dns.Name(nextCloser).FQDN())
+		}
+		return closestEncloser, covering, nil
+	}
+	return "", nil, fmt.Errorf("no NSEC3 record matches the closest encloser of %s", dns.Name(name).FQDN())
+}
+
+// checkNoData checks that the types of an NSEC or NSEC3 record matching a name prove that it has no records
+// of the given type, nor a CNAME record which would have been followed.
+// The record of a delegation only proves the absence of DS records, as the other records are in the child zone.
+func checkNoData(types []uint16, questionType uint16) error {
+	if slices.Contains(types, questionType) || slices.Contains(types, dns.TypeCNAME) {
+		return fmt.Errorf("the records exist")
+	}
+	if questionType == dns.TypeDS && slices.Contains(types, dns.TypeSOA) {
+		return fmt.Errorf("the record comes from the child zone")
+	}
+	if questionType != dns.TypeDS && isDelegation(types) {
+		return fmt.Errorf("the record comes from the parent zone")
+	}
+	return nil
+}
+
+// isDelegation reports whether the types of an NSEC or NSEC3 record are the ones of a delegation: NS without SOA.
+func isDelegation(types []uint16) bool {
+	return slices.Contains(types, dns.TypeNS) && !slices.Contains(types, dns.TypeSOA)
+}
+
+// signedRRset is an RRset of a response, along with the RRSIG records covering it.
+type signedRRset struct {
+	name    string
+	rType   uint16
+	records []dns.ResourceRecord
+	sigs    []*dns.RRSIG
+}
+
+// signedRRsets groups the records of a section by RRset, and attaches the RRSIG records covering them.
+// The RRSIG records that cover no RRset of the section are dropped.
+func signedRRsets(section []dns.ResourceRecord) []*signedRRset {
+	var sets []*signedRRset
+	find := func(name string, rType uint16) *signedRRset {
+		if set := findRRset(sets, name, rType); set != nil {
+			return set
+		}
+		set := &signedRRset{name: relativeName(name), rType: rType}
+		sets = append(sets, set)
+		return set
+	}
+	for _, record := range section {
+		switch data := record.Data.(type) {
+		case *dns.OPT:
+		case *dns.RRSIG:
+			set := find(record.Name, data.TypeCovered)
+			set.sigs = append(set.sigs, data)
+		default:
+			if record.Type != dns.TypeRRSIG {
+				set := find(record.Name, record.Type)
+				set.records = append(set.records, record)
+			}
+		}
+	}
+	return slices.DeleteFunc(sets, func(set *signedRRset) bool { return len(set.records) == 0 })
+}
+
+// findRRset returns the RRset of the given name and type, or nil if there is none.
+func findRRset(sets []*signedRRset, name string, rType uint16) *signedRRset {
+	for _, set := range sets {
+		if set.rType == rType && dns.Name(set.name).Equal(dns.Name(name)) {
+			return set
+		}
+	}
+	return nil
+}
+
+// signer returns the name of the zone that signed the RRset, or an empty string if it is not signed.
+func (s *signedRRset) signer() string {
+	if len(s.sigs) == 0 {
+		return ""
+	}
+	return relativeName(s.sigs[0].SignerName)
+}
+
+// expanded reports whether the RRset was synthesized from a wildcard, in which case its signatures
+// have fewer labels than its owner name.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-5.3.4 for more information
+func (s *signedRRset) expanded() bool {
+	return len(s.sigs) > 0 && s.wildcardLabels() < dns.CountLabels(s.name)
+}
+
+// wildcardLabels returns the number of labels of the wildcard the RRset was expanded from, without the * label.
+func (s *signedRRset) wildcardLabels() int {
+	return int(s.sigs[0].Labels)
+}
+
+// verifyRRset checks that a signature of the RRset by the signer is valid now, and made by one of the keys.
+//
+// See https://datatracker.ietf.org/doc/html/rfc4035#section-5.3 for more information
+func verifyRRset(set *signedRRset, signer string, keys []dns.ResourceRecord, now time.Time) error {
+	err := bogusf("the %s RRset of %s has no signature by %s", dns.RTypeToString(set.rType), dns.Name(set.name).FQDN(), dns.Name(signer).FQDN())
+	for _, sig := range set.sigs {
+		if sig.TypeCovered != set.rType || !dns.Name(sig.SignerName).Equal(dns.Name(signer)) || !dns.IsSupportedAlgorithm(sig.Algorithm) {
+			continue
+		}
+		if !sig.ValidAt(now) {
+			err = bogusf("the signature of the %s RRset of %s is expired or not yet valid", dns.RTypeToString(set.rType), dns.Name(set.name).FQDN())
+			continue
+		}
+		for _, record := range keys {
+			key, ok := record.Data.(*dns.DNSKEY)
+			if !ok || key.Flags&dns.DNSKEYFlagZone == 0 || key.Flags&dns.DNSKEYFlagRevoke != 0 || key.Protocol != dns.DNSKEYProtocol ||
+				key.Algorithm != sig.Algorithm || key.KeyTag() != sig.KeyTag {
+				continue
+			}
+			if verifyErr := sig.Verify(key, set.records); verifyErr != nil {
+				err = bogusf("%v", verifyErr)
+				continue
+			}
+			return nil
+		}
+	}
+	return err
+}
+
+// weakest returns the weakest of the two security statuses: bogus, then indeterminate, insecure and secure.
+func weakest(a, b Security) Security {
+	if a == SecurityBogus || b == SecurityBogus {
+		return SecurityBogus
+	}
+	return min(a, b)
+}
+
+// isSupportedDigest reports whether the digest type of a DS record can be computed.
+func isSupportedDigest(digestType uint8) bool {
+	return digestType == dns.DigestSHA1 || digestType == dns.DigestSHA256 || digestType == dns.DigestSHA384
+}
+
+// relativeName returns the domain name without its trailing dot. An escaped dot ending the last label is kept.
+func relativeName(name string) string {
+	fqdn := dns.Name(name).FQDN()
+	return string(fqdn[:len(fqdn)-1])
+}
+
+// joinName returns the domain name made of the label followed by the parent domain name.
+func joinName(label, parent string) string {
+	if parent = relativeName(parent); parent == "" {
+		return label
+	}
+	return label + "." + parent
+}
+
+// commonAncestor returns the longest domain name that is an ancestor of both names, or equal to them.
+func commonAncestor(a, b string) string {
+	labelsA, labelsB := dns.SplitLabels(a), dns.SplitLabels(b)
+	common := 0
+	for common < len(labelsA) && common < len(labelsB) &&
+		strings.EqualFold(labelsA[len(labelsA)-1-common], labelsB[len(labelsB)-1-common]) {
+		common++
+	}
+	return strings.Join(labelsA[len(labelsA)-common:], ".")
+}
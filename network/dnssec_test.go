@@ -0,0 +1,656 @@
+package network
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"dns-resolver-go/dns"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSSEC(t *testing.T) {
+	// The zones are served on 127.0.0.1 to 127.0.0.5: the root zone, signed with NSEC, delegates to example,
+	// signed with NSEC, which delegates to the unsigned zone unsigned.example and to hashed.example, signed
+	// with NSEC3 with opt-out, which delegates to the unsigned zone legacy.hashed.example.
+	legacy := newTestZone(t, "legacy.hashed.example", 0, nil, "www A 192.0.2.7")
+	hashed := newTestZone(t, "hashed.example", dns.AlgorithmRSASHA256, &dns.NSEC3PARAM{HashAlgorithm: dns.NSEC3HashSHA1, Flags: dns.NSEC3FlagOptOut, Iterations: 2, Salt: []byte{0xAA, 0xBB}},
+		"www A 192.0.2.6", "legacy NS ns.legacy", "ns.legacy A 127.0.0.5")
+	unsigned := newTestZone(t, "unsigned.example", 0, nil, "www A 192.0.2.5")
+	example := newTestZone(t, "example", dns.AlgorithmECDSAP256SHA256, nil,
+		"www A 192.0.2.1", "alias CNAME www", "wild TXT \"wildcards below\"", "*.wild A 192.0.2.2", "tampered A 192.0.2.3", "redirect DNAME hashed",
+		"unsigned NS ns.unsigned", "ns.unsigned A 127.0.0.3", "hashed NS ns.hashed", "ns.hashed A 127.0.0.4")
+	root := newTestZone(t, "", dns.AlgorithmED25519, nil, "example. NS ns.example.", "ns.example. A 127.0.0.2")
+
+	hashed.sign(t, legacy)
+	example.sign(t, unsigned, hashed)
+	root.sign(t, example)
+	// The signature of the record is not valid anymore once its address is changed
+	for i, record := range example.records {
+		if record.Name == "tampered.example" && record.Type == dns.TypeA {
+			example.records[i] = *dns.NewResourceRecord(record.Name, dns.TypeA, dns.ClassIN, record.TTL, 4, []byte{192, 0, 2, 4})
+		}
+	}
+
+	option := Option{DNSSEC: true, Quiet: true, TrustAnchors: []dns.ResourceRecord{root.ds(t)}}
+	option.Root = startZoneServers(t, root, example, unsigned, hashed, legacy)
+
+	t.Run("Should validate a secure answer", func(t *testing.T) {
+		response, security, err := LookupValidated("www.example", dns.TypeA, option)
+		assert.NoError(t, err)
+		assert.Equal(t, SecuritySecure, security)
+		assert.True(t, dns.HeaderFlagFromUint16(response.Header.Flags).AD)
+		assert.Equal(t, "192.0.2.1", getRecord(response.Answers))
+
+		response, security, err = LookupValidated("alias.example", dns.TypeA, option)
+		assert.NoError(t, err)
+		assert.Equal(t, SecuritySecure, security)
+		assert.Equal(t, 4, len(response.Answers), "the CNAME and A RRsets with their signatures")
+	})
+
+	t.Run("Should validate the answers expanded from a wildcard", func(t *testing.T) {
+		response, security, err := LookupValidated("host.wild.example", dns.TypeA, option)
+		assert.NoError(t, err)
+		assert.Equal(t, SecuritySecure, security)
+		assert.Equal(t, "192.0.2.2", getRecord(response.Answers))
+	})
+
+	t.Run("Should validate the CNAME records synthesized from DNAME records", func(t *testing.T) {
+		response, security, err := LookupValidated("www.redirect.example", dns.TypeA, option)
+		assert.NoError(t, err)
+		assert.Equal(t, SecuritySecure, security)
+		if assert.Equal(t, 3, len(response.Answers), "the DNAME record, its signature and the synthesized CNAME record") {
+			assert.Equal(t, "www.hashed.example", response.Answers[2].RDataParsed)
+		}
+
+		example.setModifier(func(response *dns.DNSMessage) {
+			for i, answer := range response.Answers {
+				if answer.Type == dns.TypeCNAME {
+					cname, _ := dns.NewResourceRecordWithData(answer.Name, answer.Class, answer.TTL, &dns.CNAME{Target: "www.unsigned.example"})
+					response.Answers[i] = *cname
+				}
+			}
+		})
+		defer example.setModifier(nil)
+		_, security, err = LookupValidated("www.redirect.example", dns.TypeA, option)
+		assert.ErrorIs(t, err, ErrBogus, "the CNAME record does not match the DNAME record")
+		assert.Equal(t, SecurityBogus, security)
+	})
+
+	t.Run("Should prove the negative responses with NSEC and NSEC3", func(t *testing.T) {
+		for _, query := range []struct {
+			name     string
+			rType    uint16
+			rcode    uint8
+			security Security
+		}{
+			{"missing.example", dns.TypeA, dns.RCodeNameError, SecuritySecure},
+			{"www.example", dns.TypeAAAA, dns.RCodeNoError, SecuritySecure},
+			{"a.b.missing.example", dns.TypeA, dns.RCodeNameError, SecuritySecure},
+			{"www.hashed.example", dns.TypeTXT, dns.RCodeNoError, SecuritySecure},
+			// The opt-out record covering the name may hide an unsigned delegation
+			{"missing.hashed.example", dns.TypeA, dns.RCodeNameError, SecurityInsecure},
+		} {
+			response, security, err := LookupValidated(query.name, query.rType, option)
+			assert.NoError(t, err, query.name)
+			assert.Equal(t, query.security, security, query.name)
+			assert.Equal(t, uint16(query.rcode), response.RCode(), query.name)
+			assert.Empty(t, response.Answers, query.name)
+		}
+	})
+
+	t.Run("Should validate the keys of a zone signed with NSEC3", func(t *testing.T) {
+		response, security, err := LookupValidated("www.hashed.example", dns.TypeA, option)
+		assert.NoError(t, err)
+		assert.Equal(t, SecuritySecure, security)
+		assert.Equal(t, "192.0.2.6", getRecord(response.Answers))
+	})
+
+	t.Run("Should report the zones below a delegation without DS records as insecure", func(t *testing.T) {
+		for name, address := range map[string]string{"www.unsigned.example": "192.0.2.5", "www.legacy.hashed.example": "192.0.2.7"} {
+			response, security, err := LookupValidated(name, dns.TypeA, option)
+			assert.NoError(t, err, name)
+			assert.Equal(t, SecurityInsecure, security, name)
+			assert.False(t, dns.HeaderFlagFromUint16(response.Header.Flags).AD, name)
+			assert.Equal(t, address, getRecord(response.Answers), name)
+		}
+	})
+
+	t.Run("Should answer SERVFAIL for bogus records", func(t *testing.T) {
+		response, security, err := LookupValidated("tampered.example", dns.TypeA, option)
+		assert.ErrorIs(t, err, ErrBogus)
+		assert.Equal(t, SecurityBogus, security)
+		assert.Equal(t, uint16(dns.RCodeServerFailure), response.RCode())
+		assert.Empty(t, response.Answers)
+
+		response, err = LookupMessage("tampered.example", dns.TypeA, option)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(dns.RCodeServerFailure), response.RCode())
+
+		noCache := option
+		noCache.UseCache = false
+		_, err = Lookup("tampered.example", dns.TypeA, noCache)
+		assert.ErrorIs(t, err, ErrBogus)
+	})
+
+	t.Run("Should report unproven negative responses as bogus", func(t *testing.T) {
+		example.setModifier(func(response *dns.DNSMessage) {
+			response.AuthorityRRs = slices.DeleteFunc(response.AuthorityRRs, func(record dns.ResourceRecord) bool {
+				return record.Type == dns.TypeNSEC
+			})
+		})
+		defer example.setModifier(nil)
+
+		_, security, err := LookupValidated("missing.example", dns.TypeA, option)
+		assert.ErrorIs(t, err, ErrBogus)
+		assert.Equal(t, SecurityBogus, security)
+		_, _, err = LookupValidated("www.unsigned.example", dns.TypeA, option)
+		assert.ErrorIs(t, err, ErrBogus, "the delegation is not proven unsigned")
+	})
+
+	t.Run("Should report the aliases whose target records were stripped as bogus", func(t *testing.T) {
+		example.setModifier(func(response *dns.DNSMessage) {
+			response.Answers = slices.DeleteFunc(response.Answers, func(record dns.ResourceRecord) bool {
+				return record.Type == dns.TypeA || (record.Type == dns.TypeRRSIG && record.Data.(*dns.RRSIG).TypeCovered == dns.TypeA)
+			})
+			response.Header.ANCount = uint16(len(response.Answers))
+		})
+		defer example.setModifier(nil)
+
+		_, security, err := LookupValidated("alias.example", dns.TypeA, option)
+		assert.ErrorIs(t, err, ErrBogus, "the target of the CNAME record is not proven to have no A records")
+		assert.Equal(t, SecurityBogus, security)
+	})
+
+	t.Run("Should report records signed by keys the trust anchors do not match as bogus", func(t *testing.T) {
+		wrongAnchor := option
+		wrongAnchor.TrustAnchors = []dns.ResourceRecord{example.ds(t)}
+		wrongAnchor.TrustAnchors[0].Name = ""
+		_, security, err := LookupValidated("www.example", dns.TypeA, wrongAnchor)
+		assert.ErrorIs(t, err, ErrBogus)
+		assert.Equal(t, SecurityBogus, security)
+	})
+
+	t.Run("Should report the names without trust anchor as indeterminate", func(t *testing.T) {
+		deepAnchor := option
+		deepAnchor.TrustAnchors = []dns.ResourceRecord{hashed.ds(t)}
+		response, security, err := LookupValidated("www.example", dns.TypeA, deepAnchor)
+		assert.NoError(t, err)
+		assert.Equal(t, SecurityIndeterminate, security)
+		assert.Equal(t, "192.0.2.1", getRecord(response.Answers))
+
+		_, security, err = LookupValidated("tampered.example", dns.TypeA, deepAnchor)
+		assert.NoError(t, err)
+		assert.Equal(t, SecurityIndeterminate, security)
+
+		_, security, err = LookupValidated("www.hashed.example", dns.TypeA, deepAnchor)
+		assert.NoError(t, err)
+		assert.Equal(t, SecuritySecure, security, "the zone has its own trust anchor")
+	})
+
+	t.Run("Should tell the names ending with an escaped dot from the fully qualified names", func(t *testing.T) {
+		sets := signedRRsets([]dns.ResourceRecord{
+			*dns.NewResourceRecord(`www.example\.`, dns.TypeA, dns.ClassIN, 300, 4, []byte{192, 0, 2, 1}),
+			*dns.NewResourceRecord("www.example", dns.TypeA, dns.ClassIN, 300, 4, []byte{192, 0, 2, 2}),
+		})
+		if assert.Equal(t, 2, len(sets)) {
+			assert.Equal(t, `www.example\.`, sets[0].name)
+			assert.Equal(t, sets[1], findRRset(sets, "WWW.example.", dns.TypeA))
+		}
+		assert.Equal(t, `*.example\.`, joinName("*", `example\.`))
+	})
+
+	t.Run("Should ask for the DNSSEC records with the DO bit", func(t *testing.T) {
+		assert.True(t, newQuery("www.example", dns.TypeA, Option{DNSSEC: true}).EDNS().DO)
+		assert.False(t, newQuery("www.example", dns.TypeA, DefaultOption()).EDNS().DO)
+	})
+}
+
+// testZone is a zone served by a test authoritative server, signed with DNSSEC unless its algorithm is zero.
+type testZone struct {
+	name      string
+	algorithm uint8
+	key       crypto.Signer
+	nsec3     *dns.NSEC3PARAM // nsec3 holds the parameters of the NSEC3 chain, the zone is signed with NSEC if nil.
+	records   []dns.ResourceRecord
+
+	mu       sync.Mutex
+	modifier func(response *dns.DNSMessage) // modifier changes the responses of the server if set.
+}
+
+// newTestZone creates a zone from the records in presentation format, relative to the zone.
+// It holds an SOA and an NS record at its apex, and a DNSKEY record unless the algorithm is zero.
+func newTestZone(t *testing.T, name string, algorithm uint8, nsec3 *dns.NSEC3PARAM, records ...string) *testZone {
+	origin := dns.Name(name).FQDN().String()
+	server, admin := dns.Name(joinName("ns", name)).FQDN(), dns.Name(joinName("admin", name)).FQDN()
+	text := fmt.Sprintf("$TTL 300\n@ SOA %s %s 1 3600 600 86400 300\n@ NS %s\n%s\n", server, admin, server, strings.Join(records, "\n"))
+	parsed, err := dns.ParseZone(strings.NewReader(text), origin, "")
+	if err != nil {
+		t.Fatalf("failed to parse the zone %s: %v", origin, err)
+	}
+	zone := &testZone{name: name, algorithm: algorithm, nsec3: nsec3, records: parsed}
+	switch algorithm {
+	case 0:
+		return zone
+	case dns.AlgorithmRSASHA256:
+		zone.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case dns.AlgorithmECDSAP256SHA256:
+		zone.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case dns.AlgorithmED25519:
+		_, zone.key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatalf("failed to generate the key of %s: %v", origin, err)
+	}
+	dnskey, err := dns.NewDNSKEY(dns.DNSKEYFlagZone|dns.DNSKEYFlagSEP, algorithm, zone.key.Public())
+	if err != nil {
+		t.Fatalf("failed to create the DNSKEY of %s: %v", origin, err)
+	}
+	zone.add(t, name, dnskey)
+	if nsec3 != nil {
+		zone.add(t, name, nsec3)
+	}
+	return zone
+}
+
+// add adds a record with the given owner name and resource data to the zone.
+func (z *testZone) add(t *testing.T, owner string, data dns.RData) {
+	record, err := dns.NewResourceRecordWithData(owner, dns.ClassIN, 300, data)
+	if err != nil {
+		t.Fatalf("failed to create the %s record of %s: %v", dns.RTypeToString(data.Type()), owner, err)
+	}
+	z.records = append(z.records, *record)
+}
+
+// dnskey returns the DNSKEY of the zone.
+func (z *testZone) dnskey() *dns.DNSKEY {
+	return z.rrset(z.name, dns.TypeDNSKEY)[0].Data.(*dns.DNSKEY)
+}
+
+// ds returns the DS record of the key of the zone, to be added to its parent zone or used as trust anchor.
+func (z *testZone) ds(t *testing.T) dns.ResourceRecord {
+	ds, err := z.dnskey().ToDS(z.name, dns.DigestSHA256)
+	if err != nil {
+		t.Fatalf("failed to compute the DS record of %s: %v", dns.Name(z.name).FQDN(), err)
+	}
+	record, _ := dns.NewResourceRecordWithData(z.name, dns.ClassIN, 300, ds)
+	return *record
+}
+
+// setModifier sets the function changing the responses of the server, or removes it if nil.
+func (z *testZone) setModifier(modifier func(response *dns.DNSMessage)) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.modifier = modifier
+}
+
+// sign adds the DS records of the signed child zones, then signs the zone with its NSEC or NSEC3 chain.
+// Unsigned zones are left as is. The child zones must be signed before their parent.
+func (z *testZone) sign(t *testing.T, children ...*testZone) {
+	for _, child := range children {
+		if child.algorithm != 0 {
+			z.records = append(z.records, child.ds(t))
+		}
+	}
+	if z.algorithm == 0 {
+		return
+	}
+
+	// The authoritative names of the zone, in canonical order, and their types
+	types := make(map[string][]uint16)
+	var names []string
+	for _, record := range z.records {
+		name := strings.ToLower(record.Name)
+		if z.cut(name) != "" && !z.isCut(name) {
+			continue // glue
+		}
+		if _, ok := types[name]; !ok {
+			names = append(names, name)
+		}
+		types[name] = append(types[name], record.Type)
+	}
+	slices.SortFunc(names, dns.CompareNames)
+
+	if z.nsec3 == nil {
+		for i, name := range names {
+			bitMap := append(slices.Clone(types[name]), dns.TypeNSEC, dns.TypeRRSIG)
+			z.add(t, name, &dns.NSEC{NextDomain: names[(i+1)%len(names)], TypeBitMap: bitMap})
+		}
+	} else {
+		type hashedName struct {
+			hash  []byte
+			types []uint16
+		}
+		var hashed []hashedName
+		for _, name := range names {
+			bitMap := slices.Clone(types[name])
+			if z.isCut(name) && !slices.Contains(bitMap, dns.TypeDS) {
+				if z.nsec3.Flags&dns.NSEC3FlagOptOut != 0 {
+					continue // unsigned delegations are skipped with opt-out
+				}
+			} else {
+				bitMap = append(bitMap, dns.TypeRRSIG)
+			}
+			hash, _ := dns.NSEC3Hash(name, z.nsec3.HashAlgorithm, z.nsec3.Iterations, z.nsec3.Salt)
+			hashed = append(hashed, hashedName{hash, bitMap})
+		}
+		slices.SortFunc(hashed, func(a, b hashedName) int { return bytes.Compare(a.hash, b.hash) })
+		for i, h := range hashed {
+			z.add(t, joinName(strings.ToLower(nsec3Hex.EncodeToString(h.hash)), z.name), &dns.NSEC3{
+				HashAlgorithm: z.nsec3.HashAlgorithm, Flags: z.nsec3.Flags, Iterations: z.nsec3.Iterations, Salt: z.nsec3.Salt,
+				NextHashedOwner: hashed[(i+1)%len(hashed)].hash, TypeBitMap: h.types,
+			})
+		}
+	}
+
+	// Every authoritative RRset is signed, except the NS RRsets of the delegations
+	type rrsetKey struct {
+		name  string
+		rType uint16
+	}
+	signed := make(map[rrsetKey]bool)
+	now := time.Now()
+	for _, record := range slices.Clone(z.records) {
+		key := rrsetKey{strings.ToLower(record.Name), record.Type}
+		if signed[key] || (z.cut(key.name) != "" && !(z.isCut(key.name) && (key.rType == dns.TypeDS || key.rType == dns.TypeNSEC))) {
+			continue
+		}
+		signed[key] = true
+		rrsig := &dns.RRSIG{Algorithm: z.algorithm, Inception: uint32(now.Add(-time.Hour).Unix()), Expiration: uint32(now.Add(time.Hour).Unix()),
+			KeyTag: z.dnskey().KeyTag(), SignerName: z.name}
+		if err := rrsig.Sign(z.key, z.rrset(record.Name, record.Type)); err != nil {
+			t.Fatalf("failed to sign the %s RRset of %s: %v", dns.RTypeToString(record.Type), dns.Name(record.Name).FQDN(), err)
+		}
+		z.add(t, record.Name, rrsig)
+	}
+}
+
+// cut returns the delegation of the zone the name is at or below, or an empty string if the zone is authoritative for it.
+func (z *testZone) cut(name string) string {
+	for _, record := range z.records {
+		if record.Type == dns.TypeNS && !dns.Name(record.Name).Equal(dns.Name(z.name)) && dns.IsSubdomain(name, record.Name) {
+			return strings.TrimSuffix(record.Name, ".")
+		}
+	}
+	return ""
+}
+
+// isCut reports whether the name is a delegation of the zone.
+func (z *testZone) isCut(name string) bool {
+	return dns.Name(z.cut(name)).Equal(dns.Name(name)) && z.cut(name) != ""
+}
+
+// redirection returns the DNAME record of the zone owned by an ancestor of the name, or nil if there is none.
+func (z *testZone) redirection(name string) *dns.ResourceRecord {
+	for i, record := range z.records {
+		if record.Type == dns.TypeDNAME && dns.IsSubdomain(name, record.Name) && !dns.Name(name).Equal(dns.Name(record.Name)) {
+			return &z.records[i]
+		}
+	}
+	return nil
+}
+
+// exists reports whether the zone has records at the name.
+func (z *testZone) exists(name string) bool {
+	return slices.ContainsFunc(z.records, func(record dns.ResourceRecord) bool { return dns.Name(record.Name).Equal(dns.Name(name)) })
+}
+
+// signedRRset returns the records of the zone with the given owner name and type, followed by their signatures.
+func (z *testZone) signedRRset(name string, rType uint16) []dns.ResourceRecord {
+	records := z.rrset(name, rType)
+	if len(records) == 0 {
+		return nil
+	}
+	for _, record := range z.rrset(name, dns.TypeRRSIG) {
+		if record.Data.(*dns.RRSIG).TypeCovered == rType {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// respond returns the response of the authoritative server of the zone to the query: a referral, an answer,
+// possibly expanded from a wildcard, or a negative response with the NSEC or NSEC3 records that prove it.
+func (z *testZone) respond(query *dns.DNSMessage) *dns.DNSMessage {
+	question := query.Questions[0]
+	name, rType := strings.ToLower(strings.TrimSuffix(question.Name, ".")), question.QType
+	rcode := dns.RCodeNoError
+	authoritative := true
+	var answers, authority, additional []dns.ResourceRecord
+
+	cut := z.cut(name)
+	switch {
+	case !dns.IsSubdomain(name, z.name):
+		rcode, authoritative = dns.RCodeRefused, false
+	case cut != "" && !(rType == dns.TypeDS && dns.Name(name).Equal(dns.Name(cut))):
+		authoritative = false
+		authority, additional = z.referral(cut)
+	case z.redirection(name) != nil:
+		dname := z.redirection(name)
+		cname, _ := dns.SynthesizeCNAME(*dname, name)
+		target := cname.Data.(*dns.CNAME).Target
+		answers = append(z.signedRRset(dname.Name, dns.TypeDNAME), *cname)
+		answers = append(answers, z.signedRRset(target, rType)...)
+		if cut := z.cut(target); cut != "" {
+			authority, additional = z.referral(cut)
+		}
+	case z.exists(name):
+		answers = z.signedRRset(name, rType)
+		if cname := z.signedRRset(name, dns.TypeCNAME); answers == nil && cname != nil {
+			answers = append(cname, z.signedRRset(cname[0].Data.(*dns.CNAME).Target, rType)...)
+		}
+		if answers == nil {
+			authority = append(z.signedRRset(z.name, dns.TypeSOA), z.denial(name, false)...)
+		}
+	default:
+		closestEncloser := name
+		for !z.exists(closestEncloser) {
+			_, closestEncloser, _ = strings.Cut(closestEncloser, ".")
+		}
+		wildcard := joinName("*", closestEncloser)
+		if expanded := z.signedRRset(wildcard, rType); expanded != nil {
+			for _, record := range expanded {
+				record.Name = name
+				answers = append(answers, record)
+			}
+			authority = z.denial(name, true)
+		} else {
+			rcode = dns.RCodeNameError
+			authority = append(z.signedRRset(z.name, dns.TypeSOA), z.denial(name, true)...)
+		}
+	}
+
+	flag := dns.NewHeaderFlag(true, 0, authoritative, false, false, false, 0, rcode).GenerateFlag()
+	header := dns.NewHeader(query.Header.ID, flag, 1, uint16(len(answers)), uint16(len(authority)), uint16(len(additional)))
+	response := dns.NewDNSMessage(*header, query.Questions, answers, authority, additional)
+	if edns := query.EDNS(); edns != nil {
+		response.SetEDNS(dns.NewEDNS(edns.UDPSize))
+	}
+	return response
+}
+
+// referral returns the authority and additional sections of the referral to the zone cut: its NS records,
+// its DS records or the proof that it has none, and the addresses of its servers.
+func (z *testZone) referral(cut string) ([]dns.ResourceRecord, []dns.ResourceRecord) {
+	authority := z.rrset(cut, dns.TypeNS)
+	if ds := z.signedRRset(cut, dns.TypeDS); ds != nil {
+		authority = append(authority, ds...)
+	} else {
+		authority = append(authority, z.denial(cut, false)...)
+	}
+	var additional []dns.ResourceRecord
+	for _, ns := range z.rrset(cut, dns.TypeNS) {
+		additional = append(additional, z.rrset(ns.Data.(*dns.NS).Host, dns.TypeA)...)
+	}
+	return authority, additional
+}
+
+// denial returns the NSEC or NSEC3 records, with their signatures, that prove that the name does not exist
+// if nameError is set, or that it has none of the records asked for otherwise.
+func (z *testZone) denial(name string, nameError bool) []dns.ResourceRecord {
+	if z.algorithm == 0 {
+		return nil
+	}
+	var records []dns.ResourceRecord
+	add := func(owner string, rType uint16) {
+		for _, record := range z.signedRRset(owner, rType) {
+			if !slices.ContainsFunc(records, func(r dns.ResourceRecord) bool { return r.Name == record.Name && r.RDataParsed == record.RDataParsed }) {
+				records = append(records, record)
+			}
+		}
+	}
+
+	closestEncloser := name
+	for !z.exists(closestEncloser) {
+		_, closestEncloser, _ = strings.Cut(closestEncloser, ".")
+	}
+	if z.nsec3 == nil {
+		if !nameError {
+			add(name, dns.TypeNSEC)
+			return records
+		}
+		add(z.coveringNSEC(name), dns.TypeNSEC)
+		add(z.coveringNSEC(joinName("*", closestEncloser)), dns.TypeNSEC)
+		return records
+	}
+
+	if owner := z.nsec3Owner(name, true); !nameError && owner != "" {
+		add(owner, dns.TypeNSEC3)
+		return records
+	}
+	// The closest encloser proof, with the record covering the wildcard for a name error
+	if dns.Name(closestEncloser).Equal(dns.Name(name)) {
+		_, closestEncloser, _ = strings.Cut(closestEncloser, ".")
+	}
+	labels := strings.Split(name, ".")
+	nextCloser := strings.Join(labels[len(labels)-dns.CountLabels(closestEncloser)-1:], ".")
+	add(z.nsec3Owner(closestEncloser, true), dns.TypeNSEC3)
+	add(z.nsec3Owner(nextCloser, false), dns.TypeNSEC3)
+	if nameError {
+		add(z.nsec3Owner(joinName("*", closestEncloser), false), dns.TypeNSEC3)
+	}
+	return records
+}
+
+// coveringNSEC returns the owner of the NSEC record covering the name, the last one sorting before it.
+func (z *testZone) coveringNSEC(name string) string {
+	var owners []string
+	for _, record := range z.records {
+		if record.Type == dns.TypeNSEC {
+			owners = append(owners, record.Name)
+		}
+	}
+	slices.SortFunc(owners, dns.CompareNames)
+	covering := owners[len(owners)-1]
+	for _, owner := range owners {
+		if dns.CompareNames(owner, name) < 0 {
+			covering = owner
+		}
+	}
+	return covering
+}
+
+// nsec3Owner returns the owner of the NSEC3 record matching the hash of the name if matching is set,
+// or covering it otherwise. It returns an empty string if no record matches.
+func (z *testZone) nsec3Owner(name string, matching bool) string {
+	hash, _ := dns.NSEC3Hash(name, z.nsec3.HashAlgorithm, z.nsec3.Iterations, z.nsec3.Salt)
+	var owners []string
+	for _, record := range z.records {
+		if record.Type == dns.TypeNSEC3 {
+			owners = append(owners, record.Name)
+		}
+	}
+	slices.SortFunc(owners, strings.Compare)
+	covering := owners[len(owners)-1]
+	for _, owner := range owners {
+		ownerHash, _ := nsec3Hex.DecodeString(strings.ToUpper(strings.SplitN(owner, ".", 2)[0]))
+		if c := bytes.Compare(ownerHash, hash); c == 0 && matching {
+			return owner
+		} else if c < 0 {
+			covering = owner
+		}
+	}
+	if matching {
+		return ""
+	}
+	return covering
+}
+
+// rrset returns the records of the zone with the given owner name and type.
+func (z *testZone) rrset(name string, rType uint16) []dns.ResourceRecord {
+	var records []dns.ResourceRecord
+	for _, record := range z.records {
+		if record.Type == rType && dns.Name(record.Name).Equal(dns.Name(name)) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// startZoneServers starts the authoritative servers of the zones on 127.0.0.1, 127.0.0.2 and so on, on the same port.
+// It returns the address of the server of the first zone.
+func startZoneServers(t *testing.T, zones ...*testZone) netip.AddrPort {
+	for attempt := 0; attempt < 10; attempt++ {
+		var conns []net.PacketConn
+		for i := range zones {
+			address := fmt.Sprintf("127.0.0.%d:0", i+1)
+			if i > 0 {
+				address = fmt.Sprintf("127.0.0.%d:%d", i+1, conns[0].LocalAddr().(*net.UDPAddr).Port)
+			}
+			conn, err := net.ListenPacket("udp", address)
+			if err != nil {
+				break
+			}
+			conns = append(conns, conn)
+		}
+		if len(conns) < len(zones) {
+			for _, conn := range conns {
+				conn.Close()
+			}
+			continue
+		}
+		for i, conn := range conns {
+			t.Cleanup(func() { conn.Close() })
+			go serveZone(conn, zones[i])
+		}
+		return conns[0].LocalAddr().(*net.UDPAddr).AddrPort()
+	}
+	t.Fatalf("failed to start the servers of the zones")
+	return netip.AddrPort{}
+}
+
+// serveZone answers the queries received on the connection with the records of the zone, until it is closed.
+func serveZone(conn net.PacketConn, zone *testZone) {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		query, err := dns.ParseMessage(buf[:n])
+		if err != nil || len(query.Questions) != 1 {
+			continue
+		}
+		response := zone.respond(query)
+		zone.mu.Lock()
+		if zone.modifier != nil {
+			zone.modifier(response)
+			response.Header.ANCount = uint16(len(response.Answers))
+			response.Header.NSCount = uint16(len(response.AuthorityRRs))
+		}
+		zone.mu.Unlock()
+		conn.WriteTo(response.ToBytes(), addr)
+	}
+}
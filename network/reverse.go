@@ -0,0 +1,38 @@
+package network
+
+import (
+	"dns-resolver-go/dns"
+	"fmt"
+	"net"
+)
+
+// LookupAddr performs a reverse lookup for the given IP address and returns the names mapped to it.
+// The PTR records are looked up under the in-addr.arpa or ip6.arpa name of the address, following the
+// CNAME records used by RFC 2317 classless delegation.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2317 for more information
+func LookupAddr(addr string, options ...Option) ([]string, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", addr)
+	}
+	reverseName, err := dns.ReverseName(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := Lookup(reverseName, dns.TypePTR, options...)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, record := range recordsOfType(records, dns.TypePTR) {
+		if ptr, ok := record.Data.(*dns.PTR); ok {
+			names = append(names, ptr.Target)
+		} else {
+			// Cached records only hold the parsed resource data
+			names = append(names, record.RDataParsed)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,69 @@
+package network
+
+import (
+	"dns-resolver-go/dns"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverse(t *testing.T) {
+	record := func(owner string, data dns.RData) dns.ResourceRecord {
+		rr, err := dns.NewResourceRecordWithData(owner, dns.ClassIN, 300, data)
+		if err != nil {
+			t.Fatalf("failed to create the record: %v", err)
+		}
+		return *rr
+	}
+
+	// The server of 2.0.192.in-addr.arpa delegates 192.0.2.64/26 with the CNAME records of RFC 2317:
+	// 70.2.0.192.in-addr.arpa is an alias of 70.64/26.2.0.192.in-addr.arpa, which holds the PTR records
+	port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+		name := strings.ToLower(strings.TrimSuffix(query.Questions[0].Name, "."))
+		response := *query
+		response.Header.Flags = dns.NewHeaderFlag(true, 0, true, false, false, false, 0, 0).GenerateFlag()
+		switch name {
+		case "70.2.0.192.in-addr.arpa":
+			// Only the alias is sent, its target is looked up by the resolver
+			response.Answers = []dns.ResourceRecord{record(name, &dns.CNAME{Target: "70.64/26.2.0.192.in-addr.arpa"})}
+		case "70.64/26.2.0.192.in-addr.arpa":
+			response.Answers = []dns.ResourceRecord{
+				record(name, &dns.PTR{Target: "host.example.com"}),
+				record(name, &dns.PTR{Target: "www.example.com"}),
+			}
+		case "71.2.0.192.in-addr.arpa":
+			// The alias is sent along with the records of its target
+			response.Answers = []dns.ResourceRecord{
+				record(name, &dns.CNAME{Target: "71.64/26.2.0.192.in-addr.arpa"}),
+				record("71.64/26.2.0.192.in-addr.arpa", &dns.PTR{Target: "mail.example.com"}),
+			}
+		default:
+			response.Header.Flags = dns.NewHeaderFlag(true, 0, true, false, false, false, 0, dns.RCodeNameError).GenerateFlag()
+		}
+		response.Header.ANCount = uint16(len(response.Answers))
+		return &response
+	})
+	option := Option{Quiet: true, Root: netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), uint16(port))}
+
+	t.Run("Should follow the CNAME records of the classless delegations to the PTR records", func(t *testing.T) {
+		names, err := LookupAddr("192.0.2.70", option)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"host.example.com", "www.example.com"}, names)
+
+		names, err = LookupAddr("192.0.2.71", option)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"mail.example.com"}, names)
+	})
+
+	t.Run("Should fail for the addresses without name", func(t *testing.T) {
+		_, err := LookupAddr("192.0.2.72", option)
+		assert.ErrorIs(t, err, ErrNameNotFound)
+	})
+
+	t.Run("Should reject an invalid IP address", func(t *testing.T) {
+		_, err := LookupAddr("8.8.8", option)
+		assert.Error(t, err)
+	})
+}
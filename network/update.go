@@ -0,0 +1,71 @@
+package network
+
+import (
+	"dns-resolver-go/dns"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// ErrUpdateRejected is returned, wrapped in an *UpdateError, when the server did not apply an UPDATE message.
+var ErrUpdateRejected = errors.New("the update was not applied")
+
+// UpdateError describes why the server did not apply an UPDATE message, from the response code of its response.
+type UpdateError struct {
+	RCode uint16 // RCode is the response code of the response, which may be an extended one.
+}
+
+// Error returns the mnemonic and the meaning of the response code.
+func (e *UpdateError) Error() string {
+	meaning, ok := updateRCodeMeanings[e.RCode]
+	if !ok {
+		meaning = "the server returned an unexpected response code"
+	}
+	return fmt.Sprintf("%v: %s: %s", ErrUpdateRejected, dns.RCodeToString(e.RCode), meaning)
+}
+
+// Unwrap returns ErrUpdateRejected so that errors.Is can be used on an UpdateError.
+func (e *UpdateError) Unwrap() error {
+	return ErrUpdateRejected
+}
+
+// updateRCodeMeanings are the meanings of the response codes of the responses to UPDATE messages.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-2.2 for more information
+var updateRCodeMeanings = map[uint16]string{
+	uint16(dns.RCodeFormatError):    "the server could not interpret the update",
+	uint16(dns.RCodeServerFailure):  "the server failed to process the update",
+	uint16(dns.RCodeNameError):      "a name that ought to exist does not exist",
+	uint16(dns.RCodeNotImplemented): "the server does not support updates",
+	uint16(dns.RCodeRefused):        "the server refuses the update for policy reasons",
+	uint16(dns.RCodeYXDomain):       "a name that ought not to exist exists",
+	uint16(dns.RCodeYXRRSet):        "an RRset that ought not to exist exists",
+	uint16(dns.RCodeNXRRSet):        "an RRset that ought to exist does not exist",
+	uint16(dns.RCodeNotAuth):        "the server is not authoritative for the zone",
+	uint16(dns.RCodeNotZone):        "a name of the prerequisite or update section is not within the zone",
+}
+
+// SendUpdate sends the UPDATE message, see dns.NewUpdate, to the server, which should be the primary server
+// of its zone, and returns the response of the server once it applied the update.
+// If the server did not apply the update, the response is returned along with an *UpdateError
+// describing its response code, such as NXRRSET when a prerequisite is not met.
+//
+// See https://datatracker.ietf.org/doc/html/rfc2136#section-3 for more information
+func SendUpdate(server netip.AddrPort, update *dns.DNSMessage) (*dns.DNSMessage, error) {
+	if opcode := dns.HeaderFlagFromUint16(update.Header.Flags).Opcode; opcode != dns.OpcodeUpdate {
+		return nil, fmt.Errorf("not an UPDATE message: opcode %s", dns.OpcodeToString(opcode))
+	}
+	client := NewClient(server.Addr().String(), int(server.Port()))
+	response, err := exchange(client, update)
+	if err != nil {
+		return nil, err
+	}
+	flags := dns.HeaderFlagFromUint16(response.Header.Flags)
+	if !flags.QR || flags.Opcode != dns.OpcodeUpdate {
+		return nil, fmt.Errorf("the server did not answer the update: opcode %s", dns.OpcodeToString(flags.Opcode))
+	}
+	if rcode := response.RCode(); rcode != uint16(dns.RCodeNoError) {
+		return response, &UpdateError{RCode: rcode}
+	}
+	return response, nil
+}
@@ -0,0 +1,59 @@
+package network
+
+import (
+	"dns-resolver-go/dns"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdate(t *testing.T) {
+	address := *dns.NewResourceRecord("host.example.com", dns.TypeA, dns.ClassIN, 300, 4, []byte{192, 0, 2, 1})
+
+	// The server applies the updates whose prerequisite is met: host.example.com must not exist
+	var applied []dns.ResourceRecord
+	port := startTestServer(t, func(query *dns.DNSMessage) *dns.DNSMessage {
+		response := query.Reply()
+		if query.Questions[0].Name != "example.com" {
+			return response.SetRCode(uint16(dns.RCodeNotAuth))
+		}
+		for _, prerequisite := range query.Prerequisites() {
+			if prerequisite.Class == dns.ClassNone && prerequisite.Type == dns.TypeAll && len(applied) > 0 {
+				return response.SetRCode(uint16(dns.RCodeYXDomain))
+			}
+		}
+		applied = append(applied, query.Updates()...)
+		return response
+	})
+	server := netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), uint16(port))
+
+	t.Run("Should send an update and return the response of the server", func(t *testing.T) {
+		update := dns.NewUpdate("example.com").RequireNameNotInUse("host.example.com").AddRecords(address)
+		response, err := SendUpdate(server, update)
+		assert.NoError(t, err)
+		assert.Equal(t, update.Header.ID, response.Header.ID)
+		assert.Equal(t, []dns.ResourceRecord{address}, applied)
+	})
+
+	t.Run("Should interpret the response codes of the rejected updates", func(t *testing.T) {
+		update := dns.NewUpdate("example.com").RequireNameNotInUse("host.example.com").AddRecords(address)
+		response, err := SendUpdate(server, update)
+		assert.ErrorIs(t, err, ErrUpdateRejected)
+		var updateErr *UpdateError
+		assert.True(t, errors.As(err, &updateErr))
+		assert.Equal(t, uint16(dns.RCodeYXDomain), updateErr.RCode)
+		assert.EqualError(t, err, "the update was not applied: YXDOMAIN: a name that ought not to exist exists")
+		assert.Equal(t, uint16(dns.RCodeYXDomain), response.RCode())
+		assert.Len(t, applied, 1)
+
+		_, err = SendUpdate(server, dns.NewUpdate("example.org").DeleteName("host.example.org"))
+		assert.EqualError(t, err, "the update was not applied: NOTAUTH: the server is not authoritative for the zone")
+	})
+
+	t.Run("Should only send UPDATE messages", func(t *testing.T) {
+		_, err := SendUpdate(server, dns.NewQuery("example.com", dns.TypeA))
+		assert.Error(t, err)
+	})
+}